@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"postservice/cmd/provider"
+	"postservice/infrastructure/kafka"
 	"postservice/internal/api"
 	"postservice/internal/bus"
+	"postservice/internal/config"
 	database "postservice/internal/db"
+	"postservice/internal/features/reap_abandoned_posts"
+	"postservice/internal/health"
+	"postservice/internal/outbox"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -22,6 +30,10 @@ type app struct {
 	configuringTasks sync.WaitGroup
 	runningTasks     sync.WaitGroup
 	env              string
+	health           *health.Health
+	migrated         atomic.Bool
+	shutdownDelay    time.Duration
+	shutdownTracing  func(context.Context) error
 }
 
 func main() {
@@ -38,7 +50,18 @@ func main() {
 
 	log.Info().Msgf("Starting PostService service in [%s] enviroment...\n", env)
 
-	provider := provider.NewProvider(env)
+	config, err := config.Load(env, strings.TrimSpace(os.Getenv("CONFIG_FILE")))
+	if err != nil {
+		log.Error().Err(err).Msg("PostService configuration is not valid")
+		os.Exit(1)
+	}
+
+	app.shutdownDelay = config.Api.ShutdownDelay
+	provider := provider.NewProvider(config)
+	app.shutdownTracing, err = provider.ProvideTracing(ctx)
+	if err != nil {
+		os.Exit(1)
+	}
 	database, err := provider.ProvideDb(ctx)
 	if err != nil {
 		os.Exit(1)
@@ -47,15 +70,33 @@ func main() {
 	if err != nil {
 		os.Exit(1)
 	}
-	eventBus, err := provider.ProvideEventBus()
+	externalBus, err := provider.ProvideExternalBus()
 	if err != nil {
 		os.Exit(1)
 	}
+	eventBus := provider.ProvideEventBus(externalBus)
+	outboxRelay := provider.ProvideOutboxRelay(database, externalBus)
+	authenticator, err := provider.ProvideAuthenticator()
+	if err != nil {
+		os.Exit(1)
+	}
+	reaper := provider.ProvideAbandonedPostsReaper(database, objectStorage)
 	subscriptions := provider.ProvideSubscriptions()
-	apiEnpoint := provider.ProvideApiEndpoint(database, objectStorage, eventBus)
+	app.health = provider.ProvideHealth(database, objectStorage, externalBus)
+	app.health.AddCheck("migrations", app.checkMigrations)
+	apiEnpoint := provider.ProvideApiEndpoint(database, objectStorage, eventBus, app.health, authenticator)
+
+	// The Api serves the health endpoints while the service is configuring,
+	// it is not ready until the migrations finish and the service is running.
+	app.runningTasks.Add(1)
+	go app.runApiEndpoint(apiEnpoint)
 
 	app.runConfigurationTasks(database, subscriptions, eventBus)
-	app.runServerTasks(apiEnpoint)
+	kafkaConsumer, err := provider.ProvideKafkaConsumer(eventBus)
+	if err != nil {
+		os.Exit(1)
+	}
+	app.runServerTasks(reaper, outboxRelay, kafkaConsumer)
 }
 
 func (app *app) configuringLog() {
@@ -77,9 +118,15 @@ func (app *app) runConfigurationTasks(database *database.Database, subscriptions
 	app.configuringTasks.Wait()
 }
 
-func (app *app) runServerTasks(apiEnpoint *api.Api) {
-	app.runningTasks.Add(1)
-	go app.runApiEndpoint(apiEnpoint)
+func (app *app) runServerTasks(reaper *reap_abandoned_posts.ReapAbandonedPostsService, outboxRelay *outbox.Relay, kafkaConsumer *kafka.KafkaConsumer) {
+	app.runningTasks.Add(2)
+	go app.runAbandonedPostsReaper(reaper)
+	go app.runOutboxRelay(outboxRelay)
+	if kafkaConsumer != nil {
+		app.runningTasks.Add(1)
+		go app.runKafkaConsumer(kafkaConsumer)
+	}
+	app.health.SetRunning()
 
 	blockForever()
 
@@ -93,9 +140,17 @@ func (app *app) applyMigrations(database *database.Database) {
 	if err != nil {
 		log.Panic().Err(err).Msg("Migrations failed")
 	}
+	app.migrated.Store(true)
 	log.Info().Msg("Migrations finished")
 }
 
+func (app *app) checkMigrations(ctx context.Context) error {
+	if !app.migrated.Load() {
+		return errors.New("migrations not finished")
+	}
+	return nil
+}
+
 func (app *app) subcribeEvents(subscriptions *[]bus.EventSubscription, eventBus *bus.EventBus) {
 	defer app.configuringTasks.Done()
 
@@ -119,6 +174,30 @@ func (app *app) runApiEndpoint(apiEnpoint *api.Api) {
 	log.Info().Msg("PostService Api stopped")
 }
 
+func (app *app) runAbandonedPostsReaper(reaper *reap_abandoned_posts.ReapAbandonedPostsService) {
+	defer app.runningTasks.Done()
+
+	reaper.Run(app.ctx)
+	log.Info().Msgf("Abandoned posts reaper stopped after reaping %d posts", reaper.ReapedPosts())
+}
+
+func (app *app) runOutboxRelay(outboxRelay *outbox.Relay) {
+	defer app.runningTasks.Done()
+
+	outboxRelay.Run(app.ctx)
+	log.Info().Msg("Outbox relay stopped")
+}
+
+func (app *app) runKafkaConsumer(kafkaConsumer *kafka.KafkaConsumer) {
+	defer app.runningTasks.Done()
+
+	err := kafkaConsumer.Run(app.ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Kafka consumer failed")
+	}
+	log.Info().Msg("Kafka consumer stopped")
+}
+
 func blockForever() {
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
@@ -126,8 +205,21 @@ func blockForever() {
 }
 
 func (app *app) shutdown() {
+	app.health.SetStopping()
+	log.Info().Msgf("Shutting down PostService Service in %s...", app.shutdownDelay)
+	time.Sleep(app.shutdownDelay)
 	app.cancel()
-	log.Info().Msg("Shutting down PostService Service...")
 	app.runningTasks.Wait()
+	app.flushTraces()
 	log.Info().Msg("PostService Service stopped")
 }
+
+func (app *app) flushTraces() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := app.shutdownTracing(ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Flushing traces failed")
+	}
+}
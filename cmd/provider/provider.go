@@ -2,118 +2,237 @@ package provider
 
 import (
 	"context"
+	"errors"
 	awsClients "postservice/infrastructure/aws"
+	"postservice/infrastructure/filesystem"
 	"postservice/infrastructure/kafka"
+	"postservice/infrastructure/memory"
 	"postservice/internal/api"
 	"postservice/internal/bus"
+	"postservice/internal/config"
 	database "postservice/internal/db"
 	"postservice/internal/features/create_post"
 	"postservice/internal/features/delete_post"
 	"postservice/internal/features/get_post"
+	"postservice/internal/features/reap_abandoned_posts"
+	"postservice/internal/features/update_post"
+	"postservice/internal/health"
+	"postservice/internal/metrics"
 	objectstorage "postservice/internal/objectStorage"
+	"postservice/internal/outbox"
+	"postservice/internal/tracing"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/rs/zerolog/log"
 )
 
 type Provider struct {
-	env string
+	config *config.Config
 }
 
-func NewProvider(env string) *Provider {
+func NewProvider(config *config.Config) *Provider {
 	return &Provider{
-		env: env,
+		config: config,
 	}
 }
 
-func (p *Provider) ProvideEventBus() (*bus.EventBus, error) {
-	kafkaProducer, err := kafka.NewKafkaProducer(p.kafkaBrokers())
+// ProvideTracing installs the configured span exporter and returns the
+// function flushing it on shutdown.
+func (p *Provider) ProvideTracing(ctx context.Context) (func(context.Context) error, error) {
+	shutdown, err := tracing.Setup(p.config.Tracing, p.config.Environment, ctx)
 	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error creating %s trace exporter", p.config.Tracing.Exporter)
 		return nil, err
 	}
 
-	return bus.NewEventBus(kafkaProducer), nil
+	return shutdown, nil
+}
+
+func (p *Provider) ProvideExternalBus() (bus.ExternalBus, error) {
+	externalBus, err := p.provideExternalBus()
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics.NewInstrumentedExternalBus(externalBus), nil
+}
+
+func (p *Provider) provideExternalBus() (bus.ExternalBus, error) {
+	switch p.config.Bus.Driver {
+	case config.BusDriverMemory:
+		log.Warn().Msg("Using the in-memory bus, events will only reach this process")
+		return memory.NewEventBroker(), nil
+	case config.BusDriverFile:
+		log.Warn().Msgf("Using the file bus, events will be appended to %s", p.config.EventLog.Path)
+		return filesystem.NewEventLog(p.config.EventLog.Path)
+	default:
+		return kafka.NewKafkaProducer(p.config.Kafka)
+	}
+}
+
+func (p *Provider) ProvideEventBus(externalBus bus.ExternalBus) *bus.EventBus {
+	eventBus := bus.NewEventBus()
+	if broker, ok := unwrap(externalBus).(*memory.EventBroker); ok {
+		broker.DeliverTo(eventBus)
+	}
+
+	return eventBus
+}
+
+func (p *Provider) ProvideOutboxRelay(database *database.Database, externalBus bus.ExternalBus) *outbox.Relay {
+	return outbox.NewRelay(database, externalBus, p.config.Outbox.RelayInterval, p.config.Outbox.RelayMaxBackoff, p.config.Outbox.MaxAttempts)
 }
 
 func (p *Provider) ProvideSubscriptions() *[]bus.EventSubscription {
-	return &[]bus.EventSubscription{
-		{},
+	return &[]bus.EventSubscription{}
+}
+
+func (p *Provider) ProvideKafkaConsumer(eventBus *bus.EventBus) (*kafka.KafkaConsumer, error) {
+	if p.config.Bus.Driver != config.BusDriverKafka {
+		return nil, nil
+	}
+
+	topics := eventBus.SubscribedEventTypes()
+	if len(topics) == 0 {
+		log.Warn().Msg("No events subscribed, Kafka consumer will not be started")
+		return nil, nil
 	}
+
+	return kafka.NewKafkaConsumer(p.config.Kafka, topics, eventBus)
 }
 
-func (p *Provider) ProvideApiEndpoint(database *database.Database, objectRepository *objectstorage.ObjectStorage, bus *bus.EventBus) *api.Api {
-	return api.NewApiEndpoint(p.env, p.ProvideApiControllers(database, objectRepository, bus))
+func (p *Provider) ProvideAuthenticator() (api.Authenticator, error) {
+	jwtConfig := api.JwtConfig{
+		HmacSecret:       p.config.Jwt.HmacSecret,
+		RsaPublicKeyFile: p.config.Jwt.RsaPublicKeyFile,
+		JwksFile:         p.config.Jwt.JwksFile,
+		Issuer:           p.config.Jwt.Issuer,
+		Audience:         p.config.Jwt.Audience,
+		UsernameClaim:    p.config.Jwt.UsernameClaim,
+	}
+
+	authenticator, err := api.NewJwtAuthenticator(jwtConfig)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("failed to configure JWT authentication")
+		return nil, err
+	}
+
+	return authenticator, nil
+}
+
+// ProvideHealth checks every dependency whose client knows how to probe it.
+func (p *Provider) ProvideHealth(database *database.Database, objectRepository *objectstorage.ObjectStorage, externalBus bus.ExternalBus) *health.Health {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("database", func(ctx context.Context) error {
+		if !database.Client.TableExists("Posts", ctx) {
+			return errors.New("table Posts not found")
+		}
+		return nil
+	})
+	if checker, ok := unwrap(objectRepository.Client).(health.Checker); ok {
+		serviceHealth.AddCheck("objectStorage", checker.HealthCheck)
+	}
+	if checker, ok := unwrap(externalBus).(health.Checker); ok {
+		serviceHealth.AddCheck("bus", checker.HealthCheck)
+	}
+
+	return serviceHealth
+}
+
+func (p *Provider) ProvideApiEndpoint(database *database.Database, objectRepository *objectstorage.ObjectStorage, bus *bus.EventBus, serviceHealth *health.Health, authenticator api.Authenticator) *api.Api {
+	return api.NewApiEndpoint(p.config.Environment, p.config.Api.Port, serviceHealth, authenticator, api.NewDatabaseIdempotencyStore(database), p.config.Api.IdempotencyKeyTtl, p.ProvideApiControllers(database, objectRepository, bus), p.ProvidePublicApiControllers(objectRepository))
+}
+
+// ProvidePublicApiControllers returns the controllers that do their own
+// authorization, such as the one serving the signed URLs of a local object
+// storage.
+func (p *Provider) ProvidePublicApiControllers(objectRepository *objectstorage.ObjectStorage) []api.Controller {
+	controllers := []api.Controller{}
+	if store, ok := unwrap(objectRepository.Client).(api.LocalObjectStore); ok {
+		controllers = append(controllers, api.NewSignedObjectsController(store, p.config.ObjectStorage.MaxObjectSize))
+	}
+
+	return controllers
 }
 
 func (p *Provider) ProvideApiControllers(database *database.Database, objectRepository *objectstorage.ObjectStorage, bus *bus.EventBus) []api.Controller {
 	return []api.Controller{
-		create_post.NewCreatePostController(create_post.NewCreatePostService(create_post.NewCreatePostRepository(database, objectRepository), bus), bus),
+		create_post.NewCreatePostController(create_post.NewCreatePostService(create_post.NewCreatePostRepository(database, objectRepository)), bus),
 		get_post.NewGetPostController(get_post.NewGetPostRepository(database, objectRepository)),
-		delete_post.NewDeletePostController(delete_post.NewDeletePostRepository(database, objectRepository), bus),
+		delete_post.NewDeletePostController(delete_post.NewDeletePostRepository(database, objectRepository)),
+		update_post.NewUpdatePostController(update_post.NewUpdatePostRepository(database)),
 	}
 }
 
-func (p *Provider) ProvideDb(ctx context.Context) (*database.Database, error) {
-	var cfg aws.Config
-	var err error
+func (p *Provider) ProvideAbandonedPostsReaper(database *database.Database, objectRepository *objectstorage.ObjectStorage) *reap_abandoned_posts.ReapAbandonedPostsService {
+	return reap_abandoned_posts.NewReapAbandonedPostsService(reap_abandoned_posts.NewReapAbandonedPostsRepository(database, objectRepository), p.config.Reaper.AbandonedPostsTtl, p.config.Reaper.Interval)
+}
 
-	if p.env == "development" {
-		cfg, err = provideDevEnvironmentDbConfig(ctx, "8000")
-	} else {
-		cfg, err = provideAwsConfig(ctx)
+func (p *Provider) ProvideDb(ctx context.Context) (*database.Database, error) {
+	if p.config.Database.Driver == config.DatabaseDriverMemory {
+		log.Warn().Msg("Using the in-memory database, data will be lost on shutdown")
+		return database.NewDatabase(metrics.NewInstrumentedDatabaseClient(memory.NewDatabaseClient())), nil
 	}
+
+	cfg, err := p.provideAwsConfig(ctx, p.config.DynamoDB.Endpoint)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load aws configuration")
 		return nil, err
 	}
 
-	return database.NewDatabase(awsClients.NewDynamodbClient(cfg)), nil
+	return database.NewDatabase(metrics.NewInstrumentedDatabaseClient(awsClients.NewDynamodbClient(cfg, p.config.Database))), nil
 }
 
 func (p *Provider) ProvideObjectStorage(ctx context.Context) (*objectstorage.ObjectStorage, error) {
-	var cfg aws.Config
-	var err error
-
-	if p.env == "development" {
-		cfg, err = provideDevEnvironmentDbConfig(ctx, "4566")
-	} else {
-		cfg, err = provideAwsConfig(ctx)
+	if p.config.ObjectStorage.Driver == config.ObjectStorageDriverFilesystem {
+		log.Warn().Msgf("Using the filesystem object storage under %s", p.config.Filesystem.Directory)
+		objectsUrl := strings.TrimSuffix(p.config.Filesystem.BaseUrl, "/") + "/" + p.config.Environment + "/postservice/objects"
+		client, err := filesystem.NewFilesystemClient(p.config.ObjectStorage, p.config.Filesystem, objectsUrl)
+		if err != nil {
+			return nil, err
+		}
+		return objectstorage.NewObjectStorage(metrics.NewInstrumentedObjectStorageClient(client)), nil
 	}
+
+	cfg, err := p.provideAwsConfig(ctx, p.config.S3.Endpoint)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load aws configuration")
 		return nil, err
 	}
 
-	return objectstorage.NewObjectStorage(awsClients.NewS3Client(cfg, "artis-bucket-2")), nil
+	return objectstorage.NewObjectStorage(metrics.NewInstrumentedObjectStorageClient(awsClients.NewS3Client(cfg, p.config.ObjectStorage, p.config.S3))), nil
 }
 
-func (p *Provider) kafkaBrokers() []string {
-	if p.env == "development" {
-		return []string{
-			"localhost:9093",
-		}
-	} else {
-		return []string{
-			"172.31.0.242:9092",
-			"172.31.7.110:9092",
-		}
+// unwrap returns the client under a metrics decorator, to look for the
+// capabilities the decorator does not expose.
+func unwrap[T any](client T) T {
+	if decorator, ok := any(client).(interface{ Unwrap() T }); ok {
+		return decorator.Unwrap()
 	}
+	return client
 }
 
-func provideAwsConfig(ctx context.Context) (aws.Config, error) {
-	return config.LoadDefaultConfig(ctx, config.WithRegion("eu-west-3"))
-}
+// provideAwsConfig points the AWS clients to endpoint when it is set, using
+// the mock credentials accepted by the local emulators. Every AWS call is
+// traced.
+func (p *Provider) provideAwsConfig(ctx context.Context, endpoint string) (aws.Config, error) {
+	apiOptions := awsConfig.WithAPIOptions([]func(*middleware.Stack) error{tracing.AwsMiddleware})
+	if endpoint == "" {
+		return awsConfig.LoadDefaultConfig(ctx, awsConfig.WithRegion(p.config.Aws.Region), apiOptions)
+	}
 
-func provideDevEnvironmentDbConfig(ctx context.Context, port string) (aws.Config, error) {
-	return config.LoadDefaultConfig(ctx,
-		config.WithRegion("localhost"),
-		config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+	return awsConfig.LoadDefaultConfig(ctx,
+		awsConfig.WithRegion(p.config.Aws.Region),
+		apiOptions,
+		awsConfig.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
 			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-				return aws.Endpoint{URL: "http://localhost:" + port}, nil
+				return aws.Endpoint{URL: endpoint}, nil
 			})),
-		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+		awsConfig.WithCredentialsProvider(credentials.StaticCredentialsProvider{
 			Value: aws.Credentials{
 				AccessKeyID: "abcd", SecretAccessKey: "a1b2c3", SessionToken: "",
 				Source: "Mock credentials used above for local instance",
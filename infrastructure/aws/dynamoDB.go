@@ -3,31 +3,45 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"postservice/internal/config"
 	database "postservice/internal/db"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/rs/zerolog/log"
 )
 
+// maxBatchGetKeys is the most keys DynamoDB reads in a single BatchGetItem.
+const maxBatchGetKeys = 100
+
 type DynamoDBClient struct {
-	client *dynamodb.Client
+	client           *dynamodb.Client
+	operationTimeout time.Duration
 }
 
-func NewDynamodbClient(config aws.Config) *DynamoDBClient {
+func NewDynamodbClient(awsConfig aws.Config, databaseConfig config.DatabaseConfig) *DynamoDBClient {
 	return &DynamoDBClient{
-		client: dynamodb.NewFromConfig(config),
+		client:           dynamodb.NewFromConfig(awsConfig),
+		operationTimeout: databaseConfig.OperationTimeout,
 	}
 }
 
-func (dc *DynamoDBClient) TableExists(tableName string) bool {
+func (dc *DynamoDBClient) TableExists(tableName string, ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
 	exists := true
 	_, err := dc.client.DescribeTable(
-		context.TODO(), &dynamodb.DescribeTableInput{TableName: aws.String(tableName)},
+		ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)},
 	)
 	if err != nil {
 		var notFoundEx *types.ResourceNotFoundException
@@ -40,10 +54,17 @@ func (dc *DynamoDBClient) TableExists(tableName string) bool {
 	return exists
 }
 
-func (dc *DynamoDBClient) IndexExists(tableName, indexName string) bool {
-	result, _ := dc.client.DescribeTable(
-		context.TODO(), &dynamodb.DescribeTableInput{TableName: aws.String(tableName)},
+func (dc *DynamoDBClient) IndexExists(tableName, indexName string, ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	result, err := dc.client.DescribeTable(
+		ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)},
 	)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't determine existence of index %s on table %s", indexName, tableName)
+		return false
+	}
 
 	indexExists := false
 	for _, gsi := range result.Table.GlobalSecondaryIndexes {
@@ -127,35 +148,121 @@ func (dc *DynamoDBClient) CreateIndexesOnTable(tableName, indexName string, inde
 	return nil
 }
 
-func (dc *DynamoDBClient) InsertData(tableName string, attributes any) error {
+func (dc *DynamoDBClient) EnableTimeToLive(tableName, attributeName string, ctx context.Context) error {
+	_, err := dc.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(attributeName),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't enable time to live on table %s", tableName)
+		return err
+	}
+
+	log.Info().Msgf("Time to live enabled on table %s with attribute %s\n", tableName, attributeName)
+	return nil
+}
+
+func (dc *DynamoDBClient) InsertData(tableName string, attributes any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
 	item, err := attributevalue.MarshalMap(attributes)
 	if err != nil {
 		return err
 	}
 
-	_, err = dc.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+	_, err = dc.client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(tableName), Item: item,
 	})
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't put item %v from table %s", item, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) InsertDataIfNotExists(tableName, keyAttribute string, attributes any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	item, err := attributevalue.MarshalMap(attributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = dc.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+		ExpressionAttributeNames: map[string]string{"#key": keyAttribute},
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = database.NewConflictError(tableName, keyAttribute, "item already exists")
+			log.Warn().Err(err).Msgf("Item already exists in table %s", tableName)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't put item %v from table %s", item, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) InsertDataIfMatches(tableName string, attributes any, conditions map[string]any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	item, err := attributevalue.MarshalMap(attributes)
+	if err != nil {
+		return err
+	}
+	conditionExpression, names, values, err := buildMatchCondition(conditions)
+	if err != nil {
 		return err
 	}
 
+	_, err = dc.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(conditionExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = database.NewConflictError(tableName, conditions, "stored item does not match")
+			log.Warn().Err(err).Msgf("Item of table %s was not replaced", tableName)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't put item %v from table %s", item, tableName)
+		return unavailableIfTransient(err)
+	}
+
 	return nil
 }
 
-func (dc *DynamoDBClient) GetData(tableName string, key any, result any) error {
+func (dc *DynamoDBClient) GetData(tableName string, key any, result any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
 	k, err := attributevalue.MarshalMap(key)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
 	}
 
-	response, err := dc.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+	response, err := dc.client.GetItem(ctx, &dynamodb.GetItemInput{
 		Key: k, TableName: aws.String(tableName),
 	})
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't get info about %s", tableName)
-		return err
+		return unavailableIfTransient(err)
 	}
 	if response.Item == nil {
 		err = database.NewNotFoundError(tableName, key)
@@ -172,24 +279,175 @@ func (dc *DynamoDBClient) GetData(tableName string, key any, result any) error {
 	return nil
 }
 
-func (dc *DynamoDBClient) RemoveData(tableName string, key any) error {
+func (dc *DynamoDBClient) UpdateData(tableName string, key any, attributes map[string]any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
+		return err
+	}
+
+	updateExpression, conditionExpression, names, values, err := buildUpdateExpressions(k, attributes, nil)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v attributes to AttributeValues", attributes)
+		return err
+	}
+
+	_, err = dc.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       k,
+		UpdateExpression:          aws.String(updateExpression),
+		ConditionExpression:       aws.String(conditionExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = database.NewNotFoundError(tableName, key)
+			log.Error().Stack().Err(err).Msgf("Item %v was not found", key)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't update item %v from table %s", key, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) UpdateDataIfVersion(tableName string, key any, attributes map[string]any, expectedVersion int, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
+		return err
+	}
+
+	updateExpression, conditionExpression, names, values, err := buildUpdateExpressions(k, attributes, &expectedVersion)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v attributes to AttributeValues", attributes)
+		return err
+	}
+
+	_, err = dc.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           aws.String(tableName),
+		Key:                                 k,
+		UpdateExpression:                    aws.String(updateExpression),
+		ConditionExpression:                 aws.String(conditionExpression),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = conditionFailedError(tableName, key, conditionFailedEx.Item, expectedVersion)
+			log.Error().Stack().Err(err).Msgf("Couldn't update item %v", key)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't update item %v from table %s", key, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) RemoveData(tableName string, key any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
 	k, err := attributevalue.MarshalMap(key)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
 	}
 
-	_, err = dc.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+	_, err = dc.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: aws.String(tableName), Key: k,
 	})
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't remove item %v from table %s", key, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) RemoveDataIfVersion(tableName string, key any, expectedVersion int, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
+		return err
+	}
+
+	conditionExpression, names, values := buildVersionCondition(k, expectedVersion)
+	_, err = dc.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                           aws.String(tableName),
+		Key:                                 k,
+		ConditionExpression:                 aws.String(conditionExpression),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = conditionFailedError(tableName, key, conditionFailedEx.Item, expectedVersion)
+			log.Error().Stack().Err(err).Msgf("Couldn't remove item %v", key)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't remove item %v from table %s", key, tableName)
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) RemoveDataIfMatches(tableName string, key any, conditions map[string]any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
 		return err
 	}
+	conditionExpression, names, values, err := buildMatchCondition(conditions)
+	if err != nil {
+		return err
+	}
+
+	_, err = dc.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       k,
+		ConditionExpression:       aws.String(conditionExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailedEx *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailedEx) {
+			err = database.NewConflictError(tableName, key, "stored item does not match")
+			log.Warn().Err(err).Msgf("Item %v was not removed", key)
+			return err
+		}
+		log.Error().Stack().Err(err).Msgf("Couldn't remove item %v from table %s", key, tableName)
+		return unavailableIfTransient(err)
+	}
 
 	return nil
 }
 
-func (dc *DynamoDBClient) RemoveMultipleData(tableName string, keys []any) error {
+func (dc *DynamoDBClient) RemoveMultipleData(tableName string, keys []any, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
 	writeRequests := make([]types.WriteRequest, len(keys))
 	for i, key := range keys {
 		k, err := attributevalue.MarshalMap(key)
@@ -209,16 +467,77 @@ func (dc *DynamoDBClient) RemoveMultipleData(tableName string, keys []any) error
 		},
 	}
 
-	_, err := dc.client.BatchWriteItem(context.TODO(), input)
+	_, err := dc.client.BatchWriteItem(ctx, input)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Failed to batch delete items %v from table %s", keys, tableName)
-		return err
+		return unavailableIfTransient(err)
+	}
+
+	return nil
+}
+
+func (dc *DynamoDBClient) ExecuteTransaction(operations []database.TransactionOperation, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	transactItems := make([]types.TransactWriteItem, len(operations))
+	for i, operation := range operations {
+		transactItem, err := mapTransactionOperation(operation)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Couldn't map transaction operation %v", operation)
+			return err
+		}
+		transactItems[i] = *transactItem
+	}
+
+	_, err := dc.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var transactionCanceledEx *types.TransactionCanceledException
+		if errors.As(err, &transactionCanceledEx) {
+			for i, reason := range transactionCanceledEx.CancellationReasons {
+				if aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+					continue
+				}
+				switch operation := operations[i].(type) {
+				case *database.UpdateOperation:
+					if operation.ExpectedVersion == nil {
+						err = database.NewNotFoundError(operation.TableName, operation.Key)
+					} else {
+						err = conditionFailedError(operation.TableName, operation.Key, reason.Item, *operation.ExpectedVersion)
+					}
+					log.Error().Stack().Err(err).Msgf("Couldn't update item %v", operation.Key)
+					return err
+				case *database.RemoveOperation:
+					err = conditionFailedError(operation.TableName, operation.Key, reason.Item, *operation.ExpectedVersion)
+					log.Error().Stack().Err(err).Msgf("Couldn't remove item %v", operation.Key)
+					return err
+				}
+			}
+		}
+		log.Error().Stack().Err(err).Msg("Couldn't execute transaction")
+		return unavailableIfTransient(err)
 	}
 
 	return nil
 }
 
-func (dc *DynamoDBClient) GetPostsByIds(postIds []string) ([]*database.Post, error) {
+// GetPostsByIds reads the posts in batches of at most maxBatchGetKeys keys.
+func (dc *DynamoDBClient) GetPostsByIds(postIds []string, ctx context.Context) ([]*database.Post, error) {
+	var posts []*database.Post
+	for start := 0; start < len(postIds); start += maxBatchGetKeys {
+		batchPosts, err := dc.getPostsBatch(postIds[start:min(start+maxBatchGetKeys, len(postIds))], ctx)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, batchPosts...)
+	}
+
+	return posts, nil
+}
+
+func (dc *DynamoDBClient) getPostsBatch(postIds []string, ctx context.Context) ([]*database.Post, error) {
 	keys := make([]map[string]types.AttributeValue, len(postIds))
 	for i, postId := range postIds {
 		keys[i] = map[string]types.AttributeValue{
@@ -234,84 +553,363 @@ func (dc *DynamoDBClient) GetPostsByIds(postIds []string) ([]*database.Post, err
 		},
 	}
 
-	result, err := dc.client.BatchGetItem(context.TODO(), input)
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("failed to batch get items")
-		return nil, err
-	}
-
 	var posts []*database.Post
-	if result.Responses != nil {
-		err = attributevalue.UnmarshalListOfMaps(result.Responses["Posts"], &posts)
+	for len(input.RequestItems) > 0 {
+		callCtx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+		result, err := dc.client.BatchGetItem(callCtx, input)
+		cancel()
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("failed to batch get items")
+			return nil, unavailableIfTransient(err)
+		}
+
+		var batchPosts []*database.Post
+		err = attributevalue.UnmarshalListOfMaps(result.Responses["Posts"], &batchPosts)
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("failed to unmarshal dynamoDB response")
 			return nil, err
 		}
+		posts = append(posts, batchPosts...)
+
+		input.RequestItems = result.UnprocessedKeys
 	}
 
 	return posts, nil
 }
 
-func (dc *DynamoDBClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int) ([]*database.Post, string, string, error) {
+func (dc *DynamoDBClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	return dc.getPostsByIndex("UserIndex", "User", username, lastPostId, lastPostCreatedAt, limit, true, ctx)
+}
+
+func (dc *DynamoDBClient) GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	return dc.getPostsByIndex("TypeIndex", "Type", postType, lastPostId, lastPostCreatedAt, limit, false, ctx)
+}
+
+func (dc *DynamoDBClient) GetPostsByStatusCreatedBefore(status, createdBefore string, ctx context.Context) ([]*database.Post, error) {
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String("Posts"),
-		IndexName:              aws.String("UserIndex"),
-		KeyConditionExpression: aws.String("#user = :user"),
+		IndexName:              aws.String("StatusIndex"),
+		KeyConditionExpression: aws.String("#status = :status AND #createdAt < :createdBefore"),
 		ExpressionAttributeNames: map[string]string{
-			"#user": "User",
+			"#status":    "Status",
+			"#createdAt": "CreatedAt",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":user": &types.AttributeValueMemberS{Value: username},
+			":status":        &types.AttributeValueMemberS{Value: status},
+			":createdBefore": &types.AttributeValueMemberS{Value: createdBefore},
 		},
-		Limit: aws.Int32(int32(limit)),
+		ScanIndexForward: aws.Bool(true),
 	}
 
-	if lastPostId != "" {
-		input.ExclusiveStartKey = map[string]types.AttributeValue{
-			"User":      &types.AttributeValueMemberS{Value: username},
-			"PostId":    &types.AttributeValueMemberS{Value: lastPostId},
-			"CreatedAt": &types.AttributeValueMemberS{Value: lastPostCreatedAt},
+	var posts []*database.Post
+	paginator := dynamodb.NewQueryPaginator(dc.client, input)
+	for paginator.HasMorePages() {
+		pageCtx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+		response, err := paginator.NextPage(pageCtx)
+		cancel()
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Couldn't query Posts with status %s", status)
+			return nil, unavailableIfTransient(err)
+		}
+
+		var pagePosts []*database.Post
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &pagePosts)
+		if err != nil {
+			log.Error().Stack().Err(err).Msg("Couldn't unmarshal response")
+			return nil, err
 		}
+		posts = append(posts, pagePosts...)
 	}
 
-	response, err := dc.client.Query(context.TODO(), input)
+	return posts, nil
+}
+
+func (dc *DynamoDBClient) GetPendingOutboxEvents(limit int, ctx context.Context) ([]*database.OutboxEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+	defer cancel()
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String("Outbox"),
+		IndexName:              aws.String("StatusIndex"),
+		KeyConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "Status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: database.OutboxStatusPending},
+		},
+		Limit:            aws.Int32(int32(limit)),
+		ScanIndexForward: aws.Bool(true),
+	}
+
+	response, err := dc.client.Query(ctx, input)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("Couldn't get info about Posts")
-		return nil, "", "", err
+		log.Error().Stack().Err(err).Msg("Couldn't get pending Outbox events")
+		return nil, unavailableIfTransient(err)
+	}
+
+	var events []*database.OutboxEvent
+	err = attributevalue.UnmarshalListOfMaps(response.Items, &events)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Couldn't unmarshal response")
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// getPostsByIndex keeps querying until it has limit published posts or the
+// index partition ends, as DynamoDB applies the limit before the filter and
+// can return short or empty pages with a last evaluated key.
+func (dc *DynamoDBClient) getPostsByIndex(indexName, partitionKey, partitionValue, lastPostId, lastPostCreatedAt string, limit int, scanForward bool, ctx context.Context) ([]*database.Post, string, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String("Posts"),
+		IndexName:              aws.String(indexName),
+		KeyConditionExpression: aws.String("#partitionKey = :partitionValue"),
+		FilterExpression:       aws.String("attribute_not_exists(#status) OR #status = :published"),
+		ExpressionAttributeNames: map[string]string{
+			"#partitionKey": partitionKey,
+			"#status":       "Status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":partitionValue": &types.AttributeValueMemberS{Value: partitionValue},
+			":published":      &types.AttributeValueMemberS{Value: database.PostStatusPublished},
+		},
+		ScanIndexForward: aws.Bool(scanForward),
+	}
+
+	if lastPostId != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			partitionKey: &types.AttributeValueMemberS{Value: partitionValue},
+			"PostId":     &types.AttributeValueMemberS{Value: lastPostId},
+			"CreatedAt":  &types.AttributeValueMemberS{Value: lastPostCreatedAt},
+		}
 	}
 
 	var results []*database.Post
-	for _, item := range response.Items {
-		var result database.Post
-		err = attributevalue.UnmarshalMap(item, &result)
+	for {
+		input.Limit = aws.Int32(int32(limit - len(results)))
+		pageCtx, cancel := context.WithTimeout(ctx, dc.operationTimeout)
+		response, err := dc.client.Query(pageCtx, input)
+		cancel()
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Couldn't get info about Posts from index %s", indexName)
+			return nil, "", "", unavailableIfTransient(err)
+		}
+
+		var pagePosts []*database.Post
+		err = attributevalue.UnmarshalListOfMaps(response.Items, &pagePosts)
 		if err != nil {
 			log.Error().Stack().Err(err).Msg("Couldn't unmarshal response")
 			return nil, "", "", err
 		}
+		results = append(results, pagePosts...)
 
-		if result.PostId == lastPostId {
-			continue
+		if response.LastEvaluatedKey == nil {
+			return results, "", "", nil
 		}
+		// The limit only leaves room for the posts still missing, so a full
+		// page ends with the last post evaluated.
+		if len(results) >= limit {
+			lastItem := response.Items[len(response.Items)-1]
+			return results, stringAttribute(lastItem, "PostId"), stringAttribute(lastItem, "CreatedAt"), nil
+		}
+		input.ExclusiveStartKey = response.LastEvaluatedKey
+	}
+}
 
-		results = append(results, &result)
+func stringAttribute(attributes map[string]types.AttributeValue, name string) string {
+	if value, ok := attributes[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
 	}
+	return ""
+}
 
-	lastPostId = ""
-	lastPostCreatedAt = ""
-	if response.LastEvaluatedKey != nil {
-		if val, ok := response.LastEvaluatedKey["PostId"]; ok {
-			if postId, ok := val.(*types.AttributeValueMemberS); ok {
-				lastPostId = postId.Value
-			}
+// unavailableIfTransient wraps the errors a later retry could get past, like
+// throttling or timeouts, so callers can tell them apart from failed requests.
+func unavailableIfTransient(err error) error {
+	var throughputExceededEx *types.ProvisionedThroughputExceededException
+	var requestLimitEx *types.RequestLimitExceeded
+	var internalServerEx *types.InternalServerError
+	var requestSendErr *smithyhttp.RequestSendError
+	if errors.As(err, &throughputExceededEx) || errors.As(err, &requestLimitEx) || errors.As(err, &internalServerEx) ||
+		errors.As(err, &requestSendErr) || errors.Is(err, context.DeadlineExceeded) {
+		return database.NewUnavailableError(err)
+	}
+	return err
+}
+
+func buildUpdateExpressions(key map[string]types.AttributeValue, attributes map[string]any, expectedVersion *int) (string, string, map[string]string, map[string]types.AttributeValue, error) {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	attributeNames := make([]string, 0, len(attributes))
+	for name := range attributes {
+		attributeNames = append(attributeNames, name)
+	}
+	sort.Strings(attributeNames)
+
+	setClauses := make([]string, len(attributeNames))
+	for i, name := range attributeNames {
+		value, err := attributevalue.Marshal(attributes[name])
+		if err != nil {
+			return "", "", nil, nil, err
 		}
-		if val, ok := response.LastEvaluatedKey["CreatedAt"]; ok {
-			if postCreatedAt, ok := val.(*types.AttributeValueMemberS); ok {
-				lastPostCreatedAt = postCreatedAt.Value
-			}
+		placeholder := strconv.Itoa(i)
+		names["#attr"+placeholder] = name
+		values[":value"+placeholder] = value
+		setClauses[i] = "#attr" + placeholder + " = :value" + placeholder
+	}
+
+	keyNames := make([]string, 0, len(key))
+	for name := range key {
+		keyNames = append(keyNames, name)
+	}
+	sort.Strings(keyNames)
+
+	conditions := make([]string, len(keyNames))
+	for i, name := range keyNames {
+		placeholder := "#key" + strconv.Itoa(i)
+		names[placeholder] = name
+		conditions[i] = "attribute_exists(" + placeholder + ")"
+	}
+
+	if expectedVersion != nil {
+		names["#version"] = database.VersionAttribute
+		values[":nextVersion"] = &types.AttributeValueMemberN{Value: strconv.Itoa(*expectedVersion + 1)}
+		setClauses = append(setClauses, "#version = :nextVersion")
+		conditions = append(conditions, versionCondition(*expectedVersion, values))
+	}
+
+	return "SET " + strings.Join(setClauses, ", "), strings.Join(conditions, " AND "), names, values, nil
+}
+
+// buildVersionCondition matches an existing item with the expected version.
+func buildVersionCondition(key map[string]types.AttributeValue, expectedVersion int) (string, map[string]string, map[string]types.AttributeValue) {
+	names := map[string]string{"#version": database.VersionAttribute}
+	values := map[string]types.AttributeValue{}
+	conditions := []string{}
+	keyNames := make([]string, 0, len(key))
+	for name := range key {
+		keyNames = append(keyNames, name)
+	}
+	sort.Strings(keyNames)
+	for i, name := range keyNames {
+		placeholder := "#key" + strconv.Itoa(i)
+		names[placeholder] = name
+		conditions = append(conditions, "attribute_exists("+placeholder+")")
+	}
+	conditions = append(conditions, versionCondition(expectedVersion, values))
+	if len(values) == 0 {
+		values = nil
+	}
+
+	return strings.Join(conditions, " AND "), names, values
+}
+
+// buildMatchCondition requires every attribute of conditions to have its value,
+// which fails when there is no stored item.
+func buildMatchCondition(conditions map[string]any) (string, map[string]string, map[string]types.AttributeValue, error) {
+	attributeNames := make([]string, 0, len(conditions))
+	for name := range conditions {
+		attributeNames = append(attributeNames, name)
+	}
+	sort.Strings(attributeNames)
+
+	names := make(map[string]string, len(conditions))
+	values := make(map[string]types.AttributeValue, len(conditions))
+	expressions := make([]string, len(attributeNames))
+	for i, name := range attributeNames {
+		value, err := attributevalue.Marshal(conditions[name])
+		if err != nil {
+			return "", nil, nil, err
 		}
+		placeholder := strconv.Itoa(i)
+		names["#condition"+placeholder] = name
+		values[":condition"+placeholder] = value
+		expressions[i] = "#condition" + placeholder + " = :condition" + placeholder
 	}
 
-	return results, lastPostId, lastPostCreatedAt, nil
+	return strings.Join(expressions, " AND "), names, values, nil
+}
+
+// Items written before versioning have no version attribute and match version 0.
+func versionCondition(expectedVersion int, values map[string]types.AttributeValue) string {
+	if expectedVersion == 0 {
+		return "attribute_not_exists(#version)"
+	}
+	values[":expectedVersion"] = &types.AttributeValueMemberN{Value: strconv.Itoa(expectedVersion)}
+	return "#version = :expectedVersion"
+}
+
+// conditionFailedError tells a missing item apart from one whose version
+// doesn't match, using the item returned by the failed condition.
+func conditionFailedError(tableName string, key any, storedItem map[string]types.AttributeValue, expectedVersion int) error {
+	if len(storedItem) == 0 {
+		return database.NewNotFoundError(tableName, key)
+	}
+	version := 0
+	if storedVersion, found := storedItem[database.VersionAttribute]; found {
+		_ = attributevalue.Unmarshal(storedVersion, &version)
+	}
+	return database.NewConflictError(tableName, key, fmt.Sprintf("expected version %d, found %d", expectedVersion, version))
+}
+
+func mapTransactionOperation(operation database.TransactionOperation) (*types.TransactWriteItem, error) {
+	switch operation := operation.(type) {
+	case *database.InsertOperation:
+		item, err := attributevalue.MarshalMap(operation.Item)
+		if err != nil {
+			return nil, err
+		}
+		return &types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(operation.TableName),
+				Item:      item,
+			},
+		}, nil
+	case *database.UpdateOperation:
+		k, err := attributevalue.MarshalMap(operation.Key)
+		if err != nil {
+			return nil, err
+		}
+		updateExpression, conditionExpression, names, values, err := buildUpdateExpressions(k, operation.Attributes, operation.ExpectedVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                           aws.String(operation.TableName),
+				Key:                                 k,
+				UpdateExpression:                    aws.String(updateExpression),
+				ConditionExpression:                 aws.String(conditionExpression),
+				ExpressionAttributeNames:            names,
+				ExpressionAttributeValues:           values,
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		}, nil
+	case *database.RemoveOperation:
+		k, err := attributevalue.MarshalMap(operation.Key)
+		if err != nil {
+			return nil, err
+		}
+		deleteItem := &types.Delete{
+			TableName: aws.String(operation.TableName),
+			Key:       k,
+		}
+		if operation.ExpectedVersion != nil {
+			conditionExpression, names, values := buildVersionCondition(k, *operation.ExpectedVersion)
+			deleteItem.ConditionExpression = aws.String(conditionExpression)
+			deleteItem.ExpressionAttributeNames = names
+			deleteItem.ExpressionAttributeValues = values
+			deleteItem.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+		}
+		return &types.TransactWriteItem{
+			Delete: deleteItem,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported transaction operation %T", operation)
+	}
 }
 
 func mapTableKeys(keys *[]database.TableAttributes) (*[]types.KeySchemaElement, *[]types.AttributeDefinition, error) {
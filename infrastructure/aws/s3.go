@@ -2,7 +2,9 @@ package aws
 
 import (
 	"context"
-	"math"
+	"errors"
+	"fmt"
+	"postservice/internal/config"
 	objectstorage "postservice/internal/objectStorage"
 	"time"
 
@@ -13,37 +15,57 @@ import (
 )
 
 type S3Client struct {
-	client              *s3.Client
-	presignClient       *s3.PresignClient
-	presignLifetimeSecs int64
-	bucketName          string
+	client                    *s3.Client
+	presignClient             *s3.PresignClient
+	getPresignLifetime        time.Duration
+	putPresignLifetime        time.Duration
+	uploadPartPresignLifetime time.Duration
+	uploadPlanner             *objectstorage.UploadPlanner
+	bucketName                string
+	operationTimeout          time.Duration
 }
 
-func NewS3Client(config aws.Config, bucketName string) *S3Client {
-	s3Client := s3.NewFromConfig(config)
+func NewS3Client(awsConfig aws.Config, objectStorageConfig config.ObjectStorageConfig, s3Config config.S3Config) *S3Client {
+	s3Client := s3.NewFromConfig(awsConfig)
 	return &S3Client{
-		client:              s3Client,
-		presignClient:       s3.NewPresignClient(s3Client),
-		presignLifetimeSecs: 60,
-		bucketName:          bucketName,
+		client:                    s3Client,
+		presignClient:             s3.NewPresignClient(s3Client),
+		getPresignLifetime:        objectStorageConfig.GetUrlLifetime,
+		putPresignLifetime:        objectStorageConfig.PutUrlLifetime,
+		uploadPartPresignLifetime: objectStorageConfig.UploadPartUrlLifetime,
+		uploadPlanner:             objectstorage.NewUploadPlanner(objectStorageConfig.MultipartThreshold, objectStorageConfig.MultipartPartSize, objectStorageConfig.MaxObjectSize, objectstorage.S3PartLimits),
+		bucketName:                s3Config.Bucket,
+		operationTimeout:          objectStorageConfig.OperationTimeout,
 	}
 }
 
-func (s3c *S3Client) GetPreSignedUrlsForPuttingObject(objectKey string, size int) (string, []string, error) {
-	if size > 100 {
-		return s3c.getMultipartPreSignedUrls(objectKey, size)
+func (s3c *S3Client) GetPreSignedUrlsForPuttingObject(objectKey string, size int, ctx context.Context) (*objectstorage.PresignedUpload, error) {
+	plan, err := s3c.uploadPlanner.Plan(size)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Couldn't plan the upload of %s", objectKey)
+		return nil, err
+	}
+	if plan.Multipart {
+		return s3c.getMultipartPreSignedUrls(objectKey, plan, ctx)
 	}
 
-	presignedUrl, err := s3c.getPreSignedUrl(objectKey)
-	return "NoUploadId", []string{presignedUrl}, err
+	presignedUrl, err := s3c.getPreSignedUrl(objectKey, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &objectstorage.PresignedUpload{
+		UploadId: objectstorage.NoUploadId,
+		PartSize: plan.PartSize,
+		Parts:    []objectstorage.PresignedPart{{PartRange: plan.Parts[0], Url: presignedUrl}},
+	}, nil
 }
 
-func (s3c *S3Client) GetPreSignedUrlForGettingObject(objectKey string) (string, error) {
-	request, err := s3c.presignClient.PresignGetObject(context.TODO(), &s3.GetObjectInput{
+func (s3c *S3Client) GetPreSignedUrlForGettingObject(objectKey string, ctx context.Context) (string, error) {
+	request, err := s3c.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s3c.bucketName),
 		Key:    aws.String(objectKey),
 	}, func(opts *s3.PresignOptions) {
-		opts.Expires = time.Duration(s3c.presignLifetimeSecs * int64(time.Second))
+		opts.Expires = s3c.getPresignLifetime
 	})
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't get a presigned request to get %v:%v.",
@@ -52,7 +74,10 @@ func (s3c *S3Client) GetPreSignedUrlForGettingObject(objectKey string) (string,
 	return request.URL, err
 }
 
-func (s3c *S3Client) CompleteMultipartUpload(multipartObject objectstorage.MultipartObject) error {
+func (s3c *S3Client) CompleteMultipartUpload(multipartObject objectstorage.MultipartObject, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s3c.operationTimeout)
+	defer cancel()
+
 	completeMultipartInput := &s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(s3c.bucketName),
 		Key:      aws.String(multipartObject.Key),
@@ -62,7 +87,7 @@ func (s3c *S3Client) CompleteMultipartUpload(multipartObject objectstorage.Multi
 		},
 	}
 
-	_, err := s3c.client.CompleteMultipartUpload(context.TODO(), completeMultipartInput)
+	_, err := s3c.client.CompleteMultipartUpload(ctx, completeMultipartInput)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Failed to complete multipart upload")
 	}
@@ -70,7 +95,80 @@ func (s3c *S3Client) CompleteMultipartUpload(multipartObject objectstorage.Multi
 	return err
 }
 
-func (s3c *S3Client) DeleteObjects(objectKeys []string) error {
+func (s3c *S3Client) AbortMultipartUpload(objectKey, uploadId string, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s3c.operationTimeout)
+	defer cancel()
+
+	_, err := s3c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s3c.bucketName),
+		Key:      aws.String(objectKey),
+		UploadId: aws.String(uploadId),
+	})
+	if err != nil {
+		var noSuchUploadEx *types.NoSuchUpload
+		if errors.As(err, &noSuchUploadEx) {
+			log.Warn().Msgf("Multipart upload %s for %s was already finished", uploadId, objectKey)
+			return nil
+		}
+		log.Error().Stack().Err(err).Msgf("Failed to abort multipart upload %s for %s", uploadId, objectKey)
+		return err
+	}
+
+	return nil
+}
+
+// StatObject reads the Content-Type the object was uploaded with, or sniffs it
+// from its first bytes when the upload didn't set one, like multipart uploads.
+func (s3c *S3Client) StatObject(objectKey string, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3c.operationTimeout)
+	defer cancel()
+
+	output, err := s3c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s3c.bucketName),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		var notFoundEx *types.NotFound
+		if errors.As(err, &notFoundEx) {
+			return nil, &objectstorage.ObjectNotFoundError{Key: objectKey}
+		}
+		log.Error().Stack().Err(err).Msgf("Failed to stat object %s", objectKey)
+		return nil, err
+	}
+
+	info := &objectstorage.ObjectInfo{
+		Size:        int(aws.ToInt64(output.ContentLength)),
+		ContentType: objectstorage.MediaType(aws.ToString(output.ContentType)),
+	}
+	if objectstorage.IsGenericContentType(info.ContentType) && info.Size > 0 {
+		info.ContentType, err = s3c.sniffContentType(objectKey, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Failed to sniff the content type of object %s", objectKey)
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func (s3c *S3Client) sniffContentType(objectKey string, ctx context.Context) (string, error) {
+	output, err := s3c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s3c.bucketName),
+		Key:    aws.String(objectKey),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", objectstorage.SniffLength-1)),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer output.Body.Close()
+
+	return objectstorage.SniffContentType(output.Body)
+}
+
+func (s3c *S3Client) DeleteObjects(objectKeys []string, ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s3c.operationTimeout)
+	defer cancel()
+
 	objects := make([]types.ObjectIdentifier, len(objectKeys))
 	for i, key := range objectKeys {
 		objects[i] = types.ObjectIdentifier{
@@ -86,7 +184,7 @@ func (s3c *S3Client) DeleteObjects(objectKeys []string) error {
 		},
 	}
 
-	_, err := s3c.client.DeleteObjects(context.TODO(), input)
+	_, err := s3c.client.DeleteObjects(ctx, input)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Failed to delete objects %v", objectKeys)
 		return err
@@ -95,12 +193,20 @@ func (s3c *S3Client) DeleteObjects(objectKeys []string) error {
 	return nil
 }
 
-func (s3c *S3Client) getPreSignedUrl(objectKey string) (string, error) {
-	request, err := s3c.presignClient.PresignPutObject(context.TODO(), &s3.PutObjectInput{
+// HealthCheck checks that the bucket exists and the credentials can reach it.
+func (s3c *S3Client) HealthCheck(ctx context.Context) error {
+	_, err := s3c.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(s3c.bucketName),
+	})
+	return err
+}
+
+func (s3c *S3Client) getPreSignedUrl(objectKey string, ctx context.Context) (string, error) {
+	request, err := s3c.presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s3c.bucketName),
 		Key:    aws.String(objectKey),
 	}, func(opts *s3.PresignOptions) {
-		opts.Expires = time.Duration(s3c.presignLifetimeSecs * int64(time.Second) * 60 * 10)
+		opts.Expires = s3c.putPresignLifetime
 	})
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Couldn't get a presigned request to put %v:%v.",
@@ -109,43 +215,49 @@ func (s3c *S3Client) getPreSignedUrl(objectKey string) (string, error) {
 	return request.URL, err
 }
 
-func (s3c *S3Client) getMultipartPreSignedUrls(objectKey string, size int) (string, []string, error) {
+func (s3c *S3Client) getMultipartPreSignedUrls(objectKey string, plan *objectstorage.UploadPlan, ctx context.Context) (*objectstorage.PresignedUpload, error) {
 	createMultipartUploadInput := &s3.CreateMultipartUploadInput{
 		Bucket: aws.String(s3c.bucketName),
 		Key:    aws.String(objectKey),
 	}
 
-	multipartOutput, err := s3c.client.CreateMultipartUpload(context.TODO(), createMultipartUploadInput)
+	createCtx, cancel := context.WithTimeout(ctx, s3c.operationTimeout)
+	multipartOutput, err := s3c.client.CreateMultipartUpload(createCtx, createMultipartUploadInput)
+	cancel()
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Failed to initiate multipart upload")
-		return "NoUploadId", []string{}, err
+		return nil, err
 	}
 
 	uploadID := *multipartOutput.UploadId
 	log.Info().Msgf("Multipart upload iniciado. UploadID: %s\n", uploadID)
 
-	numParts := int(math.Ceil(float64(size) / 100))
-	result := []string{}
-
-	for part := 1; part <= numParts; part++ {
-		request, err := s3c.presignClient.PresignUploadPart(context.TODO(), &s3.UploadPartInput{
+	result := &objectstorage.PresignedUpload{
+		UploadId: uploadID,
+		PartSize: plan.PartSize,
+		Parts:    make([]objectstorage.PresignedPart, 0, len(plan.Parts)),
+	}
+	for _, part := range plan.Parts {
+		request, err := s3c.presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
 			Bucket:     aws.String(s3c.bucketName),
 			Key:        aws.String(objectKey),
-			PartNumber: aws.Int32(int32(part)),
+			PartNumber: aws.Int32(int32(part.PartNumber)),
 			UploadId:   aws.String(uploadID),
 		}, func(opts *s3.PresignOptions) {
-			opts.Expires = time.Duration(s3c.presignLifetimeSecs * int64(time.Second) * 10)
+			opts.Expires = s3c.uploadPartPresignLifetime
 		})
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("Couldn't get a presigned request to put %v:%v.",
 				s3c.bucketName, objectKey)
-			return "NoUploadId", []string{}, err
+			// Nothing else knows the upload id, the upload would never be aborted.
+			_ = s3c.AbortMultipartUpload(objectKey, uploadID, context.WithoutCancel(ctx))
+			return nil, err
 		}
 
-		result = append(result, request.URL)
+		result.Parts = append(result.Parts, objectstorage.PresignedPart{PartRange: part, Url: request.URL})
 	}
 
-	return uploadID, result, nil
+	return result, nil
 }
 
 func transformCompletedParts(parts []objectstorage.CompletedPart) []types.CompletedPart {
@@ -0,0 +1,107 @@
+package filesystem
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"postservice/internal/bus"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const publishedAtLayout = "2006-01-02T15:04:05.000000Z"
+
+// EventLog is an ExternalBus that appends the events as JSON Lines to a file,
+// which can be followed with tail -f or replayed with ReadEventLog.
+type EventLog struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+type eventLogEntry struct {
+	Type        string          `json:"type"`
+	Data        json.RawMessage `json:"data"`
+	PublishedAt string          `json:"publishedAt"`
+}
+
+func NewEventLog(path string) (*EventLog, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't create the directory of event log %s", path)
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't open event log %s", path)
+		return nil, err
+	}
+
+	return &EventLog{
+		file: file,
+	}, nil
+}
+
+func (el *EventLog) Publish(event *bus.Event, ctx context.Context) error {
+	line, err := json.Marshal(&eventLogEntry{
+		Type:        event.Type,
+		Data:        event.Data,
+		PublishedAt: time.Now().UTC().Format(publishedAtLayout),
+	})
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error serializing event %s", event.Type)
+		return err
+	}
+
+	el.mutex.Lock()
+	_, err = el.file.Write(append(line, '\n'))
+	el.mutex.Unlock()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error publishing")
+		return err
+	}
+
+	log.Info().Msgf("Event %s published on %s", event.Type, el.file.Name())
+
+	return nil
+}
+
+func (el *EventLog) Close() error {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	return el.file.Close()
+}
+
+// ReadEventLog returns the events of the log at path in the order they were
+// published.
+func ReadEventLog(path string) ([]bus.Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := []bus.Event{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry eventLogEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("event log %s line %d: %w", path, lineNumber, err)
+		}
+		events = append(events, bus.Event{Type: entry.Type, Data: entry.Data})
+	}
+
+	return events, scanner.Err()
+}
@@ -0,0 +1,61 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"postservice/infrastructure/filesystem"
+	"postservice/internal/bus"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishAndReadEventLog(t *testing.T) {
+	log.Logger = log.Output(io.Discard)
+	path := filepath.Join(t.TempDir(), "events", "events.jsonl")
+	eventLog, err := filesystem.NewEventLog(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, eventLog.Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte(`{"post_id":"post1"}`)}, context.Background()))
+	assert.Nil(t, eventLog.Publish(&bus.Event{Type: "PostsWereDeletedEvent", Data: []byte(`{"username":"username1","postIds":["post1"]}`)}, context.Background()))
+	assert.Nil(t, eventLog.Close())
+
+	events, err := filesystem.ReadEventLog(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []bus.Event{
+		{Type: "PostWasCreatedEvent", Data: []byte(`{"post_id":"post1"}`)},
+		{Type: "PostsWereDeletedEvent", Data: []byte(`{"username":"username1","postIds":["post1"]}`)},
+	}, events)
+}
+
+func TestEventLogAppendsToExistingFile(t *testing.T) {
+	log.Logger = log.Output(io.Discard)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for i := 0; i < 2; i++ {
+		eventLog, err := filesystem.NewEventLog(path)
+		assert.Nil(t, err)
+		assert.Nil(t, eventLog.Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte(`{}`)}, context.Background()))
+		assert.Nil(t, eventLog.Close())
+	}
+
+	events, err := filesystem.ReadEventLog(path)
+
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestErrorOnPublishInvalidEventData(t *testing.T) {
+	log.Logger = log.Output(io.Discard)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	eventLog, _ := filesystem.NewEventLog(path)
+	defer eventLog.Close()
+
+	err := eventLog.Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte(`not json`)}, context.Background())
+
+	assert.NotNil(t, err)
+	content, _ := os.ReadFile(path)
+	assert.Empty(t, content)
+}
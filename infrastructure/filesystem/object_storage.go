@@ -0,0 +1,461 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"postservice/internal/config"
+	objectstorage "postservice/internal/objectStorage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const maxPartNumber = 10000
+
+// filesystemPartLimits only bound the number of parts, the files can have any size.
+var filesystemPartLimits = objectstorage.PartLimits{
+	MinPartSize: 1,
+	MaxPartSize: math.MaxInt,
+	MaxParts:    maxPartNumber,
+}
+
+// FilesystemClient keeps the objects as files under a directory and issues
+// HMAC signed URLs that the Api serves, so the whole upload flow works on a
+// single machine.
+type FilesystemClient struct {
+	directory             string
+	objectsUrl            string
+	signingSecret         []byte
+	getUrlLifetime        time.Duration
+	putUrlLifetime        time.Duration
+	uploadPartUrlLifetime time.Duration
+	uploadPlanner         *objectstorage.UploadPlanner
+}
+
+// NewFilesystemClient stores the objects under filesystemConfig.Directory,
+// objectsUrl is the address where the Api serves them.
+func NewFilesystemClient(objectStorageConfig config.ObjectStorageConfig, filesystemConfig config.FilesystemConfig, objectsUrl string) (*FilesystemClient, error) {
+	client := &FilesystemClient{
+		directory:             filesystemConfig.Directory,
+		objectsUrl:            strings.TrimSuffix(objectsUrl, "/"),
+		signingSecret:         []byte(filesystemConfig.SigningSecret),
+		getUrlLifetime:        objectStorageConfig.GetUrlLifetime,
+		putUrlLifetime:        objectStorageConfig.PutUrlLifetime,
+		uploadPartUrlLifetime: objectStorageConfig.UploadPartUrlLifetime,
+		uploadPlanner:         objectstorage.NewUploadPlanner(objectStorageConfig.MultipartThreshold, objectStorageConfig.MultipartPartSize, objectStorageConfig.MaxObjectSize, filesystemPartLimits),
+	}
+
+	for _, dir := range []string{client.objectsDir(), client.uploadsDir()} {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Couldn't create object storage directory %s", dir)
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (fc *FilesystemClient) GetPreSignedUrlsForPuttingObject(objectKey string, size int, ctx context.Context) (*objectstorage.PresignedUpload, error) {
+	_, err := fc.objectPath(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := fc.uploadPlanner.Plan(size)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Couldn't plan the upload of %s", objectKey)
+		return nil, err
+	}
+
+	if plan.Multipart {
+		return fc.getMultipartSignedUrls(objectKey, plan)
+	}
+
+	return &objectstorage.PresignedUpload{
+		UploadId: objectstorage.NoUploadId,
+		PartSize: plan.PartSize,
+		Parts:    []objectstorage.PresignedPart{{PartRange: plan.Parts[0], Url: fc.signUrl(http.MethodPut, objectKey, "", 0, fc.putUrlLifetime)}},
+	}, nil
+}
+
+func (fc *FilesystemClient) GetPreSignedUrlForGettingObject(objectKey string, ctx context.Context) (string, error) {
+	_, err := fc.objectPath(objectKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fc.signUrl(http.MethodGet, objectKey, "", 0, fc.getUrlLifetime), nil
+}
+
+func (fc *FilesystemClient) CompleteMultipartUpload(multipartObject objectstorage.MultipartObject, ctx context.Context) error {
+	err := fc.completeMultipartUpload(multipartObject, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Failed to complete multipart upload")
+	}
+
+	return err
+}
+
+func (fc *FilesystemClient) AbortMultipartUpload(objectKey, uploadId string, ctx context.Context) error {
+	uploadDir, err := fc.uploadDir(objectKey, uploadId)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Warn().Msgf("Multipart upload %s for %s was already finished", uploadId, objectKey)
+		return nil
+	}
+	if err == nil {
+		err = os.RemoveAll(uploadDir)
+	}
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Failed to abort multipart upload %s for %s", uploadId, objectKey)
+		return err
+	}
+
+	return nil
+}
+
+func (fc *FilesystemClient) DeleteObjects(objectKeys []string, ctx context.Context) error {
+	for _, objectKey := range objectKeys {
+		objectPath, err := fc.objectPath(objectKey)
+		if err == nil {
+			err = os.Remove(objectPath)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Error().Stack().Err(err).Msgf("Failed to delete objects %v", objectKeys)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StatObject sniffs the content type from the first bytes of the file, the
+// uploads don't keep the one they were sent with.
+func (fc *FilesystemClient) StatObject(objectKey string, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	info, err := fc.statObject(objectKey)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &objectstorage.ObjectNotFoundError{Key: objectKey}
+	}
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Failed to stat object %s", objectKey)
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// VerifySignedUrl checks that query carries a valid and unexpired signature
+// for method on objectKey.
+func (fc *FilesystemClient) VerifySignedUrl(method, objectKey string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return errors.New("signed URL without a valid expiration")
+	}
+	if time.Now().Unix() > expires {
+		return errors.New("signed URL expired")
+	}
+
+	partNumber := 0
+	if query.Has("partNumber") {
+		partNumber, err = strconv.Atoi(query.Get("partNumber"))
+		if err != nil {
+			return errors.New("signed URL without a valid partNumber")
+		}
+	}
+
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil || !hmac.Equal(signature, fc.signature(method, objectKey, query.Get("uploadId"), partNumber, expires)) {
+		return errors.New("signed URL with an invalid signature")
+	}
+
+	return nil
+}
+
+// WriteObject replaces the object with content and returns its ETag.
+func (fc *FilesystemClient) WriteObject(objectKey string, content io.Reader) (string, error) {
+	objectPath, err := fc.objectPath(objectKey)
+	if err != nil {
+		return "", err
+	}
+
+	return writeFile(objectPath, content)
+}
+
+// WriteObjectPart stores a part of an ongoing multipart upload and returns
+// its ETag.
+func (fc *FilesystemClient) WriteObjectPart(objectKey, uploadId string, partNumber int, content io.Reader) (string, error) {
+	if partNumber < 1 || partNumber > maxPartNumber {
+		return "", fmt.Errorf("part number %d out of range 1-%d", partNumber, maxPartNumber)
+	}
+
+	uploadDir, err := fc.uploadDir(objectKey, uploadId)
+	if err != nil {
+		return "", err
+	}
+
+	return writeFile(partPath(uploadDir, partNumber), content)
+}
+
+func (fc *FilesystemClient) OpenObject(objectKey string) (*os.File, error) {
+	objectPath, err := fc.objectPath(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(objectPath)
+}
+
+// HealthCheck checks that the storage directories are still there.
+func (fc *FilesystemClient) HealthCheck(ctx context.Context) error {
+	for _, dir := range []string{fc.objectsDir(), fc.uploadsDir()} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+
+	return nil
+}
+
+func (fc *FilesystemClient) statObject(objectKey string) (*objectstorage.ObjectInfo, error) {
+	file, err := fc.OpenObject(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := objectstorage.SniffContentType(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &objectstorage.ObjectInfo{
+		Size:        int(fileInfo.Size()),
+		ContentType: contentType,
+	}, nil
+}
+
+func (fc *FilesystemClient) getMultipartSignedUrls(objectKey string, plan *objectstorage.UploadPlan) (*objectstorage.PresignedUpload, error) {
+	uploadId, err := newUploadId()
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Failed to initiate multipart upload")
+		return nil, err
+	}
+
+	uploadDir := filepath.Join(fc.uploadsDir(), uploadId)
+	err = os.Mkdir(uploadDir, 0o755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(uploadDir, "key"), []byte(objectKey), 0o644)
+	}
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Failed to initiate multipart upload")
+		return nil, err
+	}
+
+	result := &objectstorage.PresignedUpload{
+		UploadId: uploadId,
+		PartSize: plan.PartSize,
+		Parts:    make([]objectstorage.PresignedPart, 0, len(plan.Parts)),
+	}
+	for _, part := range plan.Parts {
+		result.Parts = append(result.Parts, objectstorage.PresignedPart{
+			PartRange: part,
+			Url:       fc.signUrl(http.MethodPut, objectKey, uploadId, part.PartNumber, fc.uploadPartUrlLifetime),
+		})
+	}
+
+	return result, nil
+}
+
+func (fc *FilesystemClient) completeMultipartUpload(multipartObject objectstorage.MultipartObject, ctx context.Context) error {
+	uploadDir, err := fc.uploadDir(multipartObject.Key, multipartObject.UploadID)
+	if err != nil {
+		return err
+	}
+	objectPath, err := fc.objectPath(multipartObject.Key)
+	if err != nil {
+		return err
+	}
+	if len(multipartObject.CompletedPart) == 0 {
+		return errors.New("multipart upload without parts")
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(concatenateParts(pipeWriter, uploadDir, multipartObject.CompletedPart, ctx))
+	}()
+
+	_, err = writeFile(objectPath, pipeReader)
+	pipeReader.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(uploadDir)
+}
+
+// concatenateParts stops between parts once ctx is done, leaving the upload
+// in place to be completed again.
+func concatenateParts(writer io.Writer, uploadDir string, parts []objectstorage.CompletedPart, ctx context.Context) error {
+	previousPartNumber := 0
+	for _, part := range parts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if part.PartNumber <= previousPartNumber {
+			return fmt.Errorf("parts have to be in ascending order, got %d after %d", part.PartNumber, previousPartNumber)
+		}
+		previousPartNumber = part.PartNumber
+
+		file, err := os.Open(partPath(uploadDir, part.PartNumber))
+		if err != nil {
+			return fmt.Errorf("part %d: %w", part.PartNumber, err)
+		}
+		hash := md5.New()
+		_, err = io.Copy(io.MultiWriter(writer, hash), file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		if strings.Trim(part.ETag, `"`) != hex.EncodeToString(hash.Sum(nil)) {
+			return fmt.Errorf("ETag %s does not match part %d", part.ETag, part.PartNumber)
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes content to a temporary file that replaces path once it is
+// complete, so readers never see a half written object.
+func writeFile(path string, content io.Reader) (string, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), content)
+	closeErr := file.Close()
+	if err != nil {
+		return "", err
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return "", err
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+func (fc *FilesystemClient) signUrl(method, objectKey, uploadId string, partNumber int, lifetime time.Duration) string {
+	expires := time.Now().Add(lifetime).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	if uploadId != "" {
+		query.Set("uploadId", uploadId)
+		query.Set("partNumber", strconv.Itoa(partNumber))
+	}
+	query.Set("signature", hex.EncodeToString(fc.signature(method, objectKey, uploadId, partNumber, expires)))
+
+	return fc.objectsUrl + "/" + escapeObjectKey(objectKey) + "?" + query.Encode()
+}
+
+func (fc *FilesystemClient) signature(method, objectKey, uploadId string, partNumber int, expires int64) []byte {
+	mac := hmac.New(sha256.New, fc.signingSecret)
+	mac.Write([]byte(strings.Join([]string{
+		method,
+		objectKey,
+		uploadId,
+		strconv.Itoa(partNumber),
+		strconv.FormatInt(expires, 10),
+	}, "\n")))
+	return mac.Sum(nil)
+}
+
+// objectPath rejects the keys that could escape the objects directory.
+func (fc *FilesystemClient) objectPath(objectKey string) (string, error) {
+	if !fs.ValidPath(objectKey) || objectKey == "." || strings.Contains(objectKey, `\`) {
+		return "", fmt.Errorf("invalid object key %q", objectKey)
+	}
+
+	return filepath.Join(fc.objectsDir(), filepath.FromSlash(objectKey)), nil
+}
+
+// uploadDir returns the directory of an ongoing multipart upload of objectKey,
+// or an error wrapping fs.ErrNotExist when there is none.
+func (fc *FilesystemClient) uploadDir(objectKey, uploadId string) (string, error) {
+	id, err := hex.DecodeString(uploadId)
+	if err != nil || len(id) != 16 {
+		return "", fmt.Errorf("multipart upload %s for %s: %w", uploadId, objectKey, fs.ErrNotExist)
+	}
+
+	uploadDir := filepath.Join(fc.uploadsDir(), uploadId)
+	uploadKey, err := os.ReadFile(filepath.Join(uploadDir, "key"))
+	if err != nil || string(uploadKey) != objectKey {
+		return "", fmt.Errorf("multipart upload %s for %s: %w", uploadId, objectKey, fs.ErrNotExist)
+	}
+
+	return uploadDir, nil
+}
+
+func (fc *FilesystemClient) objectsDir() string {
+	return filepath.Join(fc.directory, "objects")
+}
+
+func (fc *FilesystemClient) uploadsDir() string {
+	return filepath.Join(fc.directory, "uploads")
+}
+
+func partPath(uploadDir string, partNumber int) string {
+	return filepath.Join(uploadDir, "part-"+strconv.Itoa(partNumber))
+}
+
+func escapeObjectKey(objectKey string) string {
+	segments := strings.Split(objectKey, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func newUploadId() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,256 @@
+package filesystem_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"postservice/infrastructure/filesystem"
+	"postservice/internal/api"
+	"postservice/internal/config"
+	objectstorage "postservice/internal/objectStorage"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUp(t *testing.T, putUrlLifetime time.Duration) (*filesystem.FilesystemClient, *httptest.Server, string) {
+	log.Logger = log.Output(io.Discard)
+	gin.SetMode(gin.TestMode)
+	directory := t.TempDir()
+	router := gin.New()
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	client, err := filesystem.NewFilesystemClient(config.ObjectStorageConfig{
+		GetUrlLifetime:        time.Minute,
+		PutUrlLifetime:        putUrlLifetime,
+		UploadPartUrlLifetime: time.Minute,
+		MultipartThreshold:    4,
+		MultipartPartSize:     4,
+		MaxObjectSize:         100,
+	}, config.FilesystemConfig{
+		Directory:     directory,
+		SigningSecret: "a-test-signing-secret",
+	}, server.URL+"/test/postservice/objects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.NewSignedObjectsController(client, 100).Routes(router.Group("/test/postservice"))
+
+	return client, server, directory
+}
+
+func request(t *testing.T, method, url string, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+	return response
+}
+
+func readBody(t *testing.T, response *http.Response) string {
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestPutGetAndDeleteObject(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/TEXT/post 1"
+
+	upload, err := client.GetPreSignedUrlsForPuttingObject(objectKey, 3, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, objectstorage.NoUploadId, upload.UploadId)
+	assert.Len(t, upload.Parts, 1)
+	putResponse := request(t, http.MethodPut, upload.Parts[0].Url, "abc")
+	assert.Equal(t, http.StatusOK, putResponse.StatusCode)
+	assert.Equal(t, `"900150983cd24fb0d6963f7d28e17f72"`, putResponse.Header.Get("ETag"))
+
+	getUrl, err := client.GetPreSignedUrlForGettingObject(objectKey, context.Background())
+	assert.Nil(t, err)
+	getResponse := request(t, http.MethodGet, getUrl, "")
+	assert.Equal(t, http.StatusOK, getResponse.StatusCode)
+	assert.Equal(t, "abc", readBody(t, getResponse))
+
+	err = client.DeleteObjects([]string{objectKey, "username1/TEXT/missing"}, context.Background())
+	assert.Nil(t, err)
+	assert.NoFileExists(t, filepath.Join(directory, "objects", "username1", "TEXT", "post 1"))
+	assert.Equal(t, http.StatusNotFound, request(t, http.MethodGet, getUrl, "").StatusCode)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/VIDEO/post1"
+
+	upload, err := client.GetPreSignedUrlsForPuttingObject(objectKey, 10, context.Background())
+	assert.Nil(t, err)
+	uploadId, putUrls := upload.UploadId, upload.Urls()
+	assert.NotEqual(t, objectstorage.NoUploadId, uploadId)
+	assert.Equal(t, 4, upload.PartSize)
+	assert.Len(t, putUrls, 3)
+	assert.Equal(t, objectstorage.PartRange{PartNumber: 3, FirstByte: 8, LastByte: 9}, upload.Parts[2].PartRange)
+	parts := []objectstorage.CompletedPart{}
+	for i, content := range []string{"0123", "4567", "89"} {
+		response := request(t, http.MethodPut, putUrls[i], content)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		parts = append(parts, objectstorage.CompletedPart{PartNumber: i + 1, ETag: response.Header.Get("ETag")})
+	}
+
+	err = client.CompleteMultipartUpload(objectstorage.MultipartObject{Key: objectKey, UploadID: uploadId, CompletedPart: parts}, context.Background())
+
+	assert.Nil(t, err)
+	content, err := os.ReadFile(filepath.Join(directory, "objects", "username1", "VIDEO", "post1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(content))
+	assert.NoDirExists(t, filepath.Join(directory, "uploads", uploadId))
+}
+
+func TestMultipartUpload_WrongETag(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/VIDEO/post1"
+	upload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 5, context.Background())
+	uploadId, putUrls := upload.UploadId, upload.Urls()
+	request(t, http.MethodPut, putUrls[0], "0123")
+	request(t, http.MethodPut, putUrls[1], "4")
+
+	err := client.CompleteMultipartUpload(objectstorage.MultipartObject{Key: objectKey, UploadID: uploadId, CompletedPart: []objectstorage.CompletedPart{
+		{PartNumber: 1, ETag: "wrong"},
+		{PartNumber: 2, ETag: "wrong"},
+	}}, context.Background())
+
+	assert.NotNil(t, err)
+	assert.NoFileExists(t, filepath.Join(directory, "objects", "username1", "VIDEO", "post1"))
+	assert.DirExists(t, filepath.Join(directory, "uploads", uploadId))
+}
+
+func TestMultipartUpload_CanceledContext(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/VIDEO/post1"
+	upload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 5, context.Background())
+	uploadId, putUrls := upload.UploadId, upload.Urls()
+	parts := []objectstorage.CompletedPart{}
+	for i, content := range []string{"0123", "4"} {
+		response := request(t, http.MethodPut, putUrls[i], content)
+		parts = append(parts, objectstorage.CompletedPart{PartNumber: i + 1, ETag: response.Header.Get("ETag")})
+	}
+	multipartObject := objectstorage.MultipartObject{Key: objectKey, UploadID: uploadId, CompletedPart: parts}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.CompleteMultipartUpload(multipartObject, ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.DirExists(t, filepath.Join(directory, "uploads", uploadId))
+	assert.Nil(t, client.CompleteMultipartUpload(multipartObject, context.Background()))
+	content, _ := os.ReadFile(filepath.Join(directory, "objects", "username1", "VIDEO", "post1"))
+	assert.Equal(t, "01234", string(content))
+}
+
+func TestAbortMultipartUpload(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/VIDEO/post1"
+	upload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 5, context.Background())
+	uploadId, putUrls := upload.UploadId, upload.Urls()
+
+	err := client.AbortMultipartUpload(objectKey, uploadId, context.Background())
+
+	assert.Nil(t, err)
+	assert.NoDirExists(t, filepath.Join(directory, "uploads", uploadId))
+	assert.Equal(t, http.StatusNotFound, request(t, http.MethodPut, putUrls[0], "0123").StatusCode)
+	assert.Nil(t, client.AbortMultipartUpload(objectKey, uploadId, context.Background()))
+}
+
+func TestSignedUrlIsRejected(t *testing.T) {
+	client, server, _ := setUp(t, -time.Minute)
+	objectKey := "username1/TEXT/post1"
+	expiredUpload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 3, context.Background())
+	expiredUrls := expiredUpload.Urls()
+	getUrl, _ := client.GetPreSignedUrlForGettingObject(objectKey, context.Background())
+
+	testCases := map[string]struct {
+		method string
+		url    string
+	}{
+		"expired":        {http.MethodPut, expiredUrls[0]},
+		"other method":   {http.MethodPut, getUrl},
+		"other object":   {http.MethodGet, strings.Replace(getUrl, "post1", "post2", 1)},
+		"unsigned":       {http.MethodGet, server.URL + "/test/postservice/objects/" + objectKey},
+		"bad signature":  {http.MethodGet, strings.Replace(getUrl, "signature=", "signature=00", 1)},
+		"escaping key":   {http.MethodGet, server.URL + "/test/postservice/objects/../secret"},
+		"tampered query": {http.MethodGet, getUrl + "&expires=9999999999"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			response := request(t, testCase.method, testCase.url, "abc")
+
+			assert.Contains(t, []int{http.StatusForbidden, http.StatusNotFound}, response.StatusCode)
+			assert.NotContains(t, readBody(t, response), "abc")
+		})
+	}
+}
+
+func TestInvalidObjectKey(t *testing.T) {
+	client, _, _ := setUp(t, time.Minute)
+
+	_, err := client.GetPreSignedUrlsForPuttingObject("../outside", 3, context.Background())
+
+	assert.NotNil(t, err)
+}
+
+func TestObjectTooLarge(t *testing.T) {
+	client, _, _ := setUp(t, time.Minute)
+
+	_, err := client.GetPreSignedUrlsForPuttingObject("username1/VIDEO/post1", 101, context.Background())
+
+	var objectTooLargeError *objectstorage.ObjectTooLargeError
+	assert.ErrorAs(t, err, &objectTooLargeError)
+	assert.Equal(t, 100, objectTooLargeError.MaxSize)
+}
+
+func TestPutObjectRejectsBodiesLargerThanTheMaxObjectSize(t *testing.T) {
+	client, _, directory := setUp(t, time.Minute)
+	objectKey := "username1/TEXT/post1"
+	upload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 3, context.Background())
+
+	response := request(t, http.MethodPut, upload.Parts[0].Url, strings.Repeat("a", 101))
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Contains(t, readBody(t, response), "Object can have at most 100 bytes")
+	assert.NoFileExists(t, filepath.Join(directory, "objects", "username1", "TEXT", "post1"))
+}
+
+func TestStatObject(t *testing.T) {
+	client, _, _ := setUp(t, time.Minute)
+	objectKey := "username1/TEXT/post1"
+	upload, _ := client.GetPreSignedUrlsForPuttingObject(objectKey, 3, context.Background())
+	request(t, http.MethodPut, upload.Parts[0].Url, "abc")
+
+	info, err := client.StatObject(objectKey, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, &objectstorage.ObjectInfo{Size: 3, ContentType: "text/plain"}, info)
+}
+
+func TestStatMissingObject(t *testing.T) {
+	client, _, _ := setUp(t, time.Minute)
+
+	_, err := client.StatObject("username1/TEXT/missing", context.Background())
+
+	var objectNotFoundError *objectstorage.ObjectNotFoundError
+	assert.ErrorAs(t, err, &objectNotFoundError)
+}
@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"postservice/internal/bus"
+	"postservice/internal/config"
+	"postservice/internal/metrics"
+	"postservice/internal/tracing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog/log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var consumerRetryBackoff = 5 * time.Second
+
+var messagesSkipped = metrics.NewCounter("postservice_kafka_messages_skipped_total", "Kafka messages skipped after failing every attempt, by topic.", "topic")
+
+type KafkaConsumer struct {
+	ConsumerGroup      sarama.ConsumerGroup
+	topics             []string
+	eventBus           *bus.EventBus
+	maxMessageAttempts int
+}
+
+func NewKafkaConsumer(kafkaConfig config.KafkaConfig, topics []string, eventBus *bus.EventBus) (*KafkaConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Return.Errors = true
+
+	consumerGroup, err := sarama.NewConsumerGroup(kafkaConfig.Brokers, kafkaConfig.ConsumerGroup, config)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error creating consumer group client")
+		return nil, err
+	}
+
+	return &KafkaConsumer{
+		ConsumerGroup:      consumerGroup,
+		topics:             topics,
+		eventBus:           eventBus,
+		maxMessageAttempts: kafkaConfig.MaxMessageAttempts,
+	}, nil
+}
+
+// Run consumes the topics until the context is cancelled. A failed event is
+// retried up to the maximum attempts and then skipped, committing its offset.
+func (kc *KafkaConsumer) Run(ctx context.Context) error {
+	defer kc.close()
+
+	go kc.logErrors(ctx)
+
+	handler := &consumerGroupHandler{
+		eventBus:    kc.eventBus,
+		maxAttempts: kc.maxMessageAttempts,
+	}
+
+	for {
+		err := kc.ConsumerGroup.Consume(ctx, kc.topics, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return err
+		}
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error consuming topics %v", kc.topics)
+		}
+
+		select {
+		case <-time.After(consumerRetryBackoff):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (kc *KafkaConsumer) logErrors(ctx context.Context) {
+	for {
+		select {
+		case err, ok := <-kc.ConsumerGroup.Errors():
+			if !ok {
+				return
+			}
+			log.Error().Stack().Err(err).Msg("Kafka consumer group error")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (kc *KafkaConsumer) close() {
+	err := kc.ConsumerGroup.Close()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error closing consumer group")
+	}
+}
+
+type consumerGroupHandler struct {
+	eventBus    *bus.EventBus
+	maxAttempts int
+}
+
+func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
+			err := h.handleMessageWithRetries(message, session.Context())
+			if err != nil && session.Context().Err() != nil {
+				return nil
+			}
+			if err != nil {
+				messagesSkipped.Inc(message.Topic)
+				log.Error().Stack().Err(err).Msgf("Event %s at partition %d offset %d failed %d times, it is skipped", message.Topic, message.Partition, message.Offset, h.maxAttempts)
+			} else {
+				log.Info().Msgf("Event %s consumed from Kafka", message.Topic)
+			}
+
+			session.MarkMessage(message, "")
+			session.Commit()
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (h *consumerGroupHandler) handleMessageWithRetries(message *sarama.ConsumerMessage, ctx context.Context) error {
+	var err error
+	for attempt := 1; attempt <= h.maxAttempts; attempt++ {
+		err = h.handleMessage(message, ctx)
+		if err == nil || attempt == h.maxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(consumerRetryBackoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+func (h *consumerGroupHandler) handleMessage(message *sarama.ConsumerMessage, ctx context.Context) error {
+	traceContext := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		traceContext[string(header.Key)] = string(header.Value)
+	}
+	ctx, span := tracing.Start(tracing.Extract(ctx, traceContext), message.Topic+" process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		semconv.MessagingSystemKafka,
+		semconv.MessagingOperationTypeDeliver,
+		semconv.MessagingDestinationName(message.Topic),
+	))
+	defer span.End()
+
+	event := bus.Event{
+		Type: message.Topic,
+		Data: message.Value,
+	}
+	err := h.eventBus.PublishLocal(event, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error handling event %s at partition %d offset %d", message.Topic, message.Partition, message.Offset)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	return nil
+}
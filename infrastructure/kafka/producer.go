@@ -1,42 +1,82 @@
 package kafka
 
 import (
+	"context"
+	"errors"
 	"postservice/internal/bus"
+	"postservice/internal/config"
+	"postservice/internal/tracing"
 
 	"github.com/IBM/sarama"
 	"github.com/rs/zerolog/log"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type KafkaProducer struct {
 	Producer sarama.SyncProducer
+	client   sarama.Client
 }
 
-func NewKafkaProducer(brokers []string) (*KafkaProducer, error) {
+func NewKafkaProducer(kafkaConfig config.KafkaConfig) (*KafkaProducer, error) {
 	config := sarama.NewConfig()
 	config.Producer.Retry.Max = 5
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Return.Successes = true
 
-	producer, err := sarama.NewSyncProducer(brokers, config)
+	client, err := sarama.NewClient(kafkaConfig.Brokers, config)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("Error creating consumer group client")
 		return nil, err
 	}
 
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error creating Kafka producer")
+		client.Close()
+		return nil, err
+	}
+
 	return &KafkaProducer{
 		Producer: producer,
+		client:   client,
 	}, nil
 }
 
-func (kp *KafkaProducer) Publish(event *bus.Event) error {
+// HealthCheck refreshes the cluster metadata, which fails when no broker
+// answers.
+func (kp *KafkaProducer) HealthCheck(ctx context.Context) error {
+	err := kp.client.RefreshMetadata()
+	if err != nil {
+		return err
+	}
+	if len(kp.client.Brokers()) == 0 {
+		return errors.New("no Kafka broker available")
+	}
+
+	return nil
+}
+
+// Publish sends the event with the trace context of ctx in the message
+// headers, so the consumers continue the trace.
+func (kp *KafkaProducer) Publish(event *bus.Event, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, event.Type+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		semconv.MessagingSystemKafka,
+		semconv.MessagingOperationTypePublish,
+		semconv.MessagingDestinationName(event.Type),
+	))
+	defer span.End()
+
 	msg := &sarama.ProducerMessage{
-		Topic: event.Type,
-		Value: sarama.StringEncoder(event.Data),
+		Topic:   event.Type,
+		Value:   sarama.StringEncoder(event.Data),
+		Headers: traceHeaders(ctx),
 	}
 
 	_, _, err := kp.Producer.SendMessage(msg)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("Error publishing")
+		tracing.Fail(span, err)
 		return err
 	}
 
@@ -44,3 +84,12 @@ func (kp *KafkaProducer) Publish(event *bus.Event) error {
 
 	return nil
 }
+
+func traceHeaders(ctx context.Context) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	for key, value := range tracing.Inject(ctx) {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+
+	return headers
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"postservice/internal/bus"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EventBroker is an ExternalBus that hands the published events to the
+// subscribers of an EventBus of the same process, so the service runs without
+// Kafka. It keeps no history of the events.
+type EventBroker struct {
+	mutex    sync.Mutex
+	eventBus *bus.EventBus
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{}
+}
+
+// DeliverTo makes the broker dispatch every published event to the
+// subscribers of eventBus, as the Kafka consumer does with its topics.
+func (eb *EventBroker) DeliverTo(eventBus *bus.EventBus) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	eb.eventBus = eventBus
+}
+
+// Publish fails when a subscriber fails to handle the event, so the outbox
+// keeps it pending and delivers it again.
+func (eb *EventBroker) Publish(event *bus.Event, ctx context.Context) error {
+	eb.mutex.Lock()
+	eventBus := eb.eventBus
+	eb.mutex.Unlock()
+
+	if eventBus != nil {
+		err := eventBus.PublishLocal(*event, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error delivering event %s", event.Type)
+			return err
+		}
+	}
+
+	log.Info().Msgf("Event %s published in memory", event.Type)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package memory_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"postservice/infrastructure/memory"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"postservice/internal/features/create_post"
+	"postservice/internal/features/delete_post"
+	objectstorage "postservice/internal/objectStorage"
+	mock_objectstorage "postservice/internal/objectStorage/mock"
+	"postservice/internal/outbox"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHandler struct {
+	mutex  sync.Mutex
+	events [][]byte
+	err    error
+}
+
+func (h *recordingHandler) Handle(event []byte) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event)
+	return h.err
+}
+
+func TestEventBrokerDeliversToSubscribers(t *testing.T) {
+	setUp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := memory.NewEventBroker()
+	eventBus := bus.NewEventBus()
+	broker.DeliverTo(eventBus)
+	handler := &recordingHandler{}
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "PostWasCreatedEvent", Handler: handler}, ctx)
+
+	err := broker.Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte(`{"post_id":"post1"}`)}, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"post_id":"post1"}`)}, handler.events)
+}
+
+func TestErrorOnEventBrokerPublishWhenSubscriberFails(t *testing.T) {
+	setUp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := memory.NewEventBroker()
+	eventBus := bus.NewEventBus()
+	broker.DeliverTo(eventBus)
+	handler := &recordingHandler{err: errors.New("some error")}
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "PostWasCreatedEvent", Handler: handler}, ctx)
+
+	err := broker.Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte(`{}`)}, context.Background())
+
+	assert.EqualError(t, err, "some error")
+	assert.Len(t, handler.events, 1)
+}
+
+func TestPostLifecycleEventsReachEventBroker(t *testing.T) {
+	client := setUp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+	objectStorageClient := mock_objectstorage.NewMockObjectStorageClient(ctrl)
+	objectStorageClient.EXPECT().GetPreSignedUrlsForPuttingObject(gomock.Any(), 10, gomock.Any()).Return(&objectstorage.PresignedUpload{UploadId: objectstorage.NoUploadId, Parts: []objectstorage.PresignedPart{{Url: "url"}}}, nil)
+	objectStorageClient.EXPECT().StatObject(gomock.Any(), gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 10, ContentType: "text/plain"}, nil)
+	objectStorageClient.EXPECT().DeleteObjects(gomock.Any(), gomock.Any()).Return(nil)
+	db := database.NewDatabase(client)
+	objectStorage := objectstorage.NewObjectStorage(objectStorageClient)
+	broker := memory.NewEventBroker()
+	eventBus := bus.NewEventBus()
+	broker.DeliverTo(eventBus)
+	createdHandler, deletedHandler := &recordingHandler{}, &recordingHandler{}
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "PostWasCreatedEvent", Handler: createdHandler}, ctx)
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "PostsWereDeletedEvent", Handler: deletedHandler}, ctx)
+	relay := outbox.NewRelay(db, broker, time.Second, time.Minute, 10)
+	createPostService := create_post.NewCreatePostService(create_post.NewCreatePostRepository(db, objectStorage))
+	deletePostService := delete_post.NewDeletePostService(delete_post.NewDeletePostRepository(db, objectStorage))
+
+	result, err := createPostService.CreatePost(&create_post.Post{User: "username1", Type: "TEXT", Title: "Meu Post", Size: 10}, context.Background())
+	assert.Nil(t, err)
+	err = createPostService.ConfirmCreatedPost(&create_post.ConfirmedCreatedPost{User: "username1", IsConfirmed: true, PostId: result.PostId}, context.Background())
+	assert.Nil(t, err)
+	err = deletePostService.DeletePosts("username1", []string{result.PostId}, nil, context.Background())
+	assert.Nil(t, err)
+	err = relay.RelayPendingEvents(context.Background())
+	assert.Nil(t, err)
+
+	assert.Len(t, createdHandler.events, 1)
+	var postWasCreatedEvent create_post.PostWasCreatedEvent
+	assert.Nil(t, json.Unmarshal(createdHandler.events[0], &postWasCreatedEvent))
+	assert.Equal(t, result.PostId, postWasCreatedEvent.PostId)
+	assert.Equal(t, database.PostStatusPublished, postWasCreatedEvent.Metadata.Status)
+	assert.Len(t, deletedHandler.events, 1)
+	var postsWereDeletedEvent delete_post.PostsWereDeletedEvent
+	assert.Nil(t, json.Unmarshal(deletedHandler.events[0], &postsWereDeletedEvent))
+	assert.Equal(t, delete_post.PostsWereDeletedEvent{Username: "username1", PostIds: []string{result.PostId}}, postsWereDeletedEvent)
+}
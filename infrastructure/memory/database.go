@@ -0,0 +1,683 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	database "postservice/internal/db"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/rs/zerolog/log"
+)
+
+type item = map[string]types.AttributeValue
+
+type table struct {
+	keys    []database.TableAttributes
+	indexes map[string][]database.TableAttributes
+	items   map[string]item
+}
+
+// DatabaseClient keeps the tables in memory with the same attribute value
+// representation DynamoDB uses, so the data behaves as it does in DynamoDB
+// when it is marshalled, filtered and sorted.
+type DatabaseClient struct {
+	mutex  sync.RWMutex
+	tables map[string]*table
+}
+
+func NewDatabaseClient() *DatabaseClient {
+	return &DatabaseClient{
+		tables: make(map[string]*table),
+	}
+}
+
+func (dc *DatabaseClient) TableExists(tableName string, ctx context.Context) bool {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	_, exists := dc.tables[tableName]
+	return exists
+}
+
+func (dc *DatabaseClient) IndexExists(tableName, indexName string, ctx context.Context) bool {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	t, exists := dc.tables[tableName]
+	if !exists {
+		return false
+	}
+	_, exists = t.indexes[indexName]
+	return exists
+}
+
+func (dc *DatabaseClient) CreateTable(tableName string, keys *[]database.TableAttributes, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	if _, exists := dc.tables[tableName]; exists {
+		return fmt.Errorf("table %s already exists", tableName)
+	}
+
+	dc.tables[tableName] = &table{
+		keys:    append([]database.TableAttributes{}, *keys...),
+		indexes: make(map[string][]database.TableAttributes),
+		items:   make(map[string]item),
+	}
+
+	log.Info().Msgf("Created table: %s\n", tableName)
+	return nil
+}
+
+func (dc *DatabaseClient) CreateIndexesOnTable(tableName, indexName string, indexes *[]database.TableAttributes, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	t, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+	t.indexes[indexName] = append([]database.TableAttributes{}, *indexes...)
+
+	log.Info().Msgf("GSI %s created on table %s\n", indexName, tableName)
+	return nil
+}
+
+func (dc *DatabaseClient) EnableTimeToLive(tableName, attributeName string, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	// DynamoDB keeps returning expired items until it deletes them, which can
+	// take days, so the callers check the expiry themselves and the items are
+	// just kept here.
+	_, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Time to live enabled on table %s with attribute %s\n", tableName, attributeName)
+	return nil
+}
+
+func (dc *DatabaseClient) InsertData(tableName string, attributes any, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction([]database.TransactionOperation{
+		&database.InsertOperation{
+			TableName: tableName,
+			Item:      attributes,
+		},
+	})
+}
+
+func (dc *DatabaseClient) InsertDataIfNotExists(tableName, keyAttribute string, attributes any, ctx context.Context) error {
+	newItem, err := attributevalue.MarshalMap(attributes)
+	if err != nil {
+		return err
+	}
+
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	t, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+	key, err := t.itemKey(newItem)
+	if err != nil {
+		return err
+	}
+	if _, found := t.items[key]; found {
+		return database.NewConflictError(tableName, keyAttribute, "item already exists")
+	}
+	t.items[key] = newItem
+
+	return nil
+}
+
+func (dc *DatabaseClient) InsertDataIfMatches(tableName string, attributes any, conditions map[string]any, ctx context.Context) error {
+	newItem, err := attributevalue.MarshalMap(attributes)
+	if err != nil {
+		return err
+	}
+
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	t, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+	key, err := t.itemKey(newItem)
+	if err != nil {
+		return err
+	}
+	if err := checkMatch(tableName, key, t, t.items[key], conditions); err != nil {
+		return err
+	}
+	t.items[key] = newItem
+
+	return nil
+}
+
+func (dc *DatabaseClient) GetData(tableName string, key any, result any, ctx context.Context) error {
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Couldn't map %v key to AttributeValues", key)
+		return err
+	}
+
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	t, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+	storedItem, found := t.items[t.primaryKey(k)]
+	if !found {
+		err = database.NewNotFoundError(tableName, key)
+		log.Error().Stack().Err(err).Msgf("Item %s was not found", key)
+		return err
+	}
+
+	return attributevalue.UnmarshalMap(storedItem, result)
+}
+
+func (dc *DatabaseClient) UpdateData(tableName string, key any, attributes map[string]any, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction([]database.TransactionOperation{
+		&database.UpdateOperation{
+			TableName:  tableName,
+			Key:        key,
+			Attributes: attributes,
+		},
+	})
+}
+
+func (dc *DatabaseClient) UpdateDataIfVersion(tableName string, key any, attributes map[string]any, expectedVersion int, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction([]database.TransactionOperation{
+		&database.UpdateOperation{
+			TableName:       tableName,
+			Key:             key,
+			Attributes:      attributes,
+			ExpectedVersion: &expectedVersion,
+		},
+	})
+}
+
+func (dc *DatabaseClient) RemoveData(tableName string, key any, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction([]database.TransactionOperation{
+		&database.RemoveOperation{
+			TableName: tableName,
+			Key:       key,
+		},
+	})
+}
+
+func (dc *DatabaseClient) RemoveDataIfVersion(tableName string, key any, expectedVersion int, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction([]database.TransactionOperation{
+		&database.RemoveOperation{
+			TableName:       tableName,
+			Key:             key,
+			ExpectedVersion: &expectedVersion,
+		},
+	})
+}
+
+func (dc *DatabaseClient) RemoveDataIfMatches(tableName string, key any, conditions map[string]any, ctx context.Context) error {
+	k, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	t, err := dc.table(tableName)
+	if err != nil {
+		return err
+	}
+	if err := checkMatch(tableName, key, t, t.items[t.primaryKey(k)], conditions); err != nil {
+		return err
+	}
+	delete(t.items, t.primaryKey(k))
+
+	return nil
+}
+
+func (dc *DatabaseClient) RemoveMultipleData(tableName string, keys []any, ctx context.Context) error {
+	operations := make([]database.TransactionOperation, len(keys))
+	for i, key := range keys {
+		operations[i] = &database.RemoveOperation{
+			TableName: tableName,
+			Key:       key,
+		}
+	}
+
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction(operations)
+}
+
+func (dc *DatabaseClient) ExecuteTransaction(operations []database.TransactionOperation, ctx context.Context) error {
+	dc.mutex.Lock()
+	defer dc.mutex.Unlock()
+
+	return dc.applyTransaction(operations)
+}
+
+func (dc *DatabaseClient) GetPostsByIds(postIds []string, ctx context.Context) ([]*database.Post, error) {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	t, err := dc.table("Posts")
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*database.Post
+	for _, postId := range postIds {
+		storedItem, found := t.items[t.primaryKey(item{"PostId": &types.AttributeValueMemberS{Value: postId}})]
+		if !found {
+			continue
+		}
+
+		var post database.Post
+		err = attributevalue.UnmarshalMap(storedItem, &post)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+
+	return posts, nil
+}
+
+func (dc *DatabaseClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	return dc.getPostsByIndex("UserIndex", username, lastPostId, lastPostCreatedAt, limit, true)
+}
+
+func (dc *DatabaseClient) GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	return dc.getPostsByIndex("TypeIndex", postType, lastPostId, lastPostCreatedAt, limit, false)
+}
+
+func (dc *DatabaseClient) GetPostsByStatusCreatedBefore(status, createdBefore string, ctx context.Context) ([]*database.Post, error) {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	items, _, err := dc.query("Posts", "StatusIndex", status, nil, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*database.Post
+	for _, storedItem := range items {
+		// Same key condition as the DynamoDB query, the items are in CreatedAt order.
+		if stringAttribute(storedItem, "CreatedAt") >= createdBefore {
+			break
+		}
+
+		var post database.Post
+		err = attributevalue.UnmarshalMap(storedItem, &post)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	}
+
+	return posts, nil
+}
+
+func (dc *DatabaseClient) GetPendingOutboxEvents(limit int, ctx context.Context) ([]*database.OutboxEvent, error) {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	items, _, err := dc.query("Outbox", "StatusIndex", database.OutboxStatusPending, nil, limit, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*database.OutboxEvent
+	err = attributevalue.UnmarshalListOfMaps(items, &events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (dc *DatabaseClient) getPostsByIndex(indexName, partitionValue, lastPostId, lastPostCreatedAt string, limit int, scanForward bool) ([]*database.Post, string, string, error) {
+	dc.mutex.RLock()
+	defer dc.mutex.RUnlock()
+
+	var exclusiveStartKey item
+	if lastPostId != "" {
+		exclusiveStartKey = item{
+			"PostId":    &types.AttributeValueMemberS{Value: lastPostId},
+			"CreatedAt": &types.AttributeValueMemberS{Value: lastPostCreatedAt},
+		}
+	}
+
+	// Same loop as the DynamoDB client, which filters the posts after the limit.
+	var posts []*database.Post
+	for {
+		items, lastEvaluatedKey, err := dc.query("Posts", indexName, partitionValue, exclusiveStartKey, limit-len(posts), scanForward)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Couldn't get info about Posts from index %s", indexName)
+			return nil, "", "", err
+		}
+
+		for _, storedItem := range items {
+			var post database.Post
+			err = attributevalue.UnmarshalMap(storedItem, &post)
+			if err != nil {
+				return nil, "", "", err
+			}
+			if post.IsPublished() {
+				posts = append(posts, &post)
+			}
+		}
+
+		if lastEvaluatedKey == nil {
+			return posts, "", "", nil
+		}
+		if len(posts) >= limit {
+			return posts, stringAttribute(lastEvaluatedKey, "PostId"), stringAttribute(lastEvaluatedKey, "CreatedAt"), nil
+		}
+		exclusiveStartKey = lastEvaluatedKey
+	}
+}
+
+// query reads at most limit items of the index partition after
+// exclusiveStartKey, following the sort key order. As in DynamoDB, the last
+// evaluated key is returned while there are items left to read.
+func (dc *DatabaseClient) query(tableName, indexName, partitionValue string, exclusiveStartKey item, limit int, scanForward bool) ([]item, item, error) {
+	t, err := dc.table(tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexKeys, found := t.indexes[indexName]
+	if !found {
+		return nil, nil, fmt.Errorf("index %s not found on table %s", indexName, tableName)
+	}
+	partitionKey := indexKeys[0].Name
+
+	var partition []item
+	for _, storedItem := range t.sortedItems() {
+		if hasAttributes(storedItem, indexKeys) && stringAttribute(storedItem, partitionKey) == partitionValue {
+			partition = append(partition, storedItem)
+		}
+	}
+
+	less := func(a, b item) bool {
+		for _, key := range indexKeys[1:] {
+			if c := compareAttributes(a[key.Name], b[key.Name]); c != 0 {
+				return c < 0
+			}
+		}
+		return t.primaryKey(a) < t.primaryKey(b)
+	}
+	sort.SliceStable(partition, func(i, j int) bool {
+		if scanForward {
+			return less(partition[i], partition[j])
+		}
+		return less(partition[j], partition[i])
+	})
+
+	// As in DynamoDB, the query resumes after the position of
+	// exclusiveStartKey, even when its item was deleted since.
+	start := 0
+	if exclusiveStartKey != nil {
+		start = sort.Search(len(partition), func(i int) bool {
+			if scanForward {
+				return less(exclusiveStartKey, partition[i])
+			}
+			return less(partition[i], exclusiveStartKey)
+		})
+	}
+
+	end := len(partition)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	var lastEvaluatedKey item
+	if end < len(partition) {
+		lastEvaluatedKey = partition[end-1]
+	}
+
+	return partition[start:end], lastEvaluatedKey, nil
+}
+
+// applyTransaction checks every operation before applying any of them, so
+// either all of them are applied or none is. The caller holds the lock.
+func (dc *DatabaseClient) applyTransaction(operations []database.TransactionOperation) error {
+	type write struct {
+		table *table
+		key   string
+		item  item
+	}
+	writes := make([]write, len(operations))
+
+	for i, operation := range operations {
+		switch operation := operation.(type) {
+		case *database.InsertOperation:
+			t, err := dc.table(operation.TableName)
+			if err != nil {
+				return err
+			}
+			newItem, err := attributevalue.MarshalMap(operation.Item)
+			if err != nil {
+				return err
+			}
+			key, err := t.itemKey(newItem)
+			if err != nil {
+				return err
+			}
+			writes[i] = write{table: t, key: key, item: newItem}
+		case *database.UpdateOperation:
+			t, err := dc.table(operation.TableName)
+			if err != nil {
+				return err
+			}
+			k, err := attributevalue.MarshalMap(operation.Key)
+			if err != nil {
+				return err
+			}
+			storedItem, found := t.items[t.primaryKey(k)]
+			if !found {
+				err = database.NewNotFoundError(operation.TableName, operation.Key)
+				log.Error().Stack().Err(err).Msgf("Item %v was not found", operation.Key)
+				return err
+			}
+			if err := checkVersion(operation.TableName, operation.Key, storedItem, operation.ExpectedVersion); err != nil {
+				return err
+			}
+			updatedItem := make(item, len(storedItem)+len(operation.Attributes)+1)
+			for name, value := range storedItem {
+				updatedItem[name] = value
+			}
+			for name, value := range operation.Attributes {
+				updatedItem[name], err = attributevalue.Marshal(value)
+				if err != nil {
+					return err
+				}
+			}
+			if operation.ExpectedVersion != nil {
+				updatedItem[database.VersionAttribute] = &types.AttributeValueMemberN{Value: strconv.Itoa(*operation.ExpectedVersion + 1)}
+			}
+			writes[i] = write{table: t, key: t.primaryKey(k), item: updatedItem}
+		case *database.RemoveOperation:
+			t, err := dc.table(operation.TableName)
+			if err != nil {
+				return err
+			}
+			k, err := attributevalue.MarshalMap(operation.Key)
+			if err != nil {
+				return err
+			}
+			if operation.ExpectedVersion != nil {
+				storedItem, found := t.items[t.primaryKey(k)]
+				if !found {
+					err = database.NewNotFoundError(operation.TableName, operation.Key)
+					log.Error().Stack().Err(err).Msgf("Item %v was not found", operation.Key)
+					return err
+				}
+				if err := checkVersion(operation.TableName, operation.Key, storedItem, operation.ExpectedVersion); err != nil {
+					return err
+				}
+			}
+			writes[i] = write{table: t, key: t.primaryKey(k)}
+		default:
+			return fmt.Errorf("unsupported transaction operation %T", operation)
+		}
+	}
+
+	for _, w := range writes {
+		if w.item == nil {
+			delete(w.table.items, w.key)
+		} else {
+			w.table.items[w.key] = w.item
+		}
+	}
+
+	return nil
+}
+
+func (dc *DatabaseClient) table(tableName string) (*table, error) {
+	t, exists := dc.tables[tableName]
+	if !exists {
+		return nil, fmt.Errorf("table %s not found", tableName)
+	}
+	return t, nil
+}
+
+func checkVersion(tableName string, key any, storedItem item, expectedVersion *int) error {
+	if expectedVersion == nil {
+		return nil
+	}
+	version := 0
+	if storedVersion, found := storedItem[database.VersionAttribute]; found {
+		if err := attributevalue.Unmarshal(storedVersion, &version); err != nil {
+			return err
+		}
+	}
+	if version != *expectedVersion {
+		err := database.NewConflictError(tableName, key, fmt.Sprintf("expected version %d, found %d", *expectedVersion, version))
+		log.Warn().Err(err).Msgf("Item %v was modified concurrently", key)
+		return err
+	}
+	return nil
+}
+
+func checkMatch(tableName string, key any, t *table, storedItem item, conditions map[string]any) error {
+	matches := storedItem != nil
+	for name, value := range conditions {
+		if !matches {
+			break
+		}
+		expected, err := attributevalue.Marshal(value)
+		if err != nil {
+			return err
+		}
+		stored, found := storedItem[name]
+		matches = found && reflect.DeepEqual(stored, expected)
+	}
+	if !matches {
+		err := database.NewConflictError(tableName, key, "stored item does not match")
+		log.Warn().Err(err).Msgf("Item %v does not match %v", key, conditions)
+		return err
+	}
+	return nil
+}
+
+func (t *table) itemKey(newItem item) (string, error) {
+	if !hasAttributes(newItem, t.keys) {
+		return "", fmt.Errorf("item is missing key attributes %v", t.keys)
+	}
+	return t.primaryKey(newItem), nil
+}
+
+func (t *table) primaryKey(attributes item) string {
+	parts := make([]string, len(t.keys))
+	for i, key := range t.keys {
+		parts[i] = stringAttribute(attributes, key.Name)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (t *table) sortedItems() []item {
+	keys := make([]string, 0, len(t.items))
+	for key := range t.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]item, len(keys))
+	for i, key := range keys {
+		items[i] = t.items[key]
+	}
+	return items
+}
+
+// Items without every key attribute of an index are left out of it, as
+// DynamoDB does with sparse indexes.
+func hasAttributes(attributes item, keys []database.TableAttributes) bool {
+	for _, key := range keys {
+		if _, found := attributes[key.Name]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+func stringAttribute(attributes item, name string) string {
+	switch value := attributes[name].(type) {
+	case *types.AttributeValueMemberS:
+		return value.Value
+	case *types.AttributeValueMemberN:
+		return value.Value
+	default:
+		return ""
+	}
+}
+
+func compareAttributes(a, b types.AttributeValue) int {
+	aNumber, aIsNumber := a.(*types.AttributeValueMemberN)
+	bNumber, bIsNumber := b.(*types.AttributeValueMemberN)
+	if aIsNumber && bIsNumber {
+		aValue, _ := strconv.ParseFloat(aNumber.Value, 64)
+		bValue, _ := strconv.ParseFloat(bNumber.Value, 64)
+		switch {
+		case aValue < bValue:
+			return -1
+		case aValue > bValue:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(stringAttribute(item{"value": a}, "value"), stringAttribute(item{"value": b}, "value"))
+}
@@ -0,0 +1,338 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"postservice/infrastructure/memory"
+	database "postservice/internal/db"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type postMetadata struct {
+	PostId    string
+	User      string
+	Type      string
+	Title     string
+	CreatedAt string
+	Status    string
+	Version   int
+}
+
+func setUp(t *testing.T) *memory.DatabaseClient {
+	log.Logger = log.Output(io.Discard)
+	client := memory.NewDatabaseClient()
+	err := database.NewDatabase(client).ApplyMigrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func insertPosts(t *testing.T, client *memory.DatabaseClient, posts ...*postMetadata) {
+	for _, post := range posts {
+		if post.CreatedAt == "" {
+			post.CreatedAt = "2024-08-08T21:51:20.000000Z"
+		}
+		if err := client.InsertData("Posts", post, context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestApplyMigrations(t *testing.T) {
+	client := setUp(t)
+
+	assert.True(t, client.TableExists("Posts", context.Background()))
+	assert.True(t, client.IndexExists("Posts", "UserIndex", context.Background()))
+	assert.True(t, client.IndexExists("Posts", "TypeIndex", context.Background()))
+	assert.True(t, client.IndexExists("Posts", "StatusIndex", context.Background()))
+	assert.True(t, client.TableExists("Outbox", context.Background()))
+	assert.True(t, client.IndexExists("Outbox", "StatusIndex", context.Background()))
+	assert.False(t, client.TableExists("Users", context.Background()))
+}
+
+func TestInsertGetUpdateAndRemoveData(t *testing.T) {
+	client := setUp(t)
+	key := &database.PostKey{PostId: "post1"}
+	insertPosts(t, client, &postMetadata{PostId: "post1", User: "username1", Title: "Meu Post"})
+
+	err := client.UpdateData("Posts", key, map[string]any{"Title": "Novo titulo", "Status": "published"}, context.Background())
+	assert.Nil(t, err)
+
+	var post postMetadata
+	err = client.GetData("Posts", key, &post, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, postMetadata{PostId: "post1", User: "username1", Title: "Novo titulo", CreatedAt: "2024-08-08T21:51:20.000000Z", Status: "published"}, post)
+
+	err = client.RemoveData("Posts", key, context.Background())
+	assert.Nil(t, err)
+	err = client.GetData("Posts", key, &post, context.Background())
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+}
+
+func TestInsertDataIfNotExistsKeepsStoredData(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client, &postMetadata{PostId: "post1", User: "username1", Title: "Meu Post"})
+
+	err := client.InsertDataIfNotExists("Posts", "PostId", &postMetadata{PostId: "post1", User: "username2", Title: "Other Post", CreatedAt: "2024-08-08T21:51:20.000000Z"}, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	var stored postMetadata
+	assert.Nil(t, client.GetData("Posts", &database.PostKey{PostId: "post1"}, &stored, context.Background()))
+	assert.Equal(t, "username1", stored.User)
+	assert.Nil(t, client.InsertDataIfNotExists("Posts", "PostId", &postMetadata{PostId: "post2", User: "username2", CreatedAt: "2024-08-08T21:51:20.000000Z"}, context.Background()))
+}
+
+func TestExpiredDataIsKeptAsInDynamoDB(t *testing.T) {
+	client := setUp(t)
+	key := &database.IdempotencyRecordKey{Key: "username1/key1"}
+	expired := &database.IdempotencyRecord{Key: key.Key, ExpiresAt: time.Now().Add(-time.Second).Unix()}
+	assert.Nil(t, client.InsertData(database.IdempotencyTable, expired, context.Background()))
+
+	var stored database.IdempotencyRecord
+	assert.Nil(t, client.GetData(database.IdempotencyTable, key, &stored, context.Background()))
+	assert.Equal(t, expired.ExpiresAt, stored.ExpiresAt)
+	live := &database.IdempotencyRecord{Key: key.Key, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	err := client.InsertDataIfNotExists(database.IdempotencyTable, "Key", live, context.Background())
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+}
+
+func TestInsertAndRemoveDataIfMatches(t *testing.T) {
+	client := setUp(t)
+	key := &database.IdempotencyRecordKey{Key: "username1/key1"}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	reserved := &database.IdempotencyRecord{Key: key.Key, RequestHash: "hash1", LockExpiresAt: 10, ExpiresAt: expiresAt}
+	assert.Nil(t, client.InsertData(database.IdempotencyTable, reserved, context.Background()))
+
+	var conflictError *database.ConflictError
+	err := client.InsertDataIfMatches(database.IdempotencyTable, &database.IdempotencyRecord{Key: key.Key, Completed: true, ExpiresAt: expiresAt}, map[string]any{"RequestHash": "hash1", "LockExpiresAt": 20}, context.Background())
+	assert.ErrorAs(t, err, &conflictError)
+	err = client.RemoveDataIfMatches(database.IdempotencyTable, key, map[string]any{"RequestHash": "hash2", "LockExpiresAt": 10}, context.Background())
+	assert.ErrorAs(t, err, &conflictError)
+	err = client.InsertDataIfMatches(database.IdempotencyTable, &database.IdempotencyRecord{Key: key.Key, RequestHash: "hash1", Completed: true, LockExpiresAt: 10, ExpiresAt: expiresAt}, map[string]any{"RequestHash": "hash1", "LockExpiresAt": 10}, context.Background())
+	assert.Nil(t, err)
+	var stored database.IdempotencyRecord
+	assert.Nil(t, client.GetData(database.IdempotencyTable, key, &stored, context.Background()))
+	assert.True(t, stored.Completed)
+	assert.Nil(t, client.RemoveDataIfMatches(database.IdempotencyTable, key, map[string]any{"RequestHash": "hash1", "LockExpiresAt": 10}, context.Background()))
+	err = client.RemoveDataIfMatches(database.IdempotencyTable, key, map[string]any{"RequestHash": "hash1", "LockExpiresAt": 10}, context.Background())
+	assert.ErrorAs(t, err, &conflictError)
+}
+
+func TestErrorOnUpdateMissingData(t *testing.T) {
+	client := setUp(t)
+
+	err := client.UpdateData("Posts", &database.PostKey{PostId: "missing"}, map[string]any{"Title": "Novo titulo"}, context.Background())
+
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+}
+
+func TestUpdateDataIfVersionIncrementsVersion(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client, &postMetadata{PostId: "post1", User: "username1", Title: "Meu Post", Version: 1})
+	key := &database.PostKey{PostId: "post1"}
+
+	err := client.UpdateDataIfVersion("Posts", key, map[string]any{"Title": "Novo titulo"}, 1, context.Background())
+	assert.Nil(t, err)
+	err = client.UpdateDataIfVersion("Posts", key, map[string]any{"Title": "Outro titulo"}, 1, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	var stored postMetadata
+	assert.Nil(t, client.GetData("Posts", key, &stored, context.Background()))
+	assert.Equal(t, "Novo titulo", stored.Title)
+	assert.Equal(t, 2, stored.Version)
+}
+
+func TestRemoveDataIfVersion(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client, &postMetadata{PostId: "post1", User: "username1", Version: 2})
+	key := &database.PostKey{PostId: "post1"}
+
+	err := client.RemoveDataIfVersion("Posts", key, 1, context.Background())
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+
+	assert.Nil(t, client.RemoveDataIfVersion("Posts", key, 2, context.Background()))
+	err = client.RemoveDataIfVersion("Posts", key, 2, context.Background())
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+}
+
+func TestGetPostsByIdsAndRemoveMultipleData(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client,
+		&postMetadata{PostId: "post1", User: "username1"},
+		&postMetadata{PostId: "post2", User: "username1"},
+		&postMetadata{PostId: "post3", User: "username2"},
+	)
+
+	posts, err := client.GetPostsByIds([]string{"post1", "missing", "post3"}, context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, posts, 2)
+	assert.Equal(t, "post1", posts[0].PostId)
+	assert.Equal(t, "post3", posts[1].PostId)
+
+	err = client.RemoveMultipleData("Posts", []any{&database.PostKey{PostId: "post1"}, &database.PostKey{PostId: "post2"}}, context.Background())
+	assert.Nil(t, err)
+	posts, _ = client.GetPostsByIds([]string{"post1", "post2", "post3"}, context.Background())
+	assert.Len(t, posts, 1)
+}
+
+func TestGetPostsByIndexUserPagesInSortKeyOrder(t *testing.T) {
+	client := setUp(t)
+	for i := 5; i >= 1; i-- {
+		insertPosts(t, client, &postMetadata{
+			PostId:    fmt.Sprintf("post%d", i),
+			User:      "username1",
+			Type:      "TEXT",
+			CreatedAt: fmt.Sprintf("2024-08-0%dT21:51:20.000000Z", i),
+		})
+	}
+	insertPosts(t, client, &postMetadata{PostId: "other", User: "username2", CreatedAt: "2024-08-01T00:00:00.000000Z"})
+
+	firstPage, lastPostId, lastPostCreatedAt, err := client.GetPostsByIndexUser("username1", "", "", 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post1", "post2"}, postIds(firstPage))
+	assert.Equal(t, "post2", lastPostId)
+	assert.Equal(t, "2024-08-02T21:51:20.000000Z", lastPostCreatedAt)
+
+	secondPage, lastPostId, lastPostCreatedAt, err := client.GetPostsByIndexUser("username1", lastPostId, lastPostCreatedAt, 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post3", "post4"}, postIds(secondPage))
+
+	lastPage, lastPostId, lastPostCreatedAt, err := client.GetPostsByIndexUser("username1", lastPostId, lastPostCreatedAt, 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post5"}, postIds(lastPage))
+	assert.Empty(t, lastPostId)
+	assert.Empty(t, lastPostCreatedAt)
+}
+
+func TestGetPostsByIndexUserResumesAfterDeletedCursor(t *testing.T) {
+	client := setUp(t)
+	for i := 1; i <= 4; i++ {
+		insertPosts(t, client, &postMetadata{
+			PostId:    fmt.Sprintf("post%d", i),
+			User:      "username1",
+			Type:      "TEXT",
+			CreatedAt: fmt.Sprintf("2024-08-0%dT21:51:20.000000Z", i),
+		})
+	}
+	firstPage, lastPostId, lastPostCreatedAt, err := client.GetPostsByIndexUser("username1", "", "", 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post1", "post2"}, postIds(firstPage))
+	assert.Nil(t, client.RemoveData("Posts", &database.PostKey{PostId: lastPostId}, context.Background()))
+
+	secondPage, lastPostId, _, err := client.GetPostsByIndexUser("username1", lastPostId, lastPostCreatedAt, 2, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post3", "post4"}, postIds(secondPage))
+	assert.Empty(t, lastPostId)
+}
+
+func TestGetPostsByIndexTypeIsDescendingAndOnlyReturnsPublishedPosts(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client,
+		&postMetadata{PostId: "post1", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-01T21:51:20.000000Z"},
+		&postMetadata{PostId: "post2", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-02T21:51:20.000000Z", Status: database.PostStatusPending},
+		&postMetadata{PostId: "post3", User: "username2", Type: "IMAGE", CreatedAt: "2024-08-03T21:51:20.000000Z", Status: database.PostStatusPublished},
+		&postMetadata{PostId: "post4", User: "username2", Type: "VIDEO", CreatedAt: "2024-08-04T21:51:20.000000Z"},
+	)
+
+	posts, lastPostId, _, err := client.GetPostsByIndexType("IMAGE", "", "", 10, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post3", "post1"}, postIds(posts))
+	assert.Empty(t, lastPostId)
+}
+
+func TestGetPostsByIndexTypeFillsPagesPastUnpublishedPosts(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client,
+		&postMetadata{PostId: "post1", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-01T21:51:20.000000Z", Status: database.PostStatusPublished},
+		&postMetadata{PostId: "post2", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-02T21:51:20.000000Z", Status: database.PostStatusPublished},
+		&postMetadata{PostId: "post3", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-03T21:51:20.000000Z", Status: database.PostStatusPending},
+		&postMetadata{PostId: "post4", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-04T21:51:20.000000Z", Status: database.PostStatusPending},
+		&postMetadata{PostId: "post5", User: "username1", Type: "IMAGE", CreatedAt: "2024-08-05T21:51:20.000000Z", Status: database.PostStatusPublished},
+	)
+
+	firstPage, lastPostId, lastPostCreatedAt, err := client.GetPostsByIndexType("IMAGE", "", "", 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post5", "post2"}, postIds(firstPage))
+	assert.Equal(t, "post2", lastPostId)
+
+	lastPage, lastPostId, _, err := client.GetPostsByIndexType("IMAGE", lastPostId, lastPostCreatedAt, 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post1"}, postIds(lastPage))
+	assert.Empty(t, lastPostId)
+}
+
+func TestGetPostsByStatusCreatedBefore(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client,
+		&postMetadata{PostId: "post1", CreatedAt: "2024-08-01T21:51:20.000000Z", Status: database.PostStatusPending},
+		&postMetadata{PostId: "post2", CreatedAt: "2024-08-03T21:51:20.000000Z", Status: database.PostStatusPending},
+		&postMetadata{PostId: "post3", CreatedAt: "2024-08-01T21:51:20.000000Z", Status: database.PostStatusPublished},
+	)
+
+	posts, err := client.GetPostsByStatusCreatedBefore(database.PostStatusPending, "2024-08-02T00:00:00.000000Z", context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post1"}, postIds(posts))
+}
+
+func TestExecuteTransactionIsAtomic(t *testing.T) {
+	client := setUp(t)
+	insertPosts(t, client, &postMetadata{PostId: "post1", User: "username1"})
+	outboxEvent, _ := database.NewOutboxEvent("PostsWereDeletedEvent", []byte("data"), nil)
+
+	err := client.ExecuteTransaction([]database.TransactionOperation{
+		&database.RemoveOperation{TableName: "Posts", Key: &database.PostKey{PostId: "post1"}},
+		&database.InsertOperation{TableName: "Outbox", Item: outboxEvent},
+		&database.UpdateOperation{TableName: "Posts", Key: &database.PostKey{PostId: "missing"}, Attributes: map[string]any{"Title": "title"}},
+	}, context.Background())
+
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	posts, _ := client.GetPostsByIds([]string{"post1"}, context.Background())
+	assert.Len(t, posts, 1)
+	events, _ := client.GetPendingOutboxEvents(10, context.Background())
+	assert.Empty(t, events)
+}
+
+func TestGetPendingOutboxEventsInCreationOrder(t *testing.T) {
+	client := setUp(t)
+	firstEvent, _ := database.NewOutboxEvent("PostWasCreatedEvent", []byte("first"), nil)
+	firstEvent.CreatedAt = database.OutboxTimestamp(time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC))
+	secondEvent, _ := database.NewOutboxEvent("PostWasUpdatedEvent", []byte("second"), nil)
+	secondEvent.CreatedAt = database.OutboxTimestamp(time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC))
+	client.InsertData("Outbox", secondEvent, context.Background())
+	client.InsertData("Outbox", firstEvent, context.Background())
+	client.UpdateData("Outbox", &database.OutboxEventKey{EventId: secondEvent.EventId}, map[string]any{"Status": database.OutboxStatusFailed}, context.Background())
+	thirdEvent, _ := database.NewOutboxEvent("PostsWereDeletedEvent", []byte("third"), nil)
+	client.InsertData("Outbox", thirdEvent, context.Background())
+
+	events, err := client.GetPendingOutboxEvents(10, context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, firstEvent, events[0])
+	assert.Equal(t, thirdEvent, events[1])
+}
+
+func postIds(posts []*database.Post) []string {
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.PostId
+	}
+	return ids
+}
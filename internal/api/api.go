@@ -5,22 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"postservice/internal/health"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 type Api struct {
-	port        int
-	env         string
-	controllers []Controller
+	port              int
+	env               string
+	health            *health.Health
+	authenticator     Authenticator
+	idempotencyStore  IdempotencyStore
+	idempotencyKeyTtl time.Duration
+	controllers       []Controller
+	publicControllers []Controller
 }
 
-func NewApiEndpoint(env string, controllers []Controller) *Api {
+func NewApiEndpoint(env string, port int, health *health.Health, authenticator Authenticator, idempotencyStore IdempotencyStore, idempotencyKeyTtl time.Duration, controllers []Controller, publicControllers []Controller) *Api {
 	return &Api{
-		port:        6666,
-		env:         env,
-		controllers: controllers,
+		port:              port,
+		env:               env,
+		health:            health,
+		authenticator:     authenticator,
+		idempotencyStore:  idempotencyStore,
+		idempotencyKeyTtl: idempotencyKeyTtl,
+		controllers:       controllers,
+		publicControllers: publicControllers,
 	}
 }
 
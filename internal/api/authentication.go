@@ -0,0 +1,72 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const identityContextKey = "identity"
+
+type Identity struct {
+	Username string
+	Subject  string
+}
+
+type Authenticator interface {
+	Authenticate(token string) (*Identity, error)
+}
+
+func Authentication(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			SendUnauthorized(c, "Missing bearer token")
+			c.Abort()
+			return
+		}
+
+		identity, err := authenticator.Authenticate(token)
+		if err != nil {
+			log.Warn().Err(err).Msg("Authentication failed")
+			SendUnauthorized(c, "Invalid bearer token")
+			c.Abort()
+			return
+		}
+
+		SetIdentity(c, identity)
+		c.Next()
+	}
+}
+
+func SetIdentity(c *gin.Context, identity *Identity) {
+	c.Set(identityContextKey, identity)
+}
+
+func GetIdentity(c *gin.Context) (*Identity, bool) {
+	value, exists := c.Get(identityContextKey)
+	if !exists {
+		return nil, false
+	}
+	identity, ok := value.(*Identity)
+	return identity, ok
+}
+
+func GetUsername(c *gin.Context) string {
+	identity, ok := GetIdentity(c)
+	if !ok {
+		return ""
+	}
+	return identity.Username
+}
+
+func bearerToken(authorizationHeader string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(authorizationHeader), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
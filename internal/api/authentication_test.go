@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"postservice/internal/api"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const hmacSecret = "test-secret"
+
+func TestAuthenticateHS256Token(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	token := signHS256(t, map[string]any{"sub": "subject1", "username": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	identity, err := authenticator.Authenticate(token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "username1", identity.Username)
+	assert.Equal(t, "subject1", identity.Subject)
+}
+
+func TestAuthenticateFallsBackToSubject(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	token := signHS256(t, map[string]any{"sub": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	identity, err := authenticator.Authenticate(token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "username1", identity.Username)
+}
+
+func TestErrorOnAuthenticateExpiredToken(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	token := signHS256(t, map[string]any{"username": "username1", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	_, err := authenticator.Authenticate(token)
+
+	assert.EqualError(t, err, "token is expired")
+}
+
+func TestErrorOnAuthenticateTokenWithoutExpiration(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	token := signHS256(t, map[string]any{"username": "username1"})
+
+	_, err := authenticator.Authenticate(token)
+
+	assert.EqualError(t, err, "token has no expiration")
+}
+
+func TestErrorOnAuthenticateTokenWithWrongSignature(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: "another-secret"})
+	token := signHS256(t, map[string]any{"username": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	_, err := authenticator.Authenticate(token)
+
+	assert.EqualError(t, err, "invalid token signature")
+}
+
+func TestErrorOnAuthenticateUnsignedToken(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	token := encodeSegment(t, map[string]any{"alg": "none"}) + "." + encodeSegment(t, map[string]any{"username": "username1", "exp": time.Now().Add(time.Hour).Unix()}) + "."
+
+	_, err := authenticator.Authenticate(token)
+
+	assert.EqualError(t, err, `unsupported token algorithm "none"`)
+}
+
+func TestErrorOnAuthenticateTokenWithWrongIssuerOrAudience(t *testing.T) {
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret, Issuer: "issuer1", Audience: "postservice"})
+	wrongIssuerToken := signHS256(t, map[string]any{"username": "username1", "iss": "issuer2", "aud": "postservice", "exp": time.Now().Add(time.Hour).Unix()})
+	wrongAudienceToken := signHS256(t, map[string]any{"username": "username1", "iss": "issuer1", "aud": []string{"other"}, "exp": time.Now().Add(time.Hour).Unix()})
+	validToken := signHS256(t, map[string]any{"username": "username1", "iss": "issuer1", "aud": []string{"other", "postservice"}, "exp": time.Now().Add(time.Hour).Unix()})
+
+	_, wrongIssuerErr := authenticator.Authenticate(wrongIssuerToken)
+	_, wrongAudienceErr := authenticator.Authenticate(wrongAudienceToken)
+	_, validErr := authenticator.Authenticate(validToken)
+
+	assert.EqualError(t, wrongIssuerErr, "token issuer is not accepted")
+	assert.EqualError(t, wrongAudienceErr, "token audience is not accepted")
+	assert.Nil(t, validErr)
+}
+
+func TestAuthenticateRS256TokenWithJwksFile(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksFile := writeJwks(t, "key1", &privateKey.PublicKey)
+	authenticator, err := api.NewJwtAuthenticator(api.JwtConfig{JwksFile: jwksFile})
+	assert.Nil(t, err)
+	token := signRS256(t, privateKey, "key1", map[string]any{"username": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	identity, err := authenticator.Authenticate(token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "username1", identity.Username)
+}
+
+func TestErrorOnAuthenticateHS256TokenWhenOnlyRsaKeysAreConfigured(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksFile := writeJwks(t, "key1", &privateKey.PublicKey)
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{JwksFile: jwksFile})
+	token := signHS256(t, map[string]any{"username": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	_, err := authenticator.Authenticate(token)
+
+	assert.EqualError(t, err, "HS256 tokens are not accepted")
+}
+
+func TestErrorOnNewJwtAuthenticatorWithoutKeys(t *testing.T) {
+	_, err := api.NewJwtAuthenticator(api.JwtConfig{})
+
+	assert.EqualError(t, err, "no JWT verification keys configured")
+}
+
+func TestAuthenticationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator, _ := api.NewJwtAuthenticator(api.JwtConfig{HmacSecret: hmacSecret})
+	router := gin.New()
+	router.Use(api.Authentication(authenticator))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, api.GetUsername(c))
+	})
+	token := signHS256(t, map[string]any{"username": "username1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	authenticatedResponse := httptest.NewRecorder()
+	authenticatedRequest := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	authenticatedRequest.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(authenticatedResponse, authenticatedRequest)
+
+	anonymousResponse := httptest.NewRecorder()
+	router.ServeHTTP(anonymousResponse, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+
+	assert.Equal(t, http.StatusOK, authenticatedResponse.Code)
+	assert.Equal(t, "username1", authenticatedResponse.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, anonymousResponse.Code)
+	assert.Contains(t, anonymousResponse.Body.String(), "Missing bearer token")
+}
+
+func signHS256(t *testing.T, claims map[string]any) string {
+	signingInput := encodeSegment(t, map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(hmacSecret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, privateKey *rsa.PrivateKey, keyId string, claims map[string]any) string {
+	signingInput := encodeSegment(t, map[string]any{"alg": "RS256", "typ": "JWT", "kid": keyId}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeSegment(t *testing.T, data any) string {
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(serialized)
+}
+
+func writeJwks(t *testing.T, keyId string, publicKey *rsa.PublicKey) string {
+	jwks := map[string]any{
+		"keys": []map[string]any{
+			{
+				"kty": "RSA",
+				"kid": keyId,
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			},
+		},
+	}
+	serialized, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, serialized, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
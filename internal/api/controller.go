@@ -11,9 +11,11 @@ type Controller interface {
 }
 
 type response struct {
-	Error   bool   `json:"error"`
-	Message string `json:"message"`
-	Content any    `json:"content"`
+	Error   bool         `json:"error"`
+	Code    ErrorCode    `json:"code,omitempty"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	Content any          `json:"content"`
 }
 
 func SendOK(c *gin.Context) {
@@ -34,18 +36,48 @@ func SendOKWithResult(c *gin.Context, result any) {
 }
 
 func SendFailure(c *gin.Context, httpStatus int, errorMessage string) {
+	sendFailure(c, httpStatus, &Error{Code: codeForStatus(httpStatus), Message: errorMessage})
+}
+
+func sendFailure(c *gin.Context, httpStatus int, apiError *Error) {
 	var payload response
 
 	payload.Error = true
-	payload.Message = errorMessage
+	payload.Code = apiError.Code
+	payload.Message = apiError.Message
+	payload.Fields = apiError.Fields
 
 	c.IndentedJSON(httpStatus, payload)
 }
 
+func codeForStatus(httpStatus int) ErrorCode {
+	for code, status := range statusByCode {
+		if status == httpStatus {
+			return code
+		}
+	}
+	if httpStatus >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeValidation
+}
+
 func SendNotFound(c *gin.Context, errorMessage string) {
 	SendFailure(c, http.StatusNotFound, errorMessage)
 }
 
+func SendUnauthorized(c *gin.Context, errorMessage string) {
+	SendFailure(c, http.StatusUnauthorized, errorMessage)
+}
+
+func SendForbidden(c *gin.Context, errorMessage string) {
+	SendFailure(c, http.StatusForbidden, errorMessage)
+}
+
+func SendConflict(c *gin.Context, errorMessage string) {
+	SendFailure(c, http.StatusConflict, errorMessage)
+}
+
 func SendInternalServerError(c *gin.Context, errorMessage string) {
 	SendFailure(c, http.StatusInternalServerError, errorMessage)
 }
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	database "postservice/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorCode is the machine-readable kind of a failed response, stable across
+// changes of its message.
+type ErrorCode string
+
+const (
+	CodeValidation   ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	CodeForbidden    ErrorCode = "FORBIDDEN"
+	CodeNotFound     ErrorCode = "NOT_FOUND"
+	CodeConflict     ErrorCode = "CONFLICT"
+	CodePrecondition ErrorCode = "PRECONDITION_FAILED"
+	// CodeIdempotencyKeyReused rejects a request whose Idempotency-Key was
+	// used for a different one.
+	CodeIdempotencyKeyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeUnavailable          ErrorCode = "UNAVAILABLE"
+	CodeInternal             ErrorCode = "INTERNAL_ERROR"
+)
+
+var statusByCode = map[ErrorCode]int{
+	CodeValidation:           http.StatusBadRequest,
+	CodeUnauthorized:         http.StatusUnauthorized,
+	CodeForbidden:            http.StatusForbidden,
+	CodeNotFound:             http.StatusNotFound,
+	CodeConflict:             http.StatusConflict,
+	CodePrecondition:         http.StatusPreconditionFailed,
+	CodeIdempotencyKeyReused: http.StatusUnprocessableEntity,
+	CodeUnavailable:          http.StatusServiceUnavailable,
+	CodeInternal:             http.StatusInternalServerError,
+}
+
+// Error is a failure with a message that is safe to send to the client. The
+// wrapped error keeps the internal details, which only go to the logs.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError tells which field of the request is not valid and why.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	for _, field := range e.Fields {
+		message += "; " + field.Field + ": " + field.Message
+	}
+	if e.Err == nil {
+		return message
+	}
+	return message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NewValidationError(message string) *Error {
+	return &Error{Code: CodeValidation, Message: message}
+}
+
+func NewFieldsValidationError(fields []FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: "Invalid request fields", Fields: fields}
+}
+
+func NewNotFoundError(message string, err error) *Error {
+	return &Error{Code: CodeNotFound, Message: message, Err: err}
+}
+
+func NewForbiddenError(message string, err error) *Error {
+	return &Error{Code: CodeForbidden, Message: message, Err: err}
+}
+
+func NewConflictError(message string, err error) *Error {
+	return &Error{Code: CodeConflict, Message: message, Err: err}
+}
+
+func NewPreconditionFailedError(message string, err error) *Error {
+	return &Error{Code: CodePrecondition, Message: message, Err: err}
+}
+
+func NewUnavailableError(message string, err error) *Error {
+	return &Error{Code: CodeUnavailable, Message: message, Err: err}
+}
+
+// SendError answers with the status and code of the kind of err. Errors that
+// are not an *Error get a generic message, so their details stay in the logs.
+func SendError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	respondError(c, err)
+}
+
+// Errors answers the requests whose handlers attached errors to the context
+// without writing a response body, like the ones aborted by BindJSON.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Size() > 0 {
+			return
+		}
+		lastError := c.Errors.Last()
+		if lastError.IsType(gin.ErrorTypeBind) {
+			respondError(c, &Error{Code: CodeValidation, Message: "Invalid request", Err: lastError.Err})
+			return
+		}
+		respondError(c, lastError.Err)
+	}
+}
+
+func respondError(c *gin.Context, err error) {
+	apiError := toApiError(err)
+	status := statusByCode[apiError.Code]
+	if status >= http.StatusInternalServerError {
+		log.Error().Stack().Err(err).Msgf("Request %s %s failed", c.Request.Method, c.Request.URL.Path)
+	} else {
+		log.Info().Err(err).Msgf("Request %s %s rejected with %s", c.Request.Method, c.Request.URL.Path, apiError.Code)
+	}
+
+	sendFailure(c, status, apiError)
+}
+
+func toApiError(err error) *Error {
+	var apiError *Error
+	var notFoundError *database.NotFoundError
+	var forbiddenError *database.ForbiddenError
+	var conflictError *database.ConflictError
+	var unavailableError *database.UnavailableError
+	switch {
+	case errors.As(err, &apiError):
+		return apiError
+	case errors.As(err, &notFoundError):
+		return NewNotFoundError("Resource not found", err)
+	case errors.As(err, &forbiddenError):
+		return NewForbiddenError("Operation not allowed", err)
+	case errors.As(err, &conflictError):
+		return NewConflictError("Resource conflicts with its current state", err)
+	case errors.As(err, &unavailableError), errors.Is(err, context.DeadlineExceeded):
+		return NewUnavailableError("Service temporarily unavailable, try again later", err)
+	default:
+		return &Error{Code: CodeInternal, Message: "Internal server error", Err: err}
+	}
+}
@@ -0,0 +1,99 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"postservice/internal/api"
+	database "postservice/internal/db"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type errorResponse struct {
+	Error   bool   `json:"error"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func serveError(t *testing.T, handler gin.HandlerFunc) (int, errorResponse) {
+	log.Logger = log.Output(io.Discard)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(api.Errors())
+	router.POST("/test", handler)
+	recorder := httptest.NewRecorder()
+
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/test", strings.NewReader("not json")))
+
+	var body errorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, body
+}
+
+func TestSendErrorMapsErrorKinds(t *testing.T) {
+	testCases := map[string]struct {
+		err     error
+		status  int
+		code    api.ErrorCode
+		message string
+	}{
+		"validation":           {api.NewValidationError("Invalid title"), http.StatusBadRequest, api.CodeValidation, "Invalid title"},
+		"api not found":        {api.NewNotFoundError("Post not found", database.NewNotFoundError("Posts", "post1")), http.StatusNotFound, api.CodeNotFound, "Post not found"},
+		"database not found":   {database.NewNotFoundError("Posts", "post1"), http.StatusNotFound, api.CodeNotFound, "Resource not found"},
+		"database forbidden":   {fmt.Errorf("removing: %w", database.NewForbiddenError("Posts", "post1", "username1")), http.StatusForbidden, api.CodeForbidden, "Operation not allowed"},
+		"database conflict":    {database.NewConflictError("Posts", "post1", "version changed"), http.StatusConflict, api.CodeConflict, "Resource conflicts with its current state"},
+		"database unavailable": {database.NewUnavailableError(errors.New("throttled")), http.StatusServiceUnavailable, api.CodeUnavailable, "Service temporarily unavailable, try again later"},
+		"deadline exceeded":    {context.DeadlineExceeded, http.StatusServiceUnavailable, api.CodeUnavailable, "Service temporarily unavailable, try again later"},
+		"internal":             {errors.New("AccessDeniedException: arn:aws:dynamodb:eu-west-3:123:table/Posts"), http.StatusInternalServerError, api.CodeInternal, "Internal server error"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			status, body := serveError(t, func(c *gin.Context) {
+				api.SendError(c, testCase.err)
+			})
+
+			assert.Equal(t, testCase.status, status)
+			assert.Equal(t, errorResponse{Error: true, Code: string(testCase.code), Message: testCase.message}, body)
+		})
+	}
+}
+
+func TestErrorsAnswersUnwrittenErrors(t *testing.T) {
+	status, body := serveError(t, func(c *gin.Context) {
+		_ = c.Error(database.NewNotFoundError("Posts", "post1"))
+	})
+
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, errorResponse{Error: true, Code: string(api.CodeNotFound), Message: "Resource not found"}, body)
+}
+
+func TestErrorsAnswersBindingErrorsAsValidation(t *testing.T) {
+	status, body := serveError(t, func(c *gin.Context) {
+		var payload map[string]any
+		_ = c.BindJSON(&payload)
+	})
+
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, errorResponse{Error: true, Code: string(api.CodeValidation), Message: "Invalid request"}, body)
+}
+
+func TestSendFailureSetsCodeOfStatus(t *testing.T) {
+	status, body := serveError(t, func(c *gin.Context) {
+		api.SendUnauthorized(c, "Missing bearer token")
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, errorResponse{Error: true, Code: string(api.CodeUnauthorized), Message: "Missing bearer token"}, body)
+}
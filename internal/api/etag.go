@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetETag sends the version of the resource in the response as its entity tag.
+func SetETag(c *gin.Context, version int) {
+	c.Header("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// IfMatchVersion returns the version the If-Match header of the request
+// expects, or nil when the request accepts any version. If-Match uses the
+// strong comparison, so a weak entity tag never matches.
+func IfMatchVersion(c *gin.Context) (*int, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(ifMatch, "W/") {
+		return nil, NewPreconditionFailedError("If-Match can't match a weak entity tag", nil)
+	}
+	tag := ifMatch
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return nil, NewValidationError("If-Match has to be a single quoted entity tag")
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 0 {
+		return nil, NewValidationError("If-Match has to be an entity tag returned by the ETag header")
+	}
+
+	return &version, nil
+}
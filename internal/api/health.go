@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"postservice/internal/health"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (api *Api) liveness(c *gin.Context) {
+	SendOK(c)
+}
+
+func (api *Api) readiness(c *gin.Context) {
+	report := api.health.Readiness(c.Request.Context())
+	if !report.Ready {
+		message := "Service is " + report.State
+		if report.State == health.StateRunning {
+			message = "Service dependencies are down: " + strings.Join(report.FailedChecks(), ", ")
+		}
+		c.IndentedJSON(http.StatusServiceUnavailable, response{
+			Error:   true,
+			Message: message,
+			Content: report,
+		})
+		return
+	}
+
+	SendOKWithResult(c, report)
+}
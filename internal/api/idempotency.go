@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	database "postservice/internal/db"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	// maxIdempotentBodySize bounds the bodies read into memory to hash them
+	// and the responses saved, far above the size of any post request.
+	maxIdempotentBodySize = 1 << 20
+	// idempotencyLockTimeout is how long a request keeps its key before a retry
+	// can take it over, in case the instance serving it died.
+	idempotencyLockTimeout = time.Minute
+)
+
+// replayedHeaders are the headers of a response that are replayed with its body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyStore saves the responses of the requests sent with an
+// Idempotency-Key header.
+type IdempotencyStore interface {
+	// Reserve saves record unless a live record has its key, which it returns.
+	Reserve(record *database.IdempotencyRecord, ctx context.Context) (*database.IdempotencyRecord, error)
+	// Complete and Release only apply while record still holds its key,
+	// which a retry takes over once the lock of record expires.
+	Complete(record *database.IdempotencyRecord, ctx context.Context) error
+	Release(record *database.IdempotencyRecord, ctx context.Context) error
+}
+
+// Idempotency runs the mutating requests with an Idempotency-Key header once
+// per user and key: retries get the saved response back for ttl, and the key
+// can not be reused for a different request. Failures that are worth retrying,
+// the 5xx ones, are not saved.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			SendError(c, NewValidationError("Idempotency-Key can have at most 255 characters"))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			SendError(c, NewValidationError(fmt.Sprintf("Request body can have at most %d bytes", maxIdempotentBodySize)))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			SendError(c, &Error{Code: CodeValidation, Message: "Invalid request", Err: err})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := &database.IdempotencyRecord{
+			Key:           GetUsername(c) + "/" + key,
+			RequestHash:   requestHash(c.Request, body),
+			CreatedAt:     now.UTC().Format(time.RFC3339),
+			LockExpiresAt: now.Add(idempotencyLockTimeout).Unix(),
+			ExpiresAt:     now.Add(ttl).Unix(),
+		}
+		storedRecord, err := store.Reserve(record, c.Request.Context())
+		if err != nil {
+			SendError(c, err)
+			c.Abort()
+			return
+		}
+		if storedRecord != nil {
+			replay(c, storedRecord, record)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The response is gone already, losing the client must not lose the record.
+		ctx := context.WithoutCancel(c.Request.Context())
+		if recorder.Size() <= 0 || recorder.Status() >= http.StatusInternalServerError {
+			err = store.Release(record, ctx)
+			if err != nil {
+				log.Error().Stack().Err(err).Msgf("Error releasing Idempotency-Key %s", record.Key)
+			}
+			return
+		}
+
+		record.Completed = true
+		record.StatusCode = recorder.Status()
+		record.Headers = make(map[string]string)
+		for _, header := range replayedHeaders {
+			if value := recorder.Header().Get(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		record.Body = recorder.body.Bytes()
+		err = store.Complete(record, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error saving the response of Idempotency-Key %s", record.Key)
+		}
+	}
+}
+
+func replay(c *gin.Context, storedRecord, record *database.IdempotencyRecord) {
+	if !storedRecord.Completed {
+		SendError(c, NewConflictError("A request with the same Idempotency-Key is still in progress", nil))
+		return
+	}
+	if storedRecord.RequestHash != record.RequestHash {
+		SendError(c, &Error{Code: CodeIdempotencyKeyReused, Message: "Idempotency-Key was already used for a different request"})
+		return
+	}
+
+	log.Info().Msgf("Replaying the response of Idempotency-Key %s", record.Key)
+	for header, value := range storedRecord.Headers {
+		c.Header(header, value)
+	}
+	c.Header(idempotencyReplayedHeader, "true")
+	c.Data(storedRecord.StatusCode, storedRecord.Headers["Content-Type"], storedRecord.Body)
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// requestHash identifies a request by its method, URI and body, so a key can
+// only be retried with the same request.
+func requestHash(request *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the body written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
+
+// DatabaseIdempotencyStore saves the records in the IdempotencyKeys table.
+type DatabaseIdempotencyStore struct {
+	database *database.Database
+}
+
+func NewDatabaseIdempotencyStore(database *database.Database) *DatabaseIdempotencyStore {
+	return &DatabaseIdempotencyStore{
+		database: database,
+	}
+}
+
+// Reserve replaces the records that outlived their time to live or their lock,
+// as the database can keep them a while after they expire.
+func (s *DatabaseIdempotencyStore) Reserve(record *database.IdempotencyRecord, ctx context.Context) (*database.IdempotencyRecord, error) {
+	for attempt := 1; ; attempt++ {
+		err := s.database.Client.InsertDataIfNotExists(database.IdempotencyTable, "Key", record, ctx)
+		var conflictError *database.ConflictError
+		if err == nil || !errors.As(err, &conflictError) {
+			return nil, err
+		}
+
+		var storedRecord database.IdempotencyRecord
+		err = s.database.Client.GetData(database.IdempotencyTable, &database.IdempotencyRecordKey{Key: record.Key}, &storedRecord, ctx)
+		var notFoundError *database.NotFoundError
+		if errors.As(err, &notFoundError) && attempt < 2 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now().Unix()
+		live := storedRecord.ExpiresAt > now && (storedRecord.Completed || storedRecord.LockExpiresAt > now)
+		if live || attempt >= 2 {
+			return &storedRecord, nil
+		}
+		// A concurrent retry that took over the record first makes this fail.
+		err = s.Release(&storedRecord, ctx)
+		if err != nil && !errors.As(err, &conflictError) {
+			return nil, err
+		}
+	}
+}
+
+func (s *DatabaseIdempotencyStore) Complete(record *database.IdempotencyRecord, ctx context.Context) error {
+	return s.database.Client.InsertDataIfMatches(database.IdempotencyTable, record, reservation(record), ctx)
+}
+
+func (s *DatabaseIdempotencyStore) Release(record *database.IdempotencyRecord, ctx context.Context) error {
+	return s.database.Client.RemoveDataIfMatches(database.IdempotencyTable, &database.IdempotencyRecordKey{Key: record.Key}, reservation(record), ctx)
+}
+
+// reservation identifies the request that reserved a key, as the lock of the
+// request that takes it over always expires later.
+func reservation(record *database.IdempotencyRecord) map[string]any {
+	return map[string]any{
+		"RequestHash":   record.RequestHash,
+		"LockExpiresAt": record.LockExpiresAt,
+	}
+}
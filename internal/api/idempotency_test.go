@@ -0,0 +1,241 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"postservice/infrastructure/memory"
+	"postservice/internal/api"
+	database "postservice/internal/db"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpIdempotency(t *testing.T, handler gin.HandlerFunc) (*gin.Engine, *api.DatabaseIdempotencyStore) {
+	log.Logger = log.Output(io.Discard)
+	gin.SetMode(gin.TestMode)
+	db := database.NewDatabase(memory.NewDatabaseClient())
+	if err := db.ApplyMigrations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store := api.NewDatabaseIdempotencyStore(db)
+	router := gin.New()
+	router.Use(api.Errors())
+	router.Use(func(c *gin.Context) {
+		api.SetIdentity(c, &api.Identity{Username: "username1"})
+	})
+	router.Use(api.Idempotency(store, time.Hour))
+	router.POST("/post", handler)
+	return router, store
+}
+
+func sendWithIdempotencyKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/post", strings.NewReader(body))
+	if key != "" {
+		request.Header.Set("Idempotency-Key", key)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	calls := 0
+	router, _ := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SetETag(c, calls)
+		api.SendOKWithResult(c, map[string]int{"call": calls})
+	})
+
+	first := sendWithIdempotencyKey(router, "key1", `{"title":"Meu Post"}`)
+	retry := sendWithIdempotencyKey(router, "key1", `{"title":"Meu Post"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotencyRejectsKeyReusedForADifferentRequest(t *testing.T) {
+	router, _ := setUpIdempotency(t, func(c *gin.Context) {
+		api.SendOK(c)
+	})
+
+	sendWithIdempotencyKey(router, "key1", `{"title":"Meu Post"}`)
+	response := sendWithIdempotencyKey(router, "key1", `{"title":"Outro Post"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	assert.Contains(t, response.Body.String(), string(api.CodeIdempotencyKeyReused))
+}
+
+func TestIdempotencyRunsAgainAfterServerErrors(t *testing.T) {
+	calls := 0
+	router, _ := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SendError(c, database.NewUnavailableError(context.DeadlineExceeded))
+	})
+
+	sendWithIdempotencyKey(router, "key1", `{}`)
+	response := sendWithIdempotencyKey(router, "key1", `{}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+}
+
+func TestIdempotencyIgnoresRequestsWithoutKey(t *testing.T) {
+	calls := 0
+	router, _ := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SendOK(c)
+	})
+
+	sendWithIdempotencyKey(router, "", `{}`)
+	sendWithIdempotencyKey(router, "", `{}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyRejectsRetriesWhileTheRequestRuns(t *testing.T) {
+	router, store := setUpIdempotency(t, func(c *gin.Context) {
+		t.Fatal("the handler must not run")
+	})
+	now := time.Now()
+	running := &database.IdempotencyRecord{
+		Key:           "username1/key1",
+		CreatedAt:     now.UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(time.Minute).Unix(),
+		ExpiresAt:     now.Add(time.Hour).Unix(),
+	}
+	_, err := store.Reserve(running, context.Background())
+	assert.Nil(t, err)
+
+	response := sendWithIdempotencyKey(router, "key1", `{}`)
+
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestIdempotencyTakesOverKeysOfAbandonedRequests(t *testing.T) {
+	calls := 0
+	router, store := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SendOK(c)
+	})
+	now := time.Now()
+	abandoned := &database.IdempotencyRecord{
+		Key:           "username1/key1",
+		CreatedAt:     now.Add(-2 * time.Minute).UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(-time.Minute).Unix(),
+		ExpiresAt:     now.Add(time.Hour).Unix(),
+	}
+	_, err := store.Reserve(abandoned, context.Background())
+	assert.Nil(t, err)
+
+	response := sendWithIdempotencyKey(router, "key1", `{}`)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyKeysAreScopedByUser(t *testing.T) {
+	calls := 0
+	router, store := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SendOK(c)
+	})
+	now := time.Now()
+	otherUserRecord := &database.IdempotencyRecord{
+		Key:           "username2/key1",
+		Completed:     true,
+		StatusCode:    http.StatusOK,
+		CreatedAt:     now.UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(time.Minute).Unix(),
+		ExpiresAt:     now.Add(time.Hour).Unix(),
+	}
+	_, err := store.Reserve(otherUserRecord, context.Background())
+	assert.Nil(t, err)
+
+	sendWithIdempotencyKey(router, "key1", `{}`)
+
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyDoesNotCompleteKeysTakenOver(t *testing.T) {
+	_, store := setUpIdempotency(t, func(c *gin.Context) {})
+	now := time.Now()
+	abandoned := &database.IdempotencyRecord{
+		Key:           "username1/key1",
+		RequestHash:   "hash1",
+		CreatedAt:     now.Add(-2 * time.Minute).UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(-time.Minute).Unix(),
+		ExpiresAt:     now.Add(time.Hour).Unix(),
+	}
+	_, err := store.Reserve(abandoned, context.Background())
+	assert.Nil(t, err)
+	retry := &database.IdempotencyRecord{
+		Key:           "username1/key1",
+		RequestHash:   "hash1",
+		CreatedAt:     now.UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(time.Minute).Unix(),
+		ExpiresAt:     now.Add(time.Hour).Unix(),
+	}
+	storedRecord, err := store.Reserve(retry, context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, storedRecord)
+
+	abandoned.Completed = true
+	err = store.Complete(abandoned, context.Background())
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	err = store.Release(abandoned, context.Background())
+	assert.ErrorAs(t, err, &conflictError)
+
+	storedRecord, err = store.Reserve(retry, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, retry.LockExpiresAt, storedRecord.LockExpiresAt)
+	assert.False(t, storedRecord.Completed)
+}
+
+func TestIdempotencyRejectsTooLargeBodies(t *testing.T) {
+	router, _ := setUpIdempotency(t, func(c *gin.Context) {
+		t.Fatal("the handler must not run")
+	})
+
+	response := sendWithIdempotencyKey(router, "key1", strings.Repeat("a", 1<<20+1))
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Request body can have at most 1048576 bytes")
+}
+
+func TestIdempotencyRunsAgainAfterTheRecordExpired(t *testing.T) {
+	calls := 0
+	router, store := setUpIdempotency(t, func(c *gin.Context) {
+		calls++
+		api.SendOK(c)
+	})
+	now := time.Now()
+	expired := &database.IdempotencyRecord{
+		Key:           "username1/key1",
+		Completed:     true,
+		StatusCode:    http.StatusOK,
+		CreatedAt:     now.Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+		LockExpiresAt: now.Add(-2 * time.Hour).Unix(),
+		ExpiresAt:     now.Add(-time.Hour).Unix(),
+	}
+	_, err := store.Reserve(expired, context.Background())
+	assert.Nil(t, err)
+
+	response := sendWithIdempotencyKey(router, "key1", `{}`)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+const clockSkew = 30 * time.Second
+
+type JwtConfig struct {
+	HmacSecret       string
+	RsaPublicKeyFile string
+	JwksFile         string
+	Issuer           string
+	Audience         string
+	UsernameClaim    string
+}
+
+type JwtAuthenticator struct {
+	hmacSecret    []byte
+	rsaKeys       map[string]*rsa.PublicKey
+	issuer        string
+	audience      string
+	usernameClaim string
+	now           func() time.Time
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+func NewJwtAuthenticator(config JwtConfig) (*JwtAuthenticator, error) {
+	authenticator := &JwtAuthenticator{
+		hmacSecret:    []byte(config.HmacSecret),
+		rsaKeys:       map[string]*rsa.PublicKey{},
+		issuer:        config.Issuer,
+		audience:      config.Audience,
+		usernameClaim: config.UsernameClaim,
+		now:           time.Now,
+	}
+	if authenticator.usernameClaim == "" {
+		authenticator.usernameClaim = "username"
+	}
+
+	if config.RsaPublicKeyFile != "" {
+		key, err := loadRsaPublicKey(config.RsaPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.rsaKeys[""] = key
+	}
+
+	if config.JwksFile != "" {
+		keys, err := loadJwks(config.JwksFile)
+		if err != nil {
+			return nil, err
+		}
+		for kid, key := range keys {
+			authenticator.rsaKeys[kid] = key
+		}
+	}
+
+	if len(authenticator.hmacSecret) == 0 && len(authenticator.rsaKeys) == 0 {
+		return nil, errors.New("no JWT verification keys configured")
+	}
+
+	return authenticator, nil
+}
+
+func (a *JwtAuthenticator) Authenticate(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature encoding: %w", err)
+	}
+
+	err = a.verifySignature(header, parts[0]+"."+parts[1], signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	err = a.validateClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.identityFromClaims(claims)
+}
+
+func (a *JwtAuthenticator) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	switch header.Algorithm {
+	case "HS256":
+		if len(a.hmacSecret) == 0 {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, a.hmacSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case "RS256":
+		if len(a.rsaKeys) == 0 {
+			return errors.New("RS256 tokens are not accepted")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		for _, key := range a.candidateRsaKeys(header.KeyId) {
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		}
+		return errors.New("invalid token signature")
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", header.Algorithm)
+	}
+}
+
+func (a *JwtAuthenticator) candidateRsaKeys(keyId string) []*rsa.PublicKey {
+	if key, ok := a.rsaKeys[keyId]; ok && keyId != "" {
+		return []*rsa.PublicKey{key}
+	}
+
+	keys := make([]*rsa.PublicKey, 0, len(a.rsaKeys))
+	for _, key := range a.rsaKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (a *JwtAuthenticator) validateClaims(claims map[string]any) error {
+	now := a.now()
+
+	expiresAt, ok := numericClaim(claims, "exp")
+	if !ok {
+		return errors.New("token has no expiration")
+	}
+	if now.After(time.Unix(expiresAt, 0).Add(clockSkew)) {
+		return errors.New("token is expired")
+	}
+
+	if notBefore, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(time.Unix(notBefore, 0)) {
+		return errors.New("token is not valid yet")
+	}
+
+	if a.issuer != "" && claims["iss"] != a.issuer {
+		return errors.New("token issuer is not accepted")
+	}
+
+	if a.audience != "" && !containsAudience(claims["aud"], a.audience) {
+		return errors.New("token audience is not accepted")
+	}
+
+	return nil
+}
+
+func (a *JwtAuthenticator) identityFromClaims(claims map[string]any) (*Identity, error) {
+	subject, _ := claims["sub"].(string)
+	username, _ := claims[a.usernameClaim].(string)
+	if username == "" {
+		username = subject
+	}
+	if username == "" {
+		return nil, errors.New("token has no username")
+	}
+
+	return &Identity{
+		Username: username,
+		Subject:  subject,
+	}, nil
+}
+
+func decodeSegment(segment string, result any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func numericClaim(claims map[string]any, name string) (int64, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+func containsAudience(audienceClaim any, audience string) bool {
+	switch value := audienceClaim.(type) {
+	case string:
+		return value == audience
+	case []any:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func loadRsaPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key in %s is not an RSA key", path)
+	}
+	return rsaKey, nil
+}
+
+func loadJwks(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet jwks
+	err = json.Unmarshal(data, &keySet)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range keySet.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		publicKey, err := key.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %s: %w", key.KeyId, err)
+		}
+		keys[key.KeyId] = publicKey
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA signing keys found in %s", path)
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(k.Modulus)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(k.Exponent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"postservice/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var httpRequestDuration = metrics.NewHistogram("postservice_http_request_duration_seconds", "Duration of the HTTP requests by route.", metrics.DefaultBuckets, "method", "route", "status")
+
+// Metrics times every request under its route pattern, so path parameters
+// do not multiply the series.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
+
+func (api *Api) metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err := metrics.Write(c.Writer)
+	if err != nil {
+		SendError(c, err)
+	}
+}
@@ -10,17 +10,31 @@ import (
 
 func (api *Api) routes() http.Handler {
 	router := gin.Default()
+	router.Use(Tracing())
+	router.Use(Metrics())
+	router.Use(Errors())
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"https:/*", "http:/*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "X-CSRF-Token"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	router.GET("/healthz", api.liveness)
+	router.GET("/readyz", api.readiness)
+	router.GET("/metrics", api.metrics)
+
+	publicRouterGroup := router.Group("/" + api.env + "/postservice")
+	for _, controller := range api.publicControllers {
+		controller.Routes(publicRouterGroup)
+	}
+
 	routerGroup := router.Group("/" + api.env + "/postservice")
+	routerGroup.Use(Authentication(api.authenticator))
+	routerGroup.Use(Idempotency(api.idempotencyStore, api.idempotencyKeyTtl))
 
 	for _, controller := range api.controllers {
 		controller.Routes(routerGroup)
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocalObjectStore is an object storage without a server of its own, the Api
+// serves the signed URLs it issues.
+type LocalObjectStore interface {
+	VerifySignedUrl(method, objectKey string, query url.Values) error
+	WriteObject(objectKey string, content io.Reader) (string, error)
+	WriteObjectPart(objectKey, uploadId string, partNumber int, content io.Reader) (string, error)
+	OpenObject(objectKey string) (*os.File, error)
+}
+
+// SignedObjectsController is registered outside the authenticated routes,
+// the signature of the URL is what grants access to the object.
+type SignedObjectsController struct {
+	store         LocalObjectStore
+	maxObjectSize int
+}
+
+func NewSignedObjectsController(store LocalObjectStore, maxObjectSize int) *SignedObjectsController {
+	return &SignedObjectsController{
+		store:         store,
+		maxObjectSize: maxObjectSize,
+	}
+}
+
+func (controller *SignedObjectsController) Routes(routerGroup *gin.RouterGroup) {
+	routerGroup.PUT("/objects/*objectKey", controller.PutObject)
+	routerGroup.GET("/objects/*objectKey", controller.GetObject)
+}
+
+func (controller *SignedObjectsController) PutObject(c *gin.Context) {
+	objectKey := strings.TrimPrefix(c.Param("objectKey"), "/")
+	query := c.Request.URL.Query()
+
+	err := controller.store.VerifySignedUrl(http.MethodPut, objectKey, query)
+	if err != nil {
+		SendError(c, NewForbiddenError("Invalid signed URL for object "+objectKey, err))
+		return
+	}
+
+	// A part is never bigger than the whole object.
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, int64(controller.maxObjectSize))
+	var eTag string
+	if uploadId := query.Get("uploadId"); uploadId != "" {
+		partNumber, convErr := strconv.Atoi(query.Get("partNumber"))
+		if convErr != nil {
+			SendError(c, NewValidationError("Invalid partNumber parameter"))
+			return
+		}
+		eTag, err = controller.store.WriteObjectPart(objectKey, uploadId, partNumber, body)
+	} else {
+		eTag, err = controller.store.WriteObject(objectKey, body)
+	}
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		SendError(c, NewValidationError(fmt.Sprintf("Object can have at most %d bytes", controller.maxObjectSize)))
+		return
+	}
+	if err != nil {
+		sendObjectError(c, objectKey, err)
+		return
+	}
+
+	c.Header("ETag", eTag)
+	c.Status(http.StatusOK)
+}
+
+func (controller *SignedObjectsController) GetObject(c *gin.Context) {
+	objectKey := strings.TrimPrefix(c.Param("objectKey"), "/")
+
+	err := controller.store.VerifySignedUrl(http.MethodGet, objectKey, c.Request.URL.Query())
+	if err != nil {
+		SendError(c, NewForbiddenError("Invalid signed URL for object "+objectKey, err))
+		return
+	}
+
+	file, err := controller.store.OpenObject(objectKey)
+	if err != nil {
+		sendObjectError(c, objectKey, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		sendObjectError(c, objectKey, err)
+		return
+	}
+
+	http.ServeContent(c.Writer, c.Request, path.Base(objectKey), info.ModTime(), file)
+}
+
+func sendObjectError(c *gin.Context, objectKey string, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		err = NewNotFoundError("Object "+objectKey+" not found", err)
+	}
+	SendError(c, err)
+}
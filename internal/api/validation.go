@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLength       = 100
+	maxDescriptionLength = 2000
+)
+
+// ValidatePostText returns an error for the title and the description of a
+// post that can't be stored. A nil field is not validated, it is not changing.
+func ValidatePostText(title, description *string) []FieldError {
+	fieldErrors := []FieldError{}
+	addError := func(field, message string, args ...any) {
+		fieldErrors = append(fieldErrors, FieldError{Field: field, Message: fmt.Sprintf(message, args...)})
+	}
+
+	if title != nil {
+		if strings.TrimSpace(*title) == "" {
+			addError("title", "is required")
+		} else if utf8.RuneCountInString(*title) > maxTitleLength {
+			addError("title", "can have at most %d characters", maxTitleLength)
+		} else if strings.ContainsFunc(*title, isForbiddenInTitle) {
+			addError("title", "can't contain '/', '\\' or control characters")
+		}
+	}
+
+	if description != nil && utf8.RuneCountInString(*description) > maxDescriptionLength {
+		addError("description", "can have at most %d characters", maxDescriptionLength)
+	}
+
+	return fieldErrors
+}
+
+func isForbiddenInTitle(r rune) bool {
+	return r == '/' || r == '\\' || unicode.IsControl(r) || r == utf8.RuneError
+}
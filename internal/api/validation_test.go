@@ -0,0 +1,23 @@
+package api_test
+
+import (
+	"postservice/internal/api"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePostText(t *testing.T) {
+	title := "Meu Post"
+	longTitle := strings.Repeat("a", 101)
+	description := strings.Repeat("a", 2000)
+	longDescription := strings.Repeat("a", 2001)
+
+	assert.Empty(t, api.ValidatePostText(&title, &description))
+	assert.Empty(t, api.ValidatePostText(nil, nil))
+	assert.Equal(t, []api.FieldError{
+		{Field: "title", Message: "can have at most 100 characters"},
+		{Field: "description", Message: "can have at most 2000 characters"},
+	}, api.ValidatePostText(&longTitle, &longDescription))
+}
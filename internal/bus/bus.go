@@ -3,6 +3,8 @@ package bus
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sort"
 )
 
 //go:generate mockgen -source=bus.go -destination=mock/bus.go
@@ -12,9 +14,10 @@ type Event struct {
 	Data []byte
 }
 
+// EventBus dispatches the events consumed from the ExternalBus to the
+// subscribers of this process. Events are published through the outbox.
 type EventBus struct {
-	subscribers map[string][]chan<- Event
-	externalBus ExternalBus
+	subscribers map[string][]chan<- localEvent
 }
 
 type EventSubscription struct {
@@ -23,55 +26,82 @@ type EventSubscription struct {
 }
 
 type ExternalBus interface {
-	Publish(event *Event) error
+	Publish(event *Event, ctx context.Context) error
 }
 
 type EventHandler interface {
-	Handle(event []byte)
+	Handle(event []byte) error
 }
 
-func NewEventBus(externalBus ExternalBus) *EventBus {
+type localEvent struct {
+	event  Event
+	result chan<- error
+}
+
+func NewEventBus() *EventBus {
 	return &EventBus{
-		subscribers: make(map[string][]chan<- Event),
-		externalBus: externalBus,
+		subscribers: make(map[string][]chan<- localEvent),
 	}
 }
 
-func (eb *EventBus) PublishLocal(event Event) {
+// PublishLocal dispatches the event to every subscriber of its type and waits
+// until all of them have handled it, so callers know when it is safe to
+// acknowledge the event upstream.
+func (eb *EventBus) PublishLocal(event Event, ctx context.Context) error {
 	subscriberChannels := eb.subscribers[event.Type]
+	results := make(chan error, len(subscriberChannels))
 
 	for _, subscriberChannel := range subscriberChannels {
-		subscriberChannel <- event
+		select {
+		case subscriberChannel <- localEvent{event: event, result: results}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-}
 
-func (eb *EventBus) Subscribe(subscription *EventSubscription, ctx context.Context) {
-	subscriptionChan := make(chan Event)
-	eb.subscribers[subscription.EventType] = append(eb.subscribers[subscription.EventType], subscriptionChan)
-	go subscription.handle(subscriptionChan, ctx)
+	var errs []error
+	for range subscriberChannels {
+		select {
+		case err := <-results:
+			errs = append(errs, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-func (eb *EventBus) Publish(eventName string, eventData any) error {
-	event, err := createEvent(eventName, eventData)
-	if err != nil {
-		return err
+func (eb *EventBus) SubscribedEventTypes() []string {
+	eventTypes := make([]string, 0, len(eb.subscribers))
+	for eventType := range eb.subscribers {
+		eventTypes = append(eventTypes, eventType)
 	}
+	sort.Strings(eventTypes)
 
-	return eb.externalBus.Publish(event)
+	return eventTypes
+}
+
+func (eb *EventBus) Subscribe(subscription *EventSubscription, ctx context.Context) {
+	subscriptionChan := make(chan localEvent)
+	eb.subscribers[subscription.EventType] = append(eb.subscribers[subscription.EventType], subscriptionChan)
+	go subscription.handle(subscriptionChan, ctx)
 }
 
-func (es EventSubscription) handle(busChannel <-chan Event, ctx context.Context) {
+func (es EventSubscription) handle(busChannel <-chan localEvent, ctx context.Context) {
 	for {
 		select {
-		case event := <-busChannel:
-			go es.Handler.Handle(event.Data)
+		case localEvent := <-busChannel:
+			go func() {
+				localEvent.result <- es.Handler.Handle(localEvent.event.Data)
+			}()
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func createEvent(eventName string, eventData any) (*Event, error) {
+func NewEvent(eventName string, eventData any) (*Event, error) {
 	dataEvent, err := serialize(eventData)
 	if err != nil {
 		return nil, err
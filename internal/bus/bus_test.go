@@ -0,0 +1,52 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"postservice/internal/bus"
+	mock_bus "postservice/internal/bus/mock"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishLocalWaitsForHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler1 := mock_bus.NewMockEventHandler(ctrl)
+	handler2 := mock_bus.NewMockEventHandler(ctrl)
+	eventBus := bus.NewEventBus()
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "UserWasDeletedEvent", Handler: handler1}, ctx)
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "UserWasDeletedEvent", Handler: handler2}, ctx)
+	handler1.EXPECT().Handle([]byte("data")).Return(nil)
+	handler2.EXPECT().Handle([]byte("data")).Return(nil)
+
+	err := eventBus.PublishLocal(bus.Event{Type: "UserWasDeletedEvent", Data: []byte("data")}, ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UserWasDeletedEvent"}, eventBus.SubscribedEventTypes())
+}
+
+func TestErrorOnPublishLocalWhenHandlerFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := mock_bus.NewMockEventHandler(ctrl)
+	eventBus := bus.NewEventBus()
+	eventBus.Subscribe(&bus.EventSubscription{EventType: "UserWasDeletedEvent", Handler: handler}, ctx)
+	handler.EXPECT().Handle([]byte("data")).Return(errors.New("some error"))
+
+	err := eventBus.PublishLocal(bus.Event{Type: "UserWasDeletedEvent", Data: []byte("data")}, ctx)
+
+	assert.EqualError(t, err, "some error")
+}
+
+func TestPublishLocalWithoutSubscribers(t *testing.T) {
+	eventBus := bus.NewEventBus()
+
+	err := eventBus.PublishLocal(bus.Event{Type: "UserWasDeletedEvent", Data: []byte("data")}, context.Background())
+
+	assert.Nil(t, err)
+}
@@ -5,6 +5,7 @@
 package mock_bus
 
 import (
+	context "context"
 	bus "postservice/internal/bus"
 	reflect "reflect"
 
@@ -35,17 +36,17 @@ func (m *MockExternalBus) EXPECT() *MockExternalBusMockRecorder {
 }
 
 // Publish mocks base method.
-func (m *MockExternalBus) Publish(event *bus.Event) error {
+func (m *MockExternalBus) Publish(event *bus.Event, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Publish", event)
+	ret := m.ctrl.Call(m, "Publish", event, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Publish indicates an expected call of Publish.
-func (mr *MockExternalBusMockRecorder) Publish(event interface{}) *gomock.Call {
+func (mr *MockExternalBusMockRecorder) Publish(event, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockExternalBus)(nil).Publish), event)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockExternalBus)(nil).Publish), event, ctx)
 }
 
 // MockEventHandler is a mock of EventHandler interface.
@@ -72,9 +73,11 @@ func (m *MockEventHandler) EXPECT() *MockEventHandlerMockRecorder {
 }
 
 // Handle mocks base method.
-func (m *MockEventHandler) Handle(event []byte) {
+func (m *MockEventHandler) Handle(event []byte) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Handle", event)
+	ret := m.ctrl.Call(m, "Handle", event)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Handle indicates an expected call of Handle.
@@ -0,0 +1,435 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Environment   string              `yaml:"environment" env:"ENVIRONMENT"`
+	Api           ApiConfig           `yaml:"api"`
+	Aws           AwsConfig           `yaml:"aws"`
+	Database      DatabaseConfig      `yaml:"database"`
+	DynamoDB      DynamoDBConfig      `yaml:"dynamodb"`
+	ObjectStorage ObjectStorageConfig `yaml:"objectStorage"`
+	S3            S3Config            `yaml:"s3"`
+	Filesystem    FilesystemConfig    `yaml:"filesystem"`
+	Bus           BusConfig           `yaml:"bus"`
+	Kafka         KafkaConfig         `yaml:"kafka"`
+	EventLog      EventLogConfig      `yaml:"eventLog"`
+	Jwt           JwtConfig           `yaml:"jwt"`
+	Reaper        ReaperConfig        `yaml:"reaper"`
+	Outbox        OutboxConfig        `yaml:"outbox"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+}
+
+type ApiConfig struct {
+	Port int `yaml:"port" env:"API_PORT"`
+	// ShutdownDelay keeps serving while readiness reports the shutdown, so the
+	// orchestrator stops routing traffic before the server closes.
+	ShutdownDelay time.Duration `yaml:"shutdownDelay" env:"API_SHUTDOWN_DELAY"`
+	// IdempotencyKeyTtl is how long the response of a request with an
+	// Idempotency-Key is replayed to its retries.
+	IdempotencyKeyTtl time.Duration `yaml:"idempotencyKeyTtl" env:"API_IDEMPOTENCY_KEY_TTL"`
+}
+
+type AwsConfig struct {
+	Region string `yaml:"region" env:"AWS_REGION"`
+}
+
+const (
+	DatabaseDriverDynamoDB = "dynamodb"
+	DatabaseDriverMemory   = "memory"
+)
+
+type DatabaseConfig struct {
+	// Driver selects the DatabaseClient, the memory one loses its data on restart.
+	Driver string `yaml:"driver" env:"DATABASE_DRIVER"`
+	// OperationTimeout bounds every call to the database, on top of the
+	// deadline of the request that makes it.
+	OperationTimeout time.Duration `yaml:"operationTimeout" env:"DATABASE_OPERATION_TIMEOUT"`
+}
+
+type DynamoDBConfig struct {
+	// Endpoint points to a local DynamoDB, leave it empty to use AWS.
+	Endpoint string `yaml:"endpoint" env:"DYNAMODB_ENDPOINT"`
+}
+
+const (
+	ObjectStorageDriverS3         = "s3"
+	ObjectStorageDriverFilesystem = "filesystem"
+)
+
+// The limits of S3 on the size of the objects and of the parts that upload them.
+const (
+	s3MaxPutSize    = 5 << 30
+	s3MinPartSize   = 5 << 20
+	s3MaxObjectSize = 5 << 40
+)
+
+type ObjectStorageConfig struct {
+	Driver                string        `yaml:"driver" env:"OBJECT_STORAGE_DRIVER"`
+	GetUrlLifetime        time.Duration `yaml:"getUrlLifetime" env:"OBJECT_STORAGE_GET_URL_LIFETIME"`
+	PutUrlLifetime        time.Duration `yaml:"putUrlLifetime" env:"OBJECT_STORAGE_PUT_URL_LIFETIME"`
+	UploadPartUrlLifetime time.Duration `yaml:"uploadPartUrlLifetime" env:"OBJECT_STORAGE_UPLOAD_PART_URL_LIFETIME"`
+	// MultipartThreshold is the size in bytes above which an object is
+	// uploaded in parts of about MultipartPartSize bytes.
+	MultipartThreshold int `yaml:"multipartThreshold" env:"OBJECT_STORAGE_MULTIPART_THRESHOLD"`
+	MultipartPartSize  int `yaml:"multipartPartSize" env:"OBJECT_STORAGE_MULTIPART_PART_SIZE"`
+	// MaxObjectSize is the size in bytes of the biggest object that can be uploaded.
+	MaxObjectSize int `yaml:"maxObjectSize" env:"OBJECT_STORAGE_MAX_OBJECT_SIZE"`
+	// OperationTimeout bounds every call to the object storage, on top of the
+	// deadline of the request that makes it.
+	OperationTimeout time.Duration `yaml:"operationTimeout" env:"OBJECT_STORAGE_OPERATION_TIMEOUT"`
+}
+
+type S3Config struct {
+	// Endpoint points to a local S3 such as LocalStack, leave it empty to use AWS.
+	Endpoint string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Bucket   string `yaml:"bucket" env:"S3_BUCKET"`
+}
+
+type FilesystemConfig struct {
+	Directory string `yaml:"directory" env:"FILESYSTEM_STORAGE_DIRECTORY"`
+	// BaseUrl is the public address of the API, the signed URLs point to it.
+	BaseUrl       string `yaml:"baseUrl" env:"FILESYSTEM_STORAGE_BASE_URL"`
+	SigningSecret string `yaml:"signingSecret" env:"FILESYSTEM_STORAGE_SIGNING_SECRET"`
+}
+
+const (
+	BusDriverKafka  = "kafka"
+	BusDriverMemory = "memory"
+	BusDriverFile   = "file"
+)
+
+type BusConfig struct {
+	// Driver selects the ExternalBus, the memory one only reaches the
+	// subscribers of this process and the file one none.
+	Driver string `yaml:"driver" env:"BUS_DRIVER"`
+}
+
+type KafkaConfig struct {
+	Brokers       []string `yaml:"brokers" env:"KAFKA_BROKERS"`
+	ConsumerGroup string   `yaml:"consumerGroup" env:"KAFKA_CONSUMER_GROUP"`
+	// MaxMessageAttempts is how many times a message is handled before it is
+	// skipped, so a message that always fails does not block its partition.
+	MaxMessageAttempts int `yaml:"maxMessageAttempts" env:"KAFKA_MAX_MESSAGE_ATTEMPTS"`
+}
+
+type EventLogConfig struct {
+	Path string `yaml:"path" env:"EVENT_LOG_PATH"`
+}
+
+type JwtConfig struct {
+	HmacSecret       string `yaml:"hs256Secret" env:"JWT_HS256_SECRET"`
+	RsaPublicKeyFile string `yaml:"rs256PublicKeyFile" env:"JWT_RS256_PUBLIC_KEY_FILE"`
+	JwksFile         string `yaml:"jwksFile" env:"JWT_JWKS_FILE"`
+	Issuer           string `yaml:"issuer" env:"JWT_ISSUER"`
+	Audience         string `yaml:"audience" env:"JWT_AUDIENCE"`
+	UsernameClaim    string `yaml:"usernameClaim" env:"JWT_USERNAME_CLAIM"`
+}
+
+type ReaperConfig struct {
+	AbandonedPostsTtl time.Duration `yaml:"abandonedPostsTtl" env:"ABANDONED_POSTS_TTL"`
+	Interval          time.Duration `yaml:"interval" env:"ABANDONED_POSTS_REAPER_INTERVAL"`
+}
+
+type OutboxConfig struct {
+	RelayInterval   time.Duration `yaml:"relayInterval" env:"OUTBOX_RELAY_INTERVAL"`
+	RelayMaxBackoff time.Duration `yaml:"relayMaxBackoff" env:"OUTBOX_RELAY_MAX_BACKOFF"`
+	MaxAttempts     int           `yaml:"maxAttempts" env:"OUTBOX_MAX_ATTEMPTS"`
+}
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOtlp   = "otlp"
+)
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+	// OtlpEndpoint is the URL of the OTLP/HTTP collector, leave it empty to
+	// use the OTEL_EXPORTER_OTLP_* variables.
+	OtlpEndpoint string  `yaml:"otlpEndpoint" env:"TRACING_OTLP_ENDPOINT"`
+	SampleRatio  float64 `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Load builds the configuration from the defaults of the environment, the
+// YAML file at path (if any) and the environment variables, in that order of
+// precedence, and validates the result.
+func Load(env, path string) (*Config, error) {
+	config := Default(env)
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading configuration file %s: %w", path, err)
+		}
+		err = yaml.Unmarshal(content, config)
+		if err != nil {
+			return nil, fmt.Errorf("parsing configuration file %s: %w", path, err)
+		}
+	}
+
+	err := applyEnvOverrides(reflect.ValueOf(config).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// developmentSecret is public, it is only accepted while the data stays on
+// this machine.
+const developmentSecret = "development-secret"
+
+func Default(env string) *Config {
+	config := &Config{
+		Environment: env,
+		Api: ApiConfig{
+			Port:              6666,
+			ShutdownDelay:     5 * time.Second,
+			IdempotencyKeyTtl: 24 * time.Hour,
+		},
+		Aws: AwsConfig{
+			Region: "eu-west-3",
+		},
+		Database: DatabaseConfig{
+			Driver:           DatabaseDriverDynamoDB,
+			OperationTimeout: 5 * time.Second,
+		},
+		ObjectStorage: ObjectStorageConfig{
+			Driver:                ObjectStorageDriverS3,
+			GetUrlLifetime:        time.Minute,
+			PutUrlLifetime:        10 * time.Hour,
+			UploadPartUrlLifetime: 10 * time.Minute,
+			MultipartThreshold:    64 << 20,
+			MultipartPartSize:     16 << 20,
+			MaxObjectSize:         5 << 30,
+			OperationTimeout:      30 * time.Second,
+		},
+		S3: S3Config{
+			Bucket: "artis-bucket-2",
+		},
+		Filesystem: FilesystemConfig{
+			Directory: "objects",
+			BaseUrl:   "http://localhost:6666",
+		},
+		Bus: BusConfig{
+			Driver: BusDriverKafka,
+		},
+		Kafka: KafkaConfig{
+			Brokers: []string{
+				"172.31.0.242:9092",
+				"172.31.7.110:9092",
+			},
+			ConsumerGroup:      "postservice",
+			MaxMessageAttempts: 5,
+		},
+		EventLog: EventLogConfig{
+			Path: "events.jsonl",
+		},
+		Reaper: ReaperConfig{
+			AbandonedPostsTtl: 24 * time.Hour,
+			Interval:          time.Hour,
+		},
+		Outbox: OutboxConfig{
+			RelayInterval:   time.Second,
+			RelayMaxBackoff: time.Minute,
+			MaxAttempts:     10,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			SampleRatio: 1,
+		},
+	}
+
+	if env == "development" {
+		config.Api.ShutdownDelay = 0
+		config.Aws.Region = "localhost"
+		config.DynamoDB.Endpoint = "http://localhost:8000"
+		config.S3.Endpoint = "http://localhost:4566"
+		config.Kafka.Brokers = []string{"localhost:9093"}
+		config.Jwt.HmacSecret = developmentSecret
+		config.Filesystem.SigningSecret = developmentSecret
+	}
+
+	return config
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(valid bool, problem string) {
+		if !valid {
+			problems = append(problems, problem)
+		}
+	}
+
+	check(c.Api.Port > 0 && c.Api.Port <= 65535, fmt.Sprintf("api.port has to be between 1 and 65535, got %d", c.Api.Port))
+	check(c.Api.ShutdownDelay >= 0, "api.shutdownDelay can not be negative")
+	check(c.Api.IdempotencyKeyTtl > 0, "api.idempotencyKeyTtl has to be positive")
+	check(c.Aws.Region != "", "aws.region is required")
+	check(c.Database.Driver == DatabaseDriverDynamoDB || c.Database.Driver == DatabaseDriverMemory, fmt.Sprintf("database.driver has to be %q or %q, got %q", DatabaseDriverDynamoDB, DatabaseDriverMemory, c.Database.Driver))
+	check(c.Database.OperationTimeout > 0, "database.operationTimeout has to be positive")
+	check(c.ObjectStorage.OperationTimeout > 0, "objectStorage.operationTimeout has to be positive")
+	check(c.ObjectStorage.GetUrlLifetime > 0, "objectStorage.getUrlLifetime has to be positive")
+	check(c.ObjectStorage.PutUrlLifetime > 0, "objectStorage.putUrlLifetime has to be positive")
+	check(c.ObjectStorage.UploadPartUrlLifetime > 0, "objectStorage.uploadPartUrlLifetime has to be positive")
+	check(c.ObjectStorage.MultipartThreshold > 0, "objectStorage.multipartThreshold has to be positive")
+	check(c.ObjectStorage.MultipartPartSize > 0, "objectStorage.multipartPartSize has to be positive")
+	check(c.ObjectStorage.MaxObjectSize > 0, "objectStorage.maxObjectSize has to be positive")
+	switch c.ObjectStorage.Driver {
+	case ObjectStorageDriverS3:
+		check(c.S3.Bucket != "", "s3.bucket is required")
+		check(c.ObjectStorage.MultipartThreshold <= s3MaxPutSize, fmt.Sprintf("objectStorage.multipartThreshold can be at most %d bytes with S3", s3MaxPutSize))
+		check(c.ObjectStorage.MultipartPartSize >= s3MinPartSize && c.ObjectStorage.MultipartPartSize <= s3MaxPutSize, fmt.Sprintf("objectStorage.multipartPartSize has to be between %d and %d bytes with S3", s3MinPartSize, s3MaxPutSize))
+		check(c.ObjectStorage.MaxObjectSize <= s3MaxObjectSize, fmt.Sprintf("objectStorage.maxObjectSize can be at most %d bytes with S3", s3MaxObjectSize))
+	case ObjectStorageDriverFilesystem:
+		check(c.Filesystem.Directory != "", "filesystem.directory is required")
+		check(strings.HasPrefix(c.Filesystem.BaseUrl, "http://") || strings.HasPrefix(c.Filesystem.BaseUrl, "https://"), fmt.Sprintf("filesystem.baseUrl has to be an http(s) URL, got %q", c.Filesystem.BaseUrl))
+		check(len(c.Filesystem.SigningSecret) >= 16, "filesystem.signingSecret needs at least 16 characters")
+	default:
+		check(false, fmt.Sprintf("objectStorage.driver has to be %q or %q, got %q", ObjectStorageDriverS3, ObjectStorageDriverFilesystem, c.ObjectStorage.Driver))
+	}
+	switch c.Bus.Driver {
+	case BusDriverKafka:
+		check(len(c.Kafka.Brokers) > 0, "kafka.brokers needs at least one broker")
+		for _, broker := range c.Kafka.Brokers {
+			check(strings.Contains(broker, ":"), fmt.Sprintf("kafka.brokers entry %q has to be host:port", broker))
+		}
+		check(c.Kafka.ConsumerGroup != "", "kafka.consumerGroup is required")
+		check(c.Kafka.MaxMessageAttempts > 0, "kafka.maxMessageAttempts has to be positive")
+	case BusDriverMemory:
+	case BusDriverFile:
+		check(c.EventLog.Path != "", "eventLog.path is required")
+	default:
+		check(false, fmt.Sprintf("bus.driver has to be %q, %q or %q, got %q", BusDriverKafka, BusDriverMemory, BusDriverFile, c.Bus.Driver))
+	}
+	check(c.Jwt.HmacSecret != "" || c.Jwt.RsaPublicKeyFile != "" || c.Jwt.JwksFile != "", "jwt needs one of hs256Secret, rs256PublicKeyFile or jwksFile")
+	if !c.isLocal() {
+		check(c.Jwt.HmacSecret != developmentSecret, "jwt.hs256Secret can only be the development secret with a local database and object storage")
+		check(c.ObjectStorage.Driver != ObjectStorageDriverFilesystem || c.Filesystem.SigningSecret != developmentSecret, "filesystem.signingSecret can only be the development secret with a local database and object storage")
+	}
+	check(c.Reaper.AbandonedPostsTtl > 0, "reaper.abandonedPostsTtl has to be positive")
+	// A post can still be uploading while any of the urls it got is valid.
+	uploadUrlLifetime := max(c.ObjectStorage.PutUrlLifetime, c.ObjectStorage.UploadPartUrlLifetime)
+	check(c.Reaper.AbandonedPostsTtl > uploadUrlLifetime, fmt.Sprintf("reaper.abandonedPostsTtl has to be longer than the upload urls lifetime of %s", uploadUrlLifetime))
+	check(c.Reaper.Interval > 0, "reaper.interval has to be positive")
+	check(c.Outbox.RelayInterval > 0, "outbox.relayInterval has to be positive")
+	check(c.Outbox.RelayMaxBackoff >= c.Outbox.RelayInterval, "outbox.relayMaxBackoff can not be shorter than outbox.relayInterval")
+	check(c.Outbox.MaxAttempts > 0, "outbox.maxAttempts has to be positive")
+	check(c.Tracing.Exporter == TracingExporterNone || c.Tracing.Exporter == TracingExporterStdout || c.Tracing.Exporter == TracingExporterOtlp, fmt.Sprintf("tracing.exporter has to be %q, %q or %q, got %q", TracingExporterNone, TracingExporterStdout, TracingExporterOtlp, c.Tracing.Exporter))
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, fmt.Sprintf("tracing.sampleRatio has to be between 0 and 1, got %g", c.Tracing.SampleRatio))
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// isLocal tells whether the database and the object storage run on this
+// machine, either in the process or behind a localhost endpoint.
+func (c *Config) isLocal() bool {
+	localDatabase := c.Database.Driver == DatabaseDriverMemory || isLocalEndpoint(c.DynamoDB.Endpoint)
+	localObjectStorage := c.ObjectStorage.Driver == ObjectStorageDriverFilesystem || isLocalEndpoint(c.S3.Endpoint)
+
+	return localDatabase && localObjectStorage
+}
+
+func isLocalEndpoint(endpoint string) bool {
+	endpointUrl, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	host := endpointUrl.Hostname()
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func applyEnvOverrides(value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			err := applyEnvOverrides(field)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := structField.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		rawValue, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(rawValue) == "" {
+			continue
+		}
+
+		err := setField(field, strings.TrimSpace(rawValue))
+		if err != nil {
+			return fmt.Errorf("invalid configuration: %s=%q: %w", name, rawValue, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, rawValue string) error {
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(rawValue)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(rawValue)
+	case reflect.Int:
+		number, err := strconv.Atoi(rawValue)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(number))
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(number)
+	case reflect.Slice:
+		var values []string
+		for _, item := range strings.Split(rawValue, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
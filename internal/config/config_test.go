@@ -0,0 +1,188 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"postservice/internal/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDevelopmentDefaults(t *testing.T) {
+	cfg, err := config.Load("development", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 6666, cfg.Api.Port)
+	assert.Equal(t, "http://localhost:8000", cfg.DynamoDB.Endpoint)
+	assert.Equal(t, []string{"localhost:9093"}, cfg.Kafka.Brokers)
+	assert.Equal(t, "artis-bucket-2", cfg.S3.Bucket)
+}
+
+func TestLoadConfigFileWithEnvironmentOverrides(t *testing.T) {
+	path := writeConfigFile(t, `
+api:
+  port: 8080
+objectStorage:
+  getUrlLifetime: 5m
+s3:
+  bucket: posts-bucket
+kafka:
+  brokers:
+    - kafka1:9092
+jwt:
+  hs256Secret: secret
+`)
+	t.Setenv("S3_BUCKET", "overridden-bucket")
+	t.Setenv("KAFKA_BROKERS", "kafka2:9092, kafka3:9092")
+	t.Setenv("OUTBOX_RELAY_INTERVAL", "2s")
+
+	cfg, err := config.Load("production", path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 8080, cfg.Api.Port)
+	assert.Equal(t, "overridden-bucket", cfg.S3.Bucket)
+	assert.Equal(t, 5*time.Minute, cfg.ObjectStorage.GetUrlLifetime)
+	assert.Equal(t, 10*time.Hour, cfg.ObjectStorage.PutUrlLifetime)
+	assert.Equal(t, []string{"kafka2:9092", "kafka3:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, 2*time.Second, cfg.Outbox.RelayInterval)
+	assert.Equal(t, "eu-west-3", cfg.Aws.Region)
+}
+
+func TestErrorOnLoadInvalidConfig(t *testing.T) {
+	path := writeConfigFile(t, `
+api:
+  port: 70000
+kafka:
+  brokers: []
+`)
+
+	_, err := config.Load("production", path)
+
+	assert.EqualError(t, err, "invalid configuration: api.port has to be between 1 and 65535, got 70000; kafka.brokers needs at least one broker; jwt needs one of hs256Secret, rs256PublicKeyFile or jwksFile")
+}
+
+func TestErrorOnLoadInvalidFilesystemObjectStorage(t *testing.T) {
+	path := writeConfigFile(t, `
+objectStorage:
+  driver: filesystem
+filesystem:
+  baseUrl: localhost:6666
+  signingSecret: short
+`)
+
+	_, err := config.Load("development", path)
+
+	assert.EqualError(t, err, `invalid configuration: filesystem.baseUrl has to be an http(s) URL, got "localhost:6666"; filesystem.signingSecret needs at least 16 characters`)
+}
+
+func TestErrorOnLoadDevelopmentSecretWithRemoteDatabase(t *testing.T) {
+	t.Setenv("DYNAMODB_ENDPOINT", "https://dynamodb.eu-west-3.amazonaws.com")
+
+	_, err := config.Load("development", "")
+
+	assert.EqualError(t, err, "invalid configuration: jwt.hs256Secret can only be the development secret with a local database and object storage")
+}
+
+func TestLoadDevelopmentSecretWithInMemoryDrivers(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "memory")
+	t.Setenv("OBJECT_STORAGE_DRIVER", "filesystem")
+
+	_, err := config.Load("development", "")
+
+	assert.Nil(t, err)
+}
+
+func TestLoadWithoutKafka(t *testing.T) {
+	t.Setenv("BUS_DRIVER", "file")
+	t.Setenv("KAFKA_BROKERS", ",")
+
+	cfg, err := config.Load("development", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, config.BusDriverFile, cfg.Bus.Driver)
+	assert.Equal(t, "events.jsonl", cfg.EventLog.Path)
+}
+
+func TestLoadTracing(t *testing.T) {
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("TRACING_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg, err := config.Load("development", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, config.TracingConfig{Exporter: config.TracingExporterOtlp, OtlpEndpoint: "http://localhost:4318", SampleRatio: 0.25}, cfg.Tracing)
+}
+
+func TestErrorOnLoadInvalidTracing(t *testing.T) {
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+
+	_, err := config.Load("development", "")
+
+	assert.ErrorContains(t, err, `tracing.exporter has to be "none", "stdout" or "otlp", got "jaeger"`)
+	assert.ErrorContains(t, err, "tracing.sampleRatio has to be between 0 and 1, got 2")
+}
+
+func TestLoadOperationTimeouts(t *testing.T) {
+	t.Setenv("DATABASE_OPERATION_TIMEOUT", "2s")
+
+	cfg, err := config.Load("development", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2*time.Second, cfg.Database.OperationTimeout)
+	assert.Equal(t, 30*time.Second, cfg.ObjectStorage.OperationTimeout)
+}
+
+func TestErrorOnLoadNonPositiveOperationTimeouts(t *testing.T) {
+	t.Setenv("DATABASE_OPERATION_TIMEOUT", "0s")
+	t.Setenv("OBJECT_STORAGE_OPERATION_TIMEOUT", "-1s")
+
+	_, err := config.Load("development", "")
+
+	assert.ErrorContains(t, err, "database.operationTimeout has to be positive")
+	assert.ErrorContains(t, err, "objectStorage.operationTimeout has to be positive")
+}
+
+func TestErrorOnLoadMultipartSizesOutOfS3Limits(t *testing.T) {
+	t.Setenv("OBJECT_STORAGE_MULTIPART_THRESHOLD", "6442450944")
+	t.Setenv("OBJECT_STORAGE_MULTIPART_PART_SIZE", "1048576")
+
+	_, err := config.Load("development", "")
+
+	assert.ErrorContains(t, err, "objectStorage.multipartThreshold can be at most 5368709120 bytes with S3")
+	assert.ErrorContains(t, err, "objectStorage.multipartPartSize has to be between 5242880 and 5368709120 bytes with S3")
+}
+
+func TestErrorOnLoadAbandonedPostsTtlShorterThanUploadUrls(t *testing.T) {
+	t.Setenv("ABANDONED_POSTS_TTL", "1h")
+	t.Setenv("OBJECT_STORAGE_UPLOAD_PART_URL_LIFETIME", "2h")
+
+	_, err := config.Load("development", "")
+
+	assert.ErrorContains(t, err, "reaper.abandonedPostsTtl has to be longer than the upload urls lifetime of 10h0m0s")
+}
+
+func TestErrorOnLoadInvalidEnvironmentOverride(t *testing.T) {
+	t.Setenv("ABANDONED_POSTS_TTL", "one day")
+
+	_, err := config.Load("development", "")
+
+	assert.ErrorContains(t, err, `invalid configuration: ABANDONED_POSTS_TTL="one day"`)
+}
+
+func TestErrorOnLoadMissingConfigFile(t *testing.T) {
+	_, err := config.Load("development", filepath.Join(t.TempDir(), "missing.yaml"))
+
+	assert.ErrorContains(t, err, "reading configuration file")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
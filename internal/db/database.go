@@ -11,21 +11,78 @@ type TableAttributes struct {
 	AttributeType string
 }
 
+// TransactionOperation is one write of a transaction run by ExecuteTransaction:
+// an InsertOperation, an UpdateOperation or a RemoveOperation.
+type TransactionOperation interface {
+	transactionTable() string
+}
+
+// VersionAttribute holds the version of an item written by the versioned
+// operations, which increment it on every write. Items written before have no
+// version, which they match as version 0.
+const VersionAttribute = "Version"
+
+type InsertOperation struct {
+	TableName string
+	Item      any
+}
+
+type UpdateOperation struct {
+	TableName  string
+	Key        any
+	Attributes map[string]any
+	// ExpectedVersion makes the update fail with a ConflictError unless the
+	// stored version matches, and increments the version when it does.
+	ExpectedVersion *int
+}
+
+type RemoveOperation struct {
+	TableName string
+	Key       any
+	// ExpectedVersion makes the removal fail with a ConflictError unless the
+	// stored version matches.
+	ExpectedVersion *int
+}
+
+func (o *InsertOperation) transactionTable() string { return o.TableName }
+func (o *UpdateOperation) transactionTable() string { return o.TableName }
+func (o *RemoveOperation) transactionTable() string { return o.TableName }
+
 type Database struct {
 	Client DatabaseClient
 }
 
 type DatabaseClient interface {
-	TableExists(tableName string) bool
-	IndexExists(tableName, indexName string) bool
+	TableExists(tableName string, ctx context.Context) bool
+	IndexExists(tableName, indexName string, ctx context.Context) bool
 	CreateTable(tableName string, keys *[]TableAttributes, ctx context.Context) error
 	CreateIndexesOnTable(tableName, indexName string, inndexes *[]TableAttributes, ctx context.Context) error
-	InsertData(tableName string, attributes any) error
-	GetData(tableName string, key any, result any) error
-	RemoveData(tableName string, key any) error
-	RemoveMultipleData(tableName string, keys []any) error
-	GetPostsByIds(postIds []string) ([]*Post, error)
-	GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int) ([]*Post, string, string, error)
+	// EnableTimeToLive lets the database delete the items of tableName once
+	// the epoch seconds in their attributeName are past.
+	EnableTimeToLive(tableName, attributeName string, ctx context.Context) error
+	InsertData(tableName string, attributes any, ctx context.Context) error
+	// InsertDataIfNotExists fails with a ConflictError when an item with the
+	// same keyAttribute is already stored, instead of replacing it.
+	InsertDataIfNotExists(tableName, keyAttribute string, attributes any, ctx context.Context) error
+	// InsertDataIfMatches replaces the stored item with the key of attributes
+	// only when its attributes have the values of conditions, and fails with a
+	// ConflictError otherwise, also when there is no stored item.
+	InsertDataIfMatches(tableName string, attributes any, conditions map[string]any, ctx context.Context) error
+	GetData(tableName string, key any, result any, ctx context.Context) error
+	UpdateData(tableName string, key any, attributes map[string]any, ctx context.Context) error
+	UpdateDataIfVersion(tableName string, key any, attributes map[string]any, expectedVersion int, ctx context.Context) error
+	RemoveData(tableName string, key any, ctx context.Context) error
+	RemoveDataIfVersion(tableName string, key any, expectedVersion int, ctx context.Context) error
+	// RemoveDataIfMatches removes the stored item with key under the
+	// conditions of InsertDataIfMatches.
+	RemoveDataIfMatches(tableName string, key any, conditions map[string]any, ctx context.Context) error
+	RemoveMultipleData(tableName string, keys []any, ctx context.Context) error
+	ExecuteTransaction(operations []TransactionOperation, ctx context.Context) error
+	GetPostsByIds(postIds []string, ctx context.Context) ([]*Post, error)
+	GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*Post, string, string, error)
+	GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*Post, string, string, error)
+	GetPostsByStatusCreatedBefore(status, createdBefore string, ctx context.Context) ([]*Post, error)
+	GetPendingOutboxEvents(limit int, ctx context.Context) ([]*OutboxEvent, error)
 }
 
 func NewDatabase(client DatabaseClient) *Database {
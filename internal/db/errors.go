@@ -18,3 +18,61 @@ func NewNotFoundError(table string, key any) *NotFoundError {
 		key:   key,
 	}
 }
+
+type ForbiddenError struct {
+	table    string
+	key      any
+	username string
+}
+
+func (e *ForbiddenError) Error() string {
+	errorMessage := fmt.Sprintf("User %s is not allowed to modify data in table %s for key %v", e.username, e.table, e.key)
+	return errorMessage
+}
+
+func NewForbiddenError(table string, key any, username string) *ForbiddenError {
+	return &ForbiddenError{
+		table:    table,
+		key:      key,
+		username: username,
+	}
+}
+
+type ConflictError struct {
+	table  string
+	key    any
+	reason string
+}
+
+func (e *ConflictError) Error() string {
+	errorMessage := fmt.Sprintf("Data in table %s for key %v conflicts with the current state: %s", e.table, e.key, e.reason)
+	return errorMessage
+}
+
+func NewConflictError(table string, key any, reason string) *ConflictError {
+	return &ConflictError{
+		table:  table,
+		key:    key,
+		reason: reason,
+	}
+}
+
+// UnavailableError marks a failure that may succeed when retried later, like
+// throttling, timeouts or an unreachable database.
+type UnavailableError struct {
+	err error
+}
+
+func (e *UnavailableError) Error() string {
+	return "Database unavailable: " + e.err.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.err
+}
+
+func NewUnavailableError(err error) *UnavailableError {
+	return &UnavailableError{
+		err: err,
+	}
+}
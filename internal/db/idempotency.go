@@ -0,0 +1,25 @@
+package database
+
+// IdempotencyTable keeps the responses of the requests sent with an
+// Idempotency-Key, which the database deletes after their ExpiresAt.
+const IdempotencyTable = "IdempotencyKeys"
+
+type IdempotencyRecordKey struct {
+	Key string
+}
+
+// IdempotencyRecord is saved without a response when its request starts, and
+// with it when the request ends.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	Completed   bool
+	StatusCode  int               `dynamodbav:",omitempty"`
+	Headers     map[string]string `dynamodbav:",omitempty"`
+	Body        []byte            `dynamodbav:",omitempty"`
+	CreatedAt   string
+	// LockExpiresAt is when a record without a response stops holding its key,
+	// in epoch seconds as ExpiresAt, the format of the DynamoDB time to live.
+	LockExpiresAt int64
+	ExpiresAt     int64
+}
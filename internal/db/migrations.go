@@ -9,7 +9,7 @@ import (
 func (db *Database) ApplyMigrations(ctx context.Context) error {
 	log.Info().Msg("Applying migrations...")
 
-	if !db.Client.TableExists("Posts") {
+	if !db.Client.TableExists("Posts", ctx) {
 		keys := []TableAttributes{
 			{
 				Name:          "PostId",
@@ -22,7 +22,7 @@ func (db *Database) ApplyMigrations(ctx context.Context) error {
 		}
 	}
 
-	if !db.Client.IndexExists("Posts", "UserIndex") {
+	if !db.Client.IndexExists("Posts", "UserIndex", ctx) {
 		indexes := []TableAttributes{
 			{
 				Name:          "User",
@@ -36,7 +36,7 @@ func (db *Database) ApplyMigrations(ctx context.Context) error {
 		db.Client.CreateIndexesOnTable("Posts", "UserIndex", &indexes, ctx)
 	}
 
-	if !db.Client.IndexExists("Posts", "TypeIndex") {
+	if !db.Client.IndexExists("Posts", "TypeIndex", ctx) {
 		indexes := []TableAttributes{
 			{
 				Name:          "Type",
@@ -50,5 +50,63 @@ func (db *Database) ApplyMigrations(ctx context.Context) error {
 		db.Client.CreateIndexesOnTable("Posts", "TypeIndex", &indexes, ctx)
 	}
 
+	if !db.Client.IndexExists("Posts", "StatusIndex", ctx) {
+		indexes := []TableAttributes{
+			{
+				Name:          "Status",
+				AttributeType: "string",
+			},
+			{
+				Name:          "CreatedAt",
+				AttributeType: "string",
+			},
+		}
+		db.Client.CreateIndexesOnTable("Posts", "StatusIndex", &indexes, ctx)
+	}
+
+	if !db.Client.TableExists("Outbox", ctx) {
+		keys := []TableAttributes{
+			{
+				Name:          "EventId",
+				AttributeType: "string",
+			},
+		}
+		err := db.Client.CreateTable("Outbox", &keys, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !db.Client.IndexExists("Outbox", "StatusIndex", ctx) {
+		indexes := []TableAttributes{
+			{
+				Name:          "Status",
+				AttributeType: "string",
+			},
+			{
+				Name:          "CreatedAt",
+				AttributeType: "string",
+			},
+		}
+		db.Client.CreateIndexesOnTable("Outbox", "StatusIndex", &indexes, ctx)
+	}
+
+	if !db.Client.TableExists(IdempotencyTable, ctx) {
+		keys := []TableAttributes{
+			{
+				Name:          "Key",
+				AttributeType: "string",
+			},
+		}
+		err := db.Client.CreateTable(IdempotencyTable, &keys, ctx)
+		if err != nil {
+			return err
+		}
+		err = db.Client.EnableTimeToLive(IdempotencyTable, "ExpiresAt", ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
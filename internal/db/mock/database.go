@@ -12,6 +12,43 @@ import (
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockTransactionOperation is a mock of TransactionOperation interface.
+type MockTransactionOperation struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionOperationMockRecorder
+}
+
+// MockTransactionOperationMockRecorder is the mock recorder for MockTransactionOperation.
+type MockTransactionOperationMockRecorder struct {
+	mock *MockTransactionOperation
+}
+
+// NewMockTransactionOperation creates a new mock instance.
+func NewMockTransactionOperation(ctrl *gomock.Controller) *MockTransactionOperation {
+	mock := &MockTransactionOperation{ctrl: ctrl}
+	mock.recorder = &MockTransactionOperationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionOperation) EXPECT() *MockTransactionOperationMockRecorder {
+	return m.recorder
+}
+
+// transactionTable mocks base method.
+func (m *MockTransactionOperation) transactionTable() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "transactionTable")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// transactionTable indicates an expected call of transactionTable.
+func (mr *MockTransactionOperationMockRecorder) transactionTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "transactionTable", reflect.TypeOf((*MockTransactionOperation)(nil).transactionTable))
+}
+
 // MockDatabaseClient is a mock of DatabaseClient interface.
 type MockDatabaseClient struct {
 	ctrl     *gomock.Controller
@@ -63,39 +100,99 @@ func (mr *MockDatabaseClientMockRecorder) CreateTable(tableName, keys, ctx inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTable", reflect.TypeOf((*MockDatabaseClient)(nil).CreateTable), tableName, keys, ctx)
 }
 
+// EnableTimeToLive mocks base method.
+func (m *MockDatabaseClient) EnableTimeToLive(tableName, attributeName string, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTimeToLive", tableName, attributeName, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTimeToLive indicates an expected call of EnableTimeToLive.
+func (mr *MockDatabaseClientMockRecorder) EnableTimeToLive(tableName, attributeName, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTimeToLive", reflect.TypeOf((*MockDatabaseClient)(nil).EnableTimeToLive), tableName, attributeName, ctx)
+}
+
+// ExecuteTransaction mocks base method.
+func (m *MockDatabaseClient) ExecuteTransaction(operations []database.TransactionOperation, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteTransaction", operations, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecuteTransaction indicates an expected call of ExecuteTransaction.
+func (mr *MockDatabaseClientMockRecorder) ExecuteTransaction(operations, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteTransaction", reflect.TypeOf((*MockDatabaseClient)(nil).ExecuteTransaction), operations, ctx)
+}
+
 // GetData mocks base method.
-func (m *MockDatabaseClient) GetData(tableName string, key, result any) error {
+func (m *MockDatabaseClient) GetData(tableName string, key, result any, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetData", tableName, key, result)
+	ret := m.ctrl.Call(m, "GetData", tableName, key, result, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GetData indicates an expected call of GetData.
-func (mr *MockDatabaseClientMockRecorder) GetData(tableName, key, result interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) GetData(tableName, key, result, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetData", reflect.TypeOf((*MockDatabaseClient)(nil).GetData), tableName, key, result, ctx)
+}
+
+// GetPendingOutboxEvents mocks base method.
+func (m *MockDatabaseClient) GetPendingOutboxEvents(limit int, ctx context.Context) ([]*database.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingOutboxEvents", limit, ctx)
+	ret0, _ := ret[0].([]*database.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingOutboxEvents indicates an expected call of GetPendingOutboxEvents.
+func (mr *MockDatabaseClientMockRecorder) GetPendingOutboxEvents(limit, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetData", reflect.TypeOf((*MockDatabaseClient)(nil).GetData), tableName, key, result)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingOutboxEvents", reflect.TypeOf((*MockDatabaseClient)(nil).GetPendingOutboxEvents), limit, ctx)
 }
 
 // GetPostsByIds mocks base method.
-func (m *MockDatabaseClient) GetPostsByIds(postIds []string) ([]*database.Post, error) {
+func (m *MockDatabaseClient) GetPostsByIds(postIds []string, ctx context.Context) ([]*database.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByIds", postIds)
+	ret := m.ctrl.Call(m, "GetPostsByIds", postIds, ctx)
 	ret0, _ := ret[0].([]*database.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPostsByIds indicates an expected call of GetPostsByIds.
-func (mr *MockDatabaseClientMockRecorder) GetPostsByIds(postIds interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) GetPostsByIds(postIds, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByIds", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByIds), postIds, ctx)
+}
+
+// GetPostsByIndexType mocks base method.
+func (m *MockDatabaseClient) GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByIndexType", postType, lastPostId, lastPostCreatedAt, limit, ctx)
+	ret0, _ := ret[0].([]*database.Post)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(string)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// GetPostsByIndexType indicates an expected call of GetPostsByIndexType.
+func (mr *MockDatabaseClientMockRecorder) GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt, limit, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByIds", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByIds), postIds)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByIndexType", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByIndexType), postType, lastPostId, lastPostCreatedAt, limit, ctx)
 }
 
 // GetPostsByIndexUser mocks base method.
-func (m *MockDatabaseClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int) ([]*database.Post, string, string, error) {
+func (m *MockDatabaseClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByIndexUser", username, lastPostId, lastPostCreatedAt, limit)
+	ret := m.ctrl.Call(m, "GetPostsByIndexUser", username, lastPostId, lastPostCreatedAt, limit, ctx)
 	ret0, _ := ret[0].([]*database.Post)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(string)
@@ -104,77 +201,176 @@ func (m *MockDatabaseClient) GetPostsByIndexUser(username, lastPostId, lastPostC
 }
 
 // GetPostsByIndexUser indicates an expected call of GetPostsByIndexUser.
-func (mr *MockDatabaseClientMockRecorder) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByIndexUser", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByIndexUser), username, lastPostId, lastPostCreatedAt, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByIndexUser", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByIndexUser), username, lastPostId, lastPostCreatedAt, limit, ctx)
+}
+
+// GetPostsByStatusCreatedBefore mocks base method.
+func (m *MockDatabaseClient) GetPostsByStatusCreatedBefore(status, createdBefore string, ctx context.Context) ([]*database.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByStatusCreatedBefore", status, createdBefore, ctx)
+	ret0, _ := ret[0].([]*database.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByStatusCreatedBefore indicates an expected call of GetPostsByStatusCreatedBefore.
+func (mr *MockDatabaseClientMockRecorder) GetPostsByStatusCreatedBefore(status, createdBefore, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByStatusCreatedBefore", reflect.TypeOf((*MockDatabaseClient)(nil).GetPostsByStatusCreatedBefore), status, createdBefore, ctx)
 }
 
 // IndexExists mocks base method.
-func (m *MockDatabaseClient) IndexExists(tableName, indexName string) bool {
+func (m *MockDatabaseClient) IndexExists(tableName, indexName string, ctx context.Context) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IndexExists", tableName, indexName)
+	ret := m.ctrl.Call(m, "IndexExists", tableName, indexName, ctx)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // IndexExists indicates an expected call of IndexExists.
-func (mr *MockDatabaseClientMockRecorder) IndexExists(tableName, indexName interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) IndexExists(tableName, indexName, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexExists", reflect.TypeOf((*MockDatabaseClient)(nil).IndexExists), tableName, indexName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexExists", reflect.TypeOf((*MockDatabaseClient)(nil).IndexExists), tableName, indexName, ctx)
 }
 
 // InsertData mocks base method.
-func (m *MockDatabaseClient) InsertData(tableName string, attributes any) error {
+func (m *MockDatabaseClient) InsertData(tableName string, attributes any, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertData", tableName, attributes)
+	ret := m.ctrl.Call(m, "InsertData", tableName, attributes, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InsertData indicates an expected call of InsertData.
-func (mr *MockDatabaseClientMockRecorder) InsertData(tableName, attributes interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) InsertData(tableName, attributes, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertData", reflect.TypeOf((*MockDatabaseClient)(nil).InsertData), tableName, attributes, ctx)
+}
+
+// InsertDataIfMatches mocks base method.
+func (m *MockDatabaseClient) InsertDataIfMatches(tableName string, attributes any, conditions map[string]any, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertDataIfMatches", tableName, attributes, conditions, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertDataIfMatches indicates an expected call of InsertDataIfMatches.
+func (mr *MockDatabaseClientMockRecorder) InsertDataIfMatches(tableName, attributes, conditions, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertDataIfMatches", reflect.TypeOf((*MockDatabaseClient)(nil).InsertDataIfMatches), tableName, attributes, conditions, ctx)
+}
+
+// InsertDataIfNotExists mocks base method.
+func (m *MockDatabaseClient) InsertDataIfNotExists(tableName, keyAttribute string, attributes any, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertDataIfNotExists", tableName, keyAttribute, attributes, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertDataIfNotExists indicates an expected call of InsertDataIfNotExists.
+func (mr *MockDatabaseClientMockRecorder) InsertDataIfNotExists(tableName, keyAttribute, attributes, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertData", reflect.TypeOf((*MockDatabaseClient)(nil).InsertData), tableName, attributes)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertDataIfNotExists", reflect.TypeOf((*MockDatabaseClient)(nil).InsertDataIfNotExists), tableName, keyAttribute, attributes, ctx)
 }
 
 // RemoveData mocks base method.
-func (m *MockDatabaseClient) RemoveData(tableName string, key any) error {
+func (m *MockDatabaseClient) RemoveData(tableName string, key any, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveData", tableName, key)
+	ret := m.ctrl.Call(m, "RemoveData", tableName, key, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveData indicates an expected call of RemoveData.
-func (mr *MockDatabaseClientMockRecorder) RemoveData(tableName, key interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) RemoveData(tableName, key, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveData", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveData), tableName, key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveData", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveData), tableName, key, ctx)
+}
+
+// RemoveDataIfMatches mocks base method.
+func (m *MockDatabaseClient) RemoveDataIfMatches(tableName string, key any, conditions map[string]any, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDataIfMatches", tableName, key, conditions, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDataIfMatches indicates an expected call of RemoveDataIfMatches.
+func (mr *MockDatabaseClientMockRecorder) RemoveDataIfMatches(tableName, key, conditions, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDataIfMatches", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveDataIfMatches), tableName, key, conditions, ctx)
+}
+
+// RemoveDataIfVersion mocks base method.
+func (m *MockDatabaseClient) RemoveDataIfVersion(tableName string, key any, expectedVersion int, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDataIfVersion", tableName, key, expectedVersion, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDataIfVersion indicates an expected call of RemoveDataIfVersion.
+func (mr *MockDatabaseClientMockRecorder) RemoveDataIfVersion(tableName, key, expectedVersion, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDataIfVersion", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveDataIfVersion), tableName, key, expectedVersion, ctx)
 }
 
 // RemoveMultipleData mocks base method.
-func (m *MockDatabaseClient) RemoveMultipleData(tableName string, keys []any) error {
+func (m *MockDatabaseClient) RemoveMultipleData(tableName string, keys []any, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveMultipleData", tableName, keys)
+	ret := m.ctrl.Call(m, "RemoveMultipleData", tableName, keys, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveMultipleData indicates an expected call of RemoveMultipleData.
-func (mr *MockDatabaseClientMockRecorder) RemoveMultipleData(tableName, keys interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) RemoveMultipleData(tableName, keys, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMultipleData", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveMultipleData), tableName, keys)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMultipleData", reflect.TypeOf((*MockDatabaseClient)(nil).RemoveMultipleData), tableName, keys, ctx)
 }
 
 // TableExists mocks base method.
-func (m *MockDatabaseClient) TableExists(tableName string) bool {
+func (m *MockDatabaseClient) TableExists(tableName string, ctx context.Context) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TableExists", tableName)
+	ret := m.ctrl.Call(m, "TableExists", tableName, ctx)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // TableExists indicates an expected call of TableExists.
-func (mr *MockDatabaseClientMockRecorder) TableExists(tableName interface{}) *gomock.Call {
+func (mr *MockDatabaseClientMockRecorder) TableExists(tableName, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableExists", reflect.TypeOf((*MockDatabaseClient)(nil).TableExists), tableName, ctx)
+}
+
+// UpdateData mocks base method.
+func (m *MockDatabaseClient) UpdateData(tableName string, key any, attributes map[string]any, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateData", tableName, key, attributes, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateData indicates an expected call of UpdateData.
+func (mr *MockDatabaseClientMockRecorder) UpdateData(tableName, key, attributes, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockDatabaseClient)(nil).UpdateData), tableName, key, attributes, ctx)
+}
+
+// UpdateDataIfVersion mocks base method.
+func (m *MockDatabaseClient) UpdateDataIfVersion(tableName string, key any, attributes map[string]any, expectedVersion int, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDataIfVersion", tableName, key, attributes, expectedVersion, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDataIfVersion indicates an expected call of UpdateDataIfVersion.
+func (mr *MockDatabaseClientMockRecorder) UpdateDataIfVersion(tableName, key, attributes, expectedVersion, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TableExists", reflect.TypeOf((*MockDatabaseClient)(nil).TableExists), tableName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDataIfVersion", reflect.TypeOf((*MockDatabaseClient)(nil).UpdateDataIfVersion), tableName, key, attributes, expectedVersion, ctx)
 }
@@ -2,6 +2,17 @@ package database
 
 import "time"
 
+const (
+	PostStatusPending   = "pending"
+	PostStatusPublished = "published"
+	// PostStatusReaping marks an abandoned pending post whose objects the
+	// reaper is deleting, so it can no longer be confirmed.
+	PostStatusReaping = "reaping"
+	// PostStatusDeleting marks a post deleted by its owner whose objects are
+	// not deleted yet, the reaper deletes them if the request could not.
+	PostStatusDeleting = "deleting"
+)
+
 type PostKey struct {
 	PostId string
 }
@@ -12,7 +23,20 @@ type Post struct {
 	Type         string    `json:"type"`
 	Title        string    `json:"title"`
 	Description  string    `json:"description"`
+	Size         int       `json:"size"`
 	CreatedAt    time.Time `json:"created_at"`
 	LastUpdated  time.Time `json:"last_updated"`
 	HasThumbnail bool      `json:"has_thumbnail"`
+	Status       string    `json:"status"`
+	UploadId     string    `json:"upload_id"`
+	Version      int       `json:"version"`
+}
+
+func (p *Post) IsPublished() bool {
+	return IsPublishedStatus(p.Status)
+}
+
+// Posts created before the lifecycle status existed have no Status and were always visible.
+func IsPublishedStatus(status string) bool {
+	return status == "" || status == PostStatusPublished
 }
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	OutboxStatusPending = "pending"
+	// OutboxStatusFailed marks the events that were not published after the
+	// maximum number of attempts, so they no longer hold back the others.
+	OutboxStatusFailed = "failed"
+)
+
+type OutboxEventKey struct {
+	EventId string
+}
+
+type OutboxEvent struct {
+	EventId   string
+	Type      string
+	Data      []byte
+	Status    string
+	CreatedAt string
+	Attempts  int
+	LastError string `dynamodbav:",omitempty"`
+	// TraceContext carries the trace of the request that stored the event to
+	// the relay that publishes it.
+	TraceContext map[string]string `dynamodbav:",omitempty"`
+}
+
+var outboxTimeLayout string = "2006-01-02T15:04:05.000000Z"
+
+func NewOutboxEvent(eventType string, data []byte, traceContext map[string]string) (*OutboxEvent, error) {
+	eventId := make([]byte, 16)
+	_, err := rand.Read(eventId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutboxEvent{
+		EventId:      hex.EncodeToString(eventId),
+		Type:         eventType,
+		Data:         data,
+		Status:       OutboxStatusPending,
+		CreatedAt:    time.Now().UTC().Format(outboxTimeLayout),
+		TraceContext: traceContext,
+	}, nil
+}
+
+func OutboxTimestamp(t time.Time) string {
+	return t.UTC().Format(outboxTimeLayout)
+}
@@ -1,8 +1,13 @@
 package create_post
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"postservice/internal/api"
 	"postservice/internal/bus"
+	database "postservice/internal/db"
+	objectstorage "postservice/internal/objectStorage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -15,15 +20,17 @@ type CreatePostController struct {
 }
 
 type CreatePostResponse struct {
-	PostId                string   `json:"postId"`
-	UploadId              string   `json:"uploadId"`
-	PresignedUrls         []string `json:"presignedUrls"`
-	PresignedThumbnailUrl string   `json:"presignedThumbnailUrl"`
+	PostId                string       `json:"postId"`
+	UploadId              string       `json:"uploadId"`
+	PartSize              int          `json:"partSize"`
+	PresignedUrls         []string     `json:"presignedUrls"`
+	Parts                 []UploadPart `json:"parts"`
+	PresignedThumbnailUrl string       `json:"presignedThumbnailUrl"`
 }
 
 type Service interface {
-	CreatePost(post *Post) (CreatePostResult, error)
-	ConfirmCreatedPost(confirmPostData *ConfirmedCreatedPost) error
+	CreatePost(post *Post, ctx context.Context) (CreatePostResult, error)
+	ConfirmCreatedPost(confirmPostData *ConfirmedCreatedPost, ctx context.Context) error
 }
 
 func NewCreatePostController(service Service, bus *bus.EventBus) *CreatePostController {
@@ -43,20 +50,31 @@ func (controller *CreatePostController) CreatePost(c *gin.Context) {
 
 	if err := c.BindJSON(&post); err != nil {
 		log.Error().Stack().Err(err).Msg("Invalid Data")
-		api.SendBadRequest(c, "Invalid Json Request")
+		api.SendError(c, api.NewValidationError("Invalid Json Request"))
+		return
+	}
+	post.User = api.GetUsername(c)
+	if fieldErrors := post.Validate(); len(fieldErrors) > 0 {
+		api.SendError(c, api.NewFieldsValidationError(fieldErrors))
 		return
 	}
 
-	postResult, err := controller.service.CreatePost(&post)
+	postResult, err := controller.service.CreatePost(&post, c.Request.Context())
 	if err != nil {
-		api.SendInternalServerError(c, err.Error())
+		var objectTooLargeError *objectstorage.ObjectTooLargeError
+		if errors.As(err, &objectTooLargeError) {
+			err = api.NewFieldsValidationError([]api.FieldError{{Field: "size", Message: fmt.Sprintf("can be at most %d bytes", objectTooLargeError.MaxSize)}})
+		}
+		api.SendError(c, err)
 		return
 	}
 
 	postResponse := &CreatePostResponse{
 		PostId:                postResult.PostId,
 		UploadId:              postResult.PresignedUrl.UploadId,
+		PartSize:              postResult.PresignedUrl.PartSize,
 		PresignedUrls:         postResult.PresignedUrl.ContentPresignedUrls,
+		Parts:                 postResult.PresignedUrl.ContentParts,
 		PresignedThumbnailUrl: postResult.PresignedUrl.ThumbanilPresignedUrl,
 	}
 
@@ -69,13 +87,39 @@ func (controller *CreatePostController) ConfirmCreatedPost(c *gin.Context) {
 	var post ConfirmedCreatedPost
 	if err := c.BindJSON(&post); err != nil {
 		log.Error().Stack().Err(err).Msg("Invalid Data")
-		api.SendBadRequest(c, "Invalid Json Request")
+		api.SendError(c, api.NewValidationError("Invalid Json Request"))
+		return
+	}
+
+	post.User = api.GetUsername(c)
+	version, err := api.IfMatchVersion(c)
+	if err != nil {
+		api.SendError(c, err)
 		return
 	}
+	post.Version = version
 
-	err := controller.service.ConfirmCreatedPost(&post)
+	err = controller.service.ConfirmCreatedPost(&post, c.Request.Context())
 	if err != nil {
-		api.SendInternalServerError(c, err.Error())
+		var notFoundError *database.NotFoundError
+		var forbiddenError *database.ForbiddenError
+		var invalidPostStatusError *InvalidPostStatusError
+		var conflictError *database.ConflictError
+		var uploadNotVerifiedError *UploadNotVerifiedError
+		if errors.As(err, &notFoundError) {
+			err = api.NewNotFoundError(fmt.Sprintf("Post not found for post id %s", post.PostId), err)
+		} else if errors.As(err, &forbiddenError) {
+			err = api.NewForbiddenError(fmt.Sprintf("Post %s does not belong to user %s", post.PostId, post.User), err)
+		} else if errors.As(err, &invalidPostStatusError) {
+			err = api.NewConflictError(invalidPostStatusError.Error(), err)
+		} else if errors.As(err, &uploadNotVerifiedError) {
+			err = api.NewConflictError(uploadNotVerifiedError.Error(), err)
+		} else if errors.As(err, &conflictError) && post.Version != nil {
+			err = api.NewPreconditionFailedError(fmt.Sprintf("Post %s does not have the version of If-Match", post.PostId), err)
+		} else if errors.As(err, &conflictError) {
+			err = api.NewConflictError(fmt.Sprintf("Post %s was modified concurrently, try again", post.PostId), err)
+		}
+		api.SendError(c, err)
 		return
 	}
 
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"postservice/internal/api"
 	"postservice/internal/bus"
+	database "postservice/internal/db"
 	"postservice/internal/features/create_post"
 	mock_create_post "postservice/internal/features/create_post/mock"
+	objectstorage "postservice/internal/objectStorage"
 	"strings"
 	"testing"
 
@@ -34,13 +37,14 @@ func setUpHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	apiResponse = httptest.NewRecorder()
 	ginContext, _ = gin.CreateTestContext(apiResponse)
+	api.SetIdentity(ginContext, &api.Identity{Username: "username1"})
 }
 
 func TestCreatePost_HasThumbnailIsTrue(t *testing.T) {
 	setUpHandler(t)
 	newPost := &create_post.Post{
 		User:         "username1",
-		Type:         "Text",
+		Type:         "TEXT",
 		Title:        "Meu Post",
 		Description:  "Este é o meu novo post",
 		Size:         120,
@@ -52,14 +56,31 @@ func TestCreatePost_HasThumbnailIsTrue(t *testing.T) {
 	expectedPresignedUrl1 := "https://presigned/url1"
 	expectedPresignedUrl2 := "https://presigned/url2"
 	expectedPresignedUrlThumbanil := "https://presigned/url/thumbnail"
-	controllerService.EXPECT().CreatePost(newPost).Return(create_post.CreatePostResult{expectedPostId, create_post.PresignedUrl{"NoUploadId", []string{expectedPresignedUrl1, expectedPresignedUrl2}, expectedPresignedUrlThumbanil}}, nil)
+	controllerService.EXPECT().CreatePost(newPost, gomock.Any()).Return(create_post.CreatePostResult{
+		PostId: expectedPostId,
+		PresignedUrl: create_post.PresignedUrl{
+			UploadId:             "NoUploadId",
+			PartSize:             60,
+			ContentPresignedUrls: []string{expectedPresignedUrl1, expectedPresignedUrl2},
+			ContentParts: []create_post.UploadPart{
+				{PartNumber: 1, Url: expectedPresignedUrl1, FirstByte: 0, LastByte: 59},
+				{PartNumber: 2, Url: expectedPresignedUrl2, FirstByte: 60, LastByte: 119},
+			},
+			ThumbanilPresignedUrl: expectedPresignedUrlThumbanil,
+		},
+	}, nil)
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
 		"content": {
 			"postId": "` + expectedPostId + `",
 			"uploadId": "NoUploadId",
+			"partSize": 60,
 			"presignedUrls":["` + expectedPresignedUrl1 + `","` + expectedPresignedUrl2 + `"],
+			"parts":[
+				{"partNumber":1,"url":"` + expectedPresignedUrl1 + `","firstByte":0,"lastByte":59},
+				{"partNumber":2,"url":"` + expectedPresignedUrl2 + `","firstByte":60,"lastByte":119}
+			],
 			"presignedThumbnailUrl":"` + expectedPresignedUrlThumbanil + `"
 		}
 	}`
@@ -74,7 +95,7 @@ func TestCreatePost_HasThumbnailIsFalse(t *testing.T) {
 	setUpHandler(t)
 	newPost := &create_post.Post{
 		User:         "username1",
-		Type:         "Text",
+		Type:         "TEXT",
 		Title:        "Meu Post",
 		Description:  "Este é o meu novo post",
 		Size:         120,
@@ -85,14 +106,30 @@ func TestCreatePost_HasThumbnailIsFalse(t *testing.T) {
 	expectedPostId := "username1-Meu_Post-1723153880"
 	expectedPresignedUrl1 := "https://presigned/url1"
 	expectedPresignedUrl2 := "https://presigned/url2"
-	controllerService.EXPECT().CreatePost(newPost).Return(create_post.CreatePostResult{expectedPostId, create_post.PresignedUrl{"NoUploadId", []string{expectedPresignedUrl1, expectedPresignedUrl2}, ""}}, nil)
+	controllerService.EXPECT().CreatePost(newPost, gomock.Any()).Return(create_post.CreatePostResult{
+		PostId: expectedPostId,
+		PresignedUrl: create_post.PresignedUrl{
+			UploadId:             "NoUploadId",
+			PartSize:             60,
+			ContentPresignedUrls: []string{expectedPresignedUrl1, expectedPresignedUrl2},
+			ContentParts: []create_post.UploadPart{
+				{PartNumber: 1, Url: expectedPresignedUrl1, FirstByte: 0, LastByte: 59},
+				{PartNumber: 2, Url: expectedPresignedUrl2, FirstByte: 60, LastByte: 119},
+			},
+		},
+	}, nil)
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
 		"content": {
 			"postId": "` + expectedPostId + `",
 			"uploadId": "NoUploadId",
+			"partSize": 60,
 			"presignedUrls":["` + expectedPresignedUrl1 + `","` + expectedPresignedUrl2 + `"],
+			"parts":[
+				{"partNumber":1,"url":"` + expectedPresignedUrl1 + `","firstByte":0,"lastByte":59},
+				{"partNumber":2,"url":"` + expectedPresignedUrl2 + `","firstByte":60,"lastByte":119}
+			],
 			"presignedThumbnailUrl":""
 		}
 	}`
@@ -107,17 +144,19 @@ func TestInternalServerErrorOnCreatePost(t *testing.T) {
 	setUpHandler(t)
 	newPost := &create_post.Post{
 		User:        "username1",
-		Type:        "Text",
+		Type:        "TEXT",
 		Title:       "Meu Post",
 		Description: "Este é o meu novo post",
+		Size:        120,
 	}
 	data, _ := serializeData(newPost)
 	ginContext.Request = httptest.NewRequest(http.MethodPost, "/post", bytes.NewBuffer(data))
 	expectedError := errors.New("some error")
-	controllerService.EXPECT().CreatePost(newPost).Return(create_post.CreatePostResult{}, expectedError)
+	controllerService.EXPECT().CreatePost(newPost, gomock.Any()).Return(create_post.CreatePostResult{}, expectedError)
 	expectedBodyResponse := `{
 		"error": true,
-		"message": "` + expectedError.Error() + `",
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
 		"content":null
 	}`
 
@@ -130,12 +169,13 @@ func TestInternalServerErrorOnCreatePost(t *testing.T) {
 func TestConfirmCreatedPostWhenIsConfirmed(t *testing.T) {
 	setUpHandler(t)
 	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      "postId",
 	}
 	data, _ := serializeData(confirmedPost)
 	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
-	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost)
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any())
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -151,6 +191,7 @@ func TestConfirmCreatedPostWhenIsConfirmed(t *testing.T) {
 func TestConfirmCreatedPostWhenMultipartIsConfirmed(t *testing.T) {
 	setUpHandler(t)
 	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      "postId",
 		IsMultipart: true,
@@ -168,7 +209,7 @@ func TestConfirmCreatedPostWhenMultipartIsConfirmed(t *testing.T) {
 	}
 	data, _ := serializeData(confirmedPost)
 	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
-	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost)
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any())
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -184,12 +225,13 @@ func TestConfirmCreatedPostWhenMultipartIsConfirmed(t *testing.T) {
 func TestConfirmCreatedPostWhenIsNotConfirmed(t *testing.T) {
 	setUpHandler(t)
 	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: false,
 		PostId:      "postId",
 	}
 	data, _ := serializeData(notConfirmedPost)
 	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
-	controllerService.EXPECT().ConfirmCreatedPost(notConfirmedPost)
+	controllerService.EXPECT().ConfirmCreatedPost(notConfirmedPost, gomock.Any())
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -202,6 +244,183 @@ func TestConfirmCreatedPostWhenIsNotConfirmed(t *testing.T) {
 	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
 }
 
+func TestConfirmCreatedPost_NotFound(t *testing.T) {
+	setUpHandler(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(database.NewNotFoundError("Posts", "postId"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "NOT_FOUND",
+		"message": "Post not found for post id postId",
+		"content": null
+	}`
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 404)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestConfirmCreatedPost_Forbidden(t *testing.T) {
+	setUpHandler(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(database.NewForbiddenError("Posts", "postId", "username1"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "FORBIDDEN",
+		"message": "Post postId does not belong to user username1",
+		"content": null
+	}`
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 403)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestConfirmCreatedPost_Conflict(t *testing.T) {
+	setUpHandler(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(&create_post.InvalidPostStatusError{})
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 409)
+}
+
+func TestConfirmCreatedPost_IfMatchDoesNotMatch(t *testing.T) {
+	setUpHandler(t)
+	version := 2
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		Version:     &version,
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	ginContext.Request.Header.Set("If-Match", `"2"`)
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(database.NewConflictError("Posts", "postId", "expected version 2, found 1"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "PRECONDITION_FAILED",
+		"message": "Post postId does not have the version of If-Match",
+		"content": null
+	}`
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 412)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestConfirmCreatedPost_ConcurrentConfirmation(t *testing.T) {
+	setUpHandler(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(database.NewConflictError("Posts", "postId", "expected version 1, found 2"))
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 409)
+}
+
+func TestConfirmCreatedPost_UploadNotVerified(t *testing.T) {
+	setUpHandler(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	data, _ := serializeData(confirmedPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/confirm-created-post", bytes.NewBuffer(data))
+	controllerService.EXPECT().ConfirmCreatedPost(confirmedPost, gomock.Any()).Return(&create_post.UploadNotVerifiedError{})
+
+	controller.ConfirmCreatedPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 409)
+	assert.Equal(t, strings.Contains(apiResponse.Body.String(), "can not be confirmed"), true)
+}
+
+func TestCreatePost_InvalidFields(t *testing.T) {
+	setUpHandler(t)
+	newPost := &create_post.Post{
+		Type:        "AUDIO",
+		Title:       "Meu/Post",
+		Description: strings.Repeat("a", 2001),
+		Size:        -1,
+	}
+	data, _ := serializeData(newPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/post", bytes.NewBuffer(data))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid request fields",
+		"fields": [
+			{"field": "type", "message": "has to be one of TEXT, IMAGE or VIDEO"},
+			{"field": "title", "message": "can't contain '/', '\\' or control characters"},
+			{"field": "description", "message": "can have at most 2000 characters"},
+			{"field": "size", "message": "has to be positive"}
+		],
+		"content": null
+	}`
+
+	controller.CreatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestCreatePost_ObjectTooLarge(t *testing.T) {
+	setUpHandler(t)
+	newPost := &create_post.Post{
+		User:  "username1",
+		Type:  "VIDEO",
+		Title: "Meu Post",
+		Size:  2 << 30,
+	}
+	data, _ := serializeData(newPost)
+	ginContext.Request = httptest.NewRequest(http.MethodPost, "/post", bytes.NewBuffer(data))
+	controllerService.EXPECT().CreatePost(newPost, gomock.Any()).Return(create_post.CreatePostResult{}, &objectstorage.ObjectTooLargeError{Size: 2 << 30, MaxSize: 1 << 30})
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid request fields",
+		"fields": [
+			{"field": "size", "message": "can be at most 1073741824 bytes"}
+		],
+		"content": null
+	}`
+
+	controller.CreatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
 func serializeData(data any) ([]byte, error) {
 	return json.Marshal(data)
 }
@@ -5,6 +5,7 @@
 package mock_create_post
 
 import (
+	context "context"
 	create_post "postservice/internal/features/create_post"
 	reflect "reflect"
 
@@ -35,30 +36,30 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // ConfirmCreatedPost mocks base method.
-func (m *MockService) ConfirmCreatedPost(confirmPostData *create_post.ConfirmedCreatedPost) error {
+func (m *MockService) ConfirmCreatedPost(confirmPostData *create_post.ConfirmedCreatedPost, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ConfirmCreatedPost", confirmPostData)
+	ret := m.ctrl.Call(m, "ConfirmCreatedPost", confirmPostData, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ConfirmCreatedPost indicates an expected call of ConfirmCreatedPost.
-func (mr *MockServiceMockRecorder) ConfirmCreatedPost(confirmPostData interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) ConfirmCreatedPost(confirmPostData, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmCreatedPost", reflect.TypeOf((*MockService)(nil).ConfirmCreatedPost), confirmPostData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmCreatedPost", reflect.TypeOf((*MockService)(nil).ConfirmCreatedPost), confirmPostData, ctx)
 }
 
 // CreatePost mocks base method.
-func (m *MockService) CreatePost(post *create_post.Post) (create_post.CreatePostResult, error) {
+func (m *MockService) CreatePost(post *create_post.Post, ctx context.Context) (create_post.CreatePostResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreatePost", post)
+	ret := m.ctrl.Call(m, "CreatePost", post, ctx)
 	ret0, _ := ret[0].(create_post.CreatePostResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreatePost indicates an expected call of CreatePost.
-func (mr *MockServiceMockRecorder) CreatePost(post interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreatePost(post, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockService)(nil).CreatePost), post)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockService)(nil).CreatePost), post, ctx)
 }
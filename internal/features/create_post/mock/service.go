@@ -5,7 +5,10 @@
 package mock_create_post
 
 import (
+	context "context"
+	bus "postservice/internal/bus"
 	create_post "postservice/internal/features/create_post"
+	objectstorage "postservice/internal/objectStorage"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,74 +37,160 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AbortUpload mocks base method.
+func (m *MockRepository) AbortUpload(post *create_post.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortUpload", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortUpload indicates an expected call of AbortUpload.
+func (mr *MockRepositoryMockRecorder) AbortUpload(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortUpload", reflect.TypeOf((*MockRepository)(nil).AbortUpload), post, ctx)
+}
+
 // AddNewPostMetaData mocks base method.
-func (m *MockRepository) AddNewPostMetaData(data *create_post.Post) error {
+func (m *MockRepository) AddNewPostMetaData(data *create_post.Post, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddNewPostMetaData", data)
+	ret := m.ctrl.Call(m, "AddNewPostMetaData", data, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddNewPostMetaData indicates an expected call of AddNewPostMetaData.
-func (mr *MockRepositoryMockRecorder) AddNewPostMetaData(data interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) AddNewPostMetaData(data, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNewPostMetaData", reflect.TypeOf((*MockRepository)(nil).AddNewPostMetaData), data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNewPostMetaData", reflect.TypeOf((*MockRepository)(nil).AddNewPostMetaData), data, ctx)
 }
 
 // CompleteMultipartUpload mocks base method.
-func (m *MockRepository) CompleteMultipartUpload(multipartPost *create_post.MultipartPost) error {
+func (m *MockRepository) CompleteMultipartUpload(multipartPost *create_post.MultipartPost, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CompleteMultipartUpload", multipartPost)
+	ret := m.ctrl.Call(m, "CompleteMultipartUpload", multipartPost, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CompleteMultipartUpload indicates an expected call of CompleteMultipartUpload.
-func (mr *MockRepositoryMockRecorder) CompleteMultipartUpload(multipartPost interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CompleteMultipartUpload(multipartPost, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUpload", reflect.TypeOf((*MockRepository)(nil).CompleteMultipartUpload), multipartPost, ctx)
+}
+
+// DeleteObjects mocks base method.
+func (m *MockRepository) DeleteObjects(post *create_post.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObjects", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObjects indicates an expected call of DeleteObjects.
+func (mr *MockRepositoryMockRecorder) DeleteObjects(post, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUpload", reflect.TypeOf((*MockRepository)(nil).CompleteMultipartUpload), multipartPost)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockRepository)(nil).DeleteObjects), post, ctx)
 }
 
 // GetPostMetadata mocks base method.
-func (m *MockRepository) GetPostMetadata(postId string) (*create_post.Post, error) {
+func (m *MockRepository) GetPostMetadata(postId string, ctx context.Context) (*create_post.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostMetadata", postId)
+	ret := m.ctrl.Call(m, "GetPostMetadata", postId, ctx)
 	ret0, _ := ret[0].(*create_post.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPostMetadata indicates an expected call of GetPostMetadata.
-func (mr *MockRepositoryMockRecorder) GetPostMetadata(postId interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPostMetadata(postId, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostMetadata", reflect.TypeOf((*MockRepository)(nil).GetPostMetadata), postId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostMetadata", reflect.TypeOf((*MockRepository)(nil).GetPostMetadata), postId, ctx)
 }
 
 // GetPresignedUrlsForUploading mocks base method.
-func (m *MockRepository) GetPresignedUrlsForUploading(data *create_post.Post) (create_post.PresignedUrl, error) {
+func (m *MockRepository) GetPresignedUrlsForUploading(data *create_post.Post, ctx context.Context) (create_post.PresignedUrl, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPresignedUrlsForUploading", data)
+	ret := m.ctrl.Call(m, "GetPresignedUrlsForUploading", data, ctx)
 	ret0, _ := ret[0].(create_post.PresignedUrl)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPresignedUrlsForUploading indicates an expected call of GetPresignedUrlsForUploading.
-func (mr *MockRepositoryMockRecorder) GetPresignedUrlsForUploading(data interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPresignedUrlsForUploading(data, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresignedUrlsForUploading", reflect.TypeOf((*MockRepository)(nil).GetPresignedUrlsForUploading), data, ctx)
+}
+
+// PublishPost mocks base method.
+func (m *MockRepository) PublishPost(post *create_post.Post, expectedVersion int, event *bus.Event, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishPost", post, expectedVersion, event, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishPost indicates an expected call of PublishPost.
+func (mr *MockRepositoryMockRecorder) PublishPost(post, expectedVersion, event, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresignedUrlsForUploading", reflect.TypeOf((*MockRepository)(nil).GetPresignedUrlsForUploading), data)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishPost", reflect.TypeOf((*MockRepository)(nil).PublishPost), post, expectedVersion, event, ctx)
 }
 
 // RemoveUnconfirmedPost mocks base method.
-func (m *MockRepository) RemoveUnconfirmedPost(postId string) error {
+func (m *MockRepository) RemoveUnconfirmedPost(postId string, expectedVersion int, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveUnconfirmedPost", postId)
+	ret := m.ctrl.Call(m, "RemoveUnconfirmedPost", postId, expectedVersion, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveUnconfirmedPost indicates an expected call of RemoveUnconfirmedPost.
-func (mr *MockRepositoryMockRecorder) RemoveUnconfirmedPost(postId interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) RemoveUnconfirmedPost(postId, expectedVersion, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUnconfirmedPost", reflect.TypeOf((*MockRepository)(nil).RemoveUnconfirmedPost), postId, expectedVersion, ctx)
+}
+
+// SaveUploadId mocks base method.
+func (m *MockRepository) SaveUploadId(postId, uploadId string, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUploadId", postId, uploadId, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUploadId indicates an expected call of SaveUploadId.
+func (mr *MockRepositoryMockRecorder) SaveUploadId(postId, uploadId, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUploadId", reflect.TypeOf((*MockRepository)(nil).SaveUploadId), postId, uploadId, ctx)
+}
+
+// StatContent mocks base method.
+func (m *MockRepository) StatContent(post *create_post.Post, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatContent", post, ctx)
+	ret0, _ := ret[0].(*objectstorage.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatContent indicates an expected call of StatContent.
+func (mr *MockRepositoryMockRecorder) StatContent(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatContent", reflect.TypeOf((*MockRepository)(nil).StatContent), post, ctx)
+}
+
+// StatThumbnail mocks base method.
+func (m *MockRepository) StatThumbnail(post *create_post.Post, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatThumbnail", post, ctx)
+	ret0, _ := ret[0].(*objectstorage.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatThumbnail indicates an expected call of StatThumbnail.
+func (mr *MockRepositoryMockRecorder) StatThumbnail(post, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUnconfirmedPost", reflect.TypeOf((*MockRepository)(nil).RemoveUnconfirmedPost), postId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatThumbnail", reflect.TypeOf((*MockRepository)(nil).StatThumbnail), post, ctx)
 }
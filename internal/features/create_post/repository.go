@@ -1,10 +1,20 @@
 package create_post
 
 import (
+	"context"
+	"errors"
+	"postservice/internal/bus"
 	database "postservice/internal/db"
 	objectstorage "postservice/internal/objectStorage"
+	"postservice/internal/tracing"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
+// maxInsertAttempts bounds the new ids tried when the id of a post is taken.
+const maxInsertAttempts = 3
+
 type CreatePostRepository struct {
 	dataRepository   *database.Database
 	objectRepository *objectstorage.ObjectStorage
@@ -31,70 +41,196 @@ type PostMetadata struct {
 	HasThumbnail bool   `json:"has_thumbnail"`
 	CreatedAt    string `json:"created_at"`
 	LastUpdated  string `json:"last_updated"`
+	Status       string `json:"status"`
+	Version      int    `json:"version"`
 }
 
-func (r *CreatePostRepository) AddNewPostMetaData(post *Post) error {
+func (r *CreatePostRepository) AddNewPostMetaData(post *Post, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.AddNewPostMetaData")
+	defer span.End()
+
 	data := &PostMetadata{
 		PostId:       post.PostId,
 		User:         post.User,
 		Type:         post.Type,
 		Title:        post.Title,
 		Description:  post.Description,
+		Size:         post.Size,
 		HasThumbnail: post.HasThumbnail,
 		CreatedAt:    post.CreatedAt,
 		LastUpdated:  post.LastUpdated,
+		Status:       post.Status,
+		Version:      post.Version,
+	}
+	for attempt := 1; ; attempt++ {
+		err := r.dataRepository.Client.InsertDataIfNotExists("Posts", "PostId", data, ctx)
+		var conflictError *database.ConflictError
+		if !errors.As(err, &conflictError) || attempt == maxInsertAttempts {
+			tracing.Fail(span, err)
+			return err
+		}
+
+		log.Warn().Msgf("Post id %s is already taken, generating another one", data.PostId)
+		data.PostId, err = generatePostId(time.Now().UTC())
+		if err != nil {
+			tracing.Fail(span, err)
+			return err
+		}
+		post.PostId = data.PostId
 	}
-	return r.dataRepository.Client.InsertData("Posts", data)
 }
 
-func (r *CreatePostRepository) GetPresignedUrlsForUploading(post *Post) (PresignedUrl, error) {
-	key := post.User + "/" + post.Type + "/" + post.PostId
-	var presignedUrl PresignedUrl
-	uploadId, contentPresignedUrls, err := r.objectRepository.Client.GetPreSignedUrlsForPuttingObject(key, post.Size)
-	presignedUrl.UploadId = uploadId
-	presignedUrl.ContentPresignedUrls = contentPresignedUrls
+func (r *CreatePostRepository) GetPresignedUrlsForUploading(post *Post, ctx context.Context) (PresignedUrl, error) {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.GetPresignedUrlsForUploading")
+	defer span.End()
 
+	upload, err := r.objectRepository.Client.GetPreSignedUrlsForPuttingObject(contentKey(post), post.Size, ctx)
 	if err != nil {
+		tracing.Fail(span, err)
 		return PresignedUrl{}, err
 	}
+	presignedUrl := convertPresignedUploadToPresignedUrl(upload)
 
 	if post.HasThumbnail {
-		thumbnailKey := post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
-		_, thumbanilPresignedUrl, err := r.objectRepository.Client.GetPreSignedUrlsForPuttingObject(thumbnailKey, 0)
+		thumbnailUpload, err := r.objectRepository.Client.GetPreSignedUrlsForPuttingObject(thumbnailKey(post), 0, ctx)
 
 		if err != nil {
+			tracing.Fail(span, err)
 			return PresignedUrl{}, err
 		}
 
-		presignedUrl.ThumbanilPresignedUrl = thumbanilPresignedUrl[0]
+		presignedUrl.ThumbanilPresignedUrl = thumbnailUpload.Parts[0].Url
 	}
 	return presignedUrl, nil
 }
 
-func (r *CreatePostRepository) GetPostMetadata(postId string) (*Post, error) {
+func (r *CreatePostRepository) GetPostMetadata(postId string, ctx context.Context) (*Post, error) {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.GetPostMetadata")
+	defer span.End()
+
 	postKey := &PostKey{
 		PostId: postId,
 	}
 	var post Post
-	err := r.dataRepository.Client.GetData("Posts", postKey, &post)
+	err := r.dataRepository.Client.GetData("Posts", postKey, &post, ctx)
+	tracing.Fail(span, err)
 
 	return &post, err
 }
 
-func (r *CreatePostRepository) CompleteMultipartUpload(multipartPost *MultipartPost) error {
+func (r *CreatePostRepository) CompleteMultipartUpload(multipartPost *MultipartPost, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.CompleteMultipartUpload")
+	defer span.End()
+
 	multipartObject := convertMultipartPostToMultipartObject(multipartPost)
-	return r.objectRepository.Client.CompleteMultipartUpload(multipartObject)
+	err := r.objectRepository.Client.CompleteMultipartUpload(multipartObject, ctx)
+	tracing.Fail(span, err)
+
+	return err
+}
+
+func (r *CreatePostRepository) SaveUploadId(postId, uploadId string, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.SaveUploadId")
+	defer span.End()
+
+	postKey := &PostKey{
+		PostId: postId,
+	}
+	attributes := map[string]any{
+		"UploadId": uploadId,
+	}
+	err := r.dataRepository.Client.UpdateData("Posts", postKey, attributes, ctx)
+	tracing.Fail(span, err)
+
+	return err
+}
+
+func (r *CreatePostRepository) PublishPost(post *Post, expectedVersion int, event *bus.Event, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.PublishPost")
+	defer span.End()
+
+	outboxEvent, err := database.NewOutboxEvent(event.Type, event.Data, tracing.Inject(ctx))
+	if err != nil {
+		return err
+	}
+
+	postKey := &PostKey{
+		PostId: post.PostId,
+	}
+	operations := []database.TransactionOperation{
+		&database.UpdateOperation{
+			TableName: "Posts",
+			Key:       postKey,
+			Attributes: map[string]any{
+				"Status": post.Status,
+			},
+			ExpectedVersion: &expectedVersion,
+		},
+		&database.InsertOperation{
+			TableName: "Outbox",
+			Item:      outboxEvent,
+		},
+	}
+	err = r.dataRepository.Client.ExecuteTransaction(operations, ctx)
+	tracing.Fail(span, err)
+
+	return err
 }
 
-func (r *CreatePostRepository) RemoveUnconfirmedPost(postId string) error {
+func (r *CreatePostRepository) AbortUpload(post *Post, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.AbortUpload")
+	defer span.End()
+
+	err := r.objectRepository.Client.AbortMultipartUpload(contentKey(post), post.UploadId, ctx)
+	tracing.Fail(span, err)
+
+	return err
+}
+
+func (r *CreatePostRepository) DeleteObjects(post *Post, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.DeleteObjects")
+	defer span.End()
+
+	err := r.objectRepository.Client.DeleteObjects([]string{contentKey(post), thumbnailKey(post)}, ctx)
+	tracing.Fail(span, err)
+
+	return err
+}
+
+func (r *CreatePostRepository) StatContent(post *Post, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.StatContent")
+	defer span.End()
+
+	info, err := r.objectRepository.Client.StatObject(contentKey(post), ctx)
+	tracing.Fail(span, err)
+
+	return info, err
+}
+
+func (r *CreatePostRepository) StatThumbnail(post *Post, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.StatThumbnail")
+	defer span.End()
+
+	info, err := r.objectRepository.Client.StatObject(thumbnailKey(post), ctx)
+	tracing.Fail(span, err)
+
+	return info, err
+}
+
+func (r *CreatePostRepository) RemoveUnconfirmedPost(postId string, expectedVersion int, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostRepository.RemoveUnconfirmedPost")
+	defer span.End()
+
 	postKey := &PostKey{
 		PostId: postId,
 	}
-	return r.dataRepository.Client.RemoveData("Posts", postKey)
+	err := r.dataRepository.Client.RemoveDataIfVersion("Posts", postKey, expectedVersion, ctx)
+	tracing.Fail(span, err)
+
+	return err
 }
 
 func convertMultipartPostToMultipartObject(post *MultipartPost) objectstorage.MultipartObject {
-	key := post.Post.User + "/" + post.Post.Type + "/" + post.Post.PostId
 	completedParts := make([]objectstorage.CompletedPart, len(post.CompletedParts))
 	for i, part := range post.CompletedParts {
 		completedParts[i] = objectstorage.CompletedPart{
@@ -104,8 +240,35 @@ func convertMultipartPostToMultipartObject(post *MultipartPost) objectstorage.Mu
 	}
 
 	return objectstorage.MultipartObject{
-		Key:           key,
+		Key:           contentKey(post.Post),
 		UploadID:      post.UploadId,
 		CompletedPart: completedParts,
 	}
 }
+
+func convertPresignedUploadToPresignedUrl(upload *objectstorage.PresignedUpload) PresignedUrl {
+	parts := make([]UploadPart, len(upload.Parts))
+	for i, part := range upload.Parts {
+		parts[i] = UploadPart{
+			PartNumber: part.PartNumber,
+			Url:        part.Url,
+			FirstByte:  part.FirstByte,
+			LastByte:   part.LastByte,
+		}
+	}
+
+	return PresignedUrl{
+		UploadId:             upload.UploadId,
+		PartSize:             upload.PartSize,
+		ContentPresignedUrls: upload.Urls(),
+		ContentParts:         parts,
+	}
+}
+
+func contentKey(post *Post) string {
+	return post.User + "/" + post.Type + "/" + post.PostId
+}
+
+func thumbnailKey(post *Post) string {
+	return post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
+}
@@ -1,6 +1,8 @@
 package create_post_test
 
 import (
+	"context"
+	"postservice/internal/bus"
 	database "postservice/internal/db"
 	mock_database "postservice/internal/db/mock"
 	"postservice/internal/features/create_post"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 )
 
 var dbClient *mock_database.MockDatabaseClient
@@ -31,9 +34,11 @@ func TestAddNewPostMetaDataInRepository(t *testing.T) {
 		Type:         "Text",
 		Title:        "Meu Post",
 		Description:  "Este é o meu novo post",
+		Size:         120,
 		HasThumbnail: true,
 		CreatedAt:    time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC().String(),
 		LastUpdated:  time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC().String(),
+		Status:       "pending",
 	}
 	data := &create_post.PostMetadata{
 		PostId:       newPost.PostId,
@@ -41,13 +46,47 @@ func TestAddNewPostMetaDataInRepository(t *testing.T) {
 		Type:         newPost.Type,
 		Title:        newPost.Title,
 		Description:  newPost.Description,
+		Size:         newPost.Size,
 		HasThumbnail: newPost.HasThumbnail,
 		CreatedAt:    newPost.CreatedAt,
 		LastUpdated:  newPost.LastUpdated,
+		Status:       newPost.Status,
 	}
-	dbClient.EXPECT().InsertData("Posts", data)
+	dbClient.EXPECT().InsertDataIfNotExists("Posts", "PostId", data, gomock.Any())
 
-	createPostRepository.AddNewPostMetaData(newPost)
+	createPostRepository.AddNewPostMetaData(newPost, context.Background())
+}
+
+func TestAddNewPostMetaDataRetriesWithNewIdOnCollision(t *testing.T) {
+	setUp(t)
+	newPost := &create_post.Post{PostId: "01J4TVR5E0AAAAAAAAAAAAAAAA", User: "username1", Type: "TEXT", Title: "Meu Post"}
+	insertedIds := []string{}
+	dbClient.EXPECT().InsertDataIfNotExists("Posts", "PostId", gomock.Any(), gomock.Any()).DoAndReturn(func(tableName, keyAttribute string, attributes any, ctx context.Context) error {
+		insertedIds = append(insertedIds, attributes.(*create_post.PostMetadata).PostId)
+		if len(insertedIds) == 1 {
+			return database.NewConflictError("Posts", "PostId", "item already exists")
+		}
+		return nil
+	}).Times(2)
+
+	err := createPostRepository.AddNewPostMetaData(newPost, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "01J4TVR5E0AAAAAAAAAAAAAAAA", insertedIds[0])
+	assert.NotEqual(t, insertedIds[0], insertedIds[1])
+	assert.Len(t, insertedIds[1], 26)
+	assert.Equal(t, insertedIds[1], newPost.PostId)
+}
+
+func TestErrorOnAddNewPostMetaDataWhenIdsKeepColliding(t *testing.T) {
+	setUp(t)
+	newPost := &create_post.Post{PostId: "01J4TVR5E0AAAAAAAAAAAAAAAA", User: "username1", Type: "TEXT", Title: "Meu Post"}
+	dbClient.EXPECT().InsertDataIfNotExists("Posts", "PostId", gomock.Any(), gomock.Any()).Return(database.NewConflictError("Posts", "PostId", "item already exists")).Times(3)
+
+	err := createPostRepository.AddNewPostMetaData(newPost, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
 }
 
 func TestGetPresignedUrlsForUploading_HasThumbnailIsTrue(t *testing.T) {
@@ -65,10 +104,10 @@ func TestGetPresignedUrlsForUploading_HasThumbnailIsTrue(t *testing.T) {
 	}
 	expectedKey := "username1/Text/username1-Meu_Post-1723153880"
 	expectedThumbnailKey := "username1/Text/THUMBNAILS/username1-Meu_Post-1723153880"
-	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedKey, newPost.Size)
-	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedThumbnailKey, 0).Return("NoUploadId", []string{"fakeurl"}, nil)
+	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedKey, newPost.Size, gomock.Any()).Return(&objectstorage.PresignedUpload{UploadId: objectstorage.NoUploadId, Parts: []objectstorage.PresignedPart{{Url: "fakeurl"}}}, nil)
+	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedThumbnailKey, 0, gomock.Any()).Return(&objectstorage.PresignedUpload{UploadId: objectstorage.NoUploadId, Parts: []objectstorage.PresignedPart{{Url: "fakeurl"}}}, nil)
 
-	createPostRepository.GetPresignedUrlsForUploading(newPost)
+	createPostRepository.GetPresignedUrlsForUploading(newPost, context.Background())
 }
 
 func TestGetPresignedUrlsForUploading_HasThumbnailIsFalse(t *testing.T) {
@@ -85,9 +124,9 @@ func TestGetPresignedUrlsForUploading_HasThumbnailIsFalse(t *testing.T) {
 		LastUpdated:  time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC().String(),
 	}
 	expectedKey := "username1/Text/username1-Meu_Post-1723153880"
-	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedKey, newPost.Size)
+	osClient.EXPECT().GetPreSignedUrlsForPuttingObject(expectedKey, newPost.Size, gomock.Any()).Return(&objectstorage.PresignedUpload{UploadId: objectstorage.NoUploadId, Parts: []objectstorage.PresignedPart{{Url: "fakeurl"}}}, nil)
 
-	createPostRepository.GetPresignedUrlsForUploading(newPost)
+	createPostRepository.GetPresignedUrlsForUploading(newPost, context.Background())
 }
 
 func TestGetPostMetadata(t *testing.T) {
@@ -97,9 +136,72 @@ func TestGetPostMetadata(t *testing.T) {
 	expectedKey := &create_post.PostKey{
 		PostId: postId,
 	}
-	dbClient.EXPECT().GetData("Posts", expectedKey, &post)
+	dbClient.EXPECT().GetData("Posts", expectedKey, &post, gomock.Any())
+
+	createPostRepository.GetPostMetadata(postId, context.Background())
+}
+
+func TestSaveUploadIdInRepository(t *testing.T) {
+	setUp(t)
+	postId := "username1-Meu_Post-1723153880"
+	expectedKey := &create_post.PostKey{
+		PostId: postId,
+	}
+	dbClient.EXPECT().UpdateData("Posts", expectedKey, map[string]any{"UploadId": "upload-id"}, gomock.Any())
+
+	createPostRepository.SaveUploadId(postId, "upload-id", context.Background())
+}
+
+func TestPublishPostInRepository(t *testing.T) {
+	setUp(t)
+	post := &create_post.Post{
+		PostId: "username1-Meu_Post-1723153880",
+		Status: "published",
+	}
+	expectedKey := &create_post.PostKey{
+		PostId: post.PostId,
+	}
+	event := &bus.Event{
+		Type: "PostWasCreatedEvent",
+		Data: []byte(`{"post_id":"username1-Meu_Post-1723153880"}`),
+	}
+	expectedVersion := 1
+	dbClient.EXPECT().ExecuteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(operations []database.TransactionOperation, ctx context.Context) error {
+		assert.Len(t, operations, 2)
+		assert.Equal(t, &database.UpdateOperation{TableName: "Posts", Key: expectedKey, Attributes: map[string]any{"Status": "published"}, ExpectedVersion: &expectedVersion}, operations[0])
+		outboxInsert := operations[1].(*database.InsertOperation)
+		outboxEvent := outboxInsert.Item.(*database.OutboxEvent)
+		assert.Equal(t, "Outbox", outboxInsert.TableName)
+		assert.NotEmpty(t, outboxEvent.EventId)
+		assert.Equal(t, event.Type, outboxEvent.Type)
+		assert.Equal(t, event.Data, outboxEvent.Data)
+		assert.Equal(t, database.OutboxStatusPending, outboxEvent.Status)
+		return nil
+	})
+
+	err := createPostRepository.PublishPost(post, expectedVersion, event, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestAbortUploadInRepository(t *testing.T) {
+	setUp(t)
+	post := &create_post.Post{PostId: "postId", User: "username1", Type: "VIDEO", UploadId: "upload-id"}
+	osClient.EXPECT().AbortMultipartUpload("username1/VIDEO/postId", "upload-id", gomock.Any()).Return(nil)
+
+	err := createPostRepository.AbortUpload(post, context.Background())
 
-	createPostRepository.GetPostMetadata(postId)
+	assert.Nil(t, err)
+}
+
+func TestDeleteObjectsInRepository(t *testing.T) {
+	setUp(t)
+	post := &create_post.Post{PostId: "postId", User: "username1", Type: "VIDEO"}
+	osClient.EXPECT().DeleteObjects([]string{"username1/VIDEO/postId", "username1/VIDEO/THUMBNAILS/postId"}, gomock.Any()).Return(nil)
+
+	err := createPostRepository.DeleteObjects(post, context.Background())
+
+	assert.Nil(t, err)
 }
 
 func TestRemoveUnconfirmedPostMetaDataInRepository(t *testing.T) {
@@ -108,7 +210,24 @@ func TestRemoveUnconfirmedPostMetaDataInRepository(t *testing.T) {
 	expectedKey := &create_post.PostKey{
 		PostId: postId,
 	}
-	dbClient.EXPECT().RemoveData("Posts", expectedKey)
+	dbClient.EXPECT().RemoveDataIfVersion("Posts", expectedKey, 1, gomock.Any())
+
+	createPostRepository.RemoveUnconfirmedPost(postId, 1, context.Background())
+}
+
+func TestStatContentAndThumbnailInRepository(t *testing.T) {
+	setUp(t)
+	post := &create_post.Post{PostId: "postId", User: "username1", Type: "IMAGE"}
+	contentInfo := &objectstorage.ObjectInfo{Size: 100, ContentType: "image/png"}
+	thumbnailInfo := &objectstorage.ObjectInfo{Size: 10, ContentType: "image/jpeg"}
+	osClient.EXPECT().StatObject("username1/IMAGE/postId", gomock.Any()).Return(contentInfo, nil)
+	osClient.EXPECT().StatObject("username1/IMAGE/THUMBNAILS/postId", gomock.Any()).Return(thumbnailInfo, nil)
+
+	content, contentErr := createPostRepository.StatContent(post, context.Background())
+	thumbnail, thumbnailErr := createPostRepository.StatThumbnail(post, context.Background())
 
-	createPostRepository.RemoveUnconfirmedPost(postId)
+	assert.Nil(t, contentErr)
+	assert.Nil(t, thumbnailErr)
+	assert.Equal(t, contentInfo, content)
+	assert.Equal(t, thumbnailInfo, thumbnail)
 }
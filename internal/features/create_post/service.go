@@ -1,9 +1,15 @@
 package create_post
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"postservice/internal/bus"
-	"strconv"
-	"strings"
+	database "postservice/internal/db"
+	"postservice/internal/metrics"
+	objectstorage "postservice/internal/objectStorage"
+	"postservice/internal/tracing"
+	"postservice/internal/ulid"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -12,16 +18,25 @@ import (
 //go:generate mockgen -source=service.go -destination=mock/service.go
 
 type Repository interface {
-	AddNewPostMetaData(data *Post) error
-	GetPresignedUrlsForUploading(data *Post) (PresignedUrl, error)
-	GetPostMetadata(postId string) (*Post, error)
-	CompleteMultipartUpload(multipartPost *MultipartPost) error
-	RemoveUnconfirmedPost(postId string) error
+	AddNewPostMetaData(data *Post, ctx context.Context) error
+	GetPresignedUrlsForUploading(data *Post, ctx context.Context) (PresignedUrl, error)
+	GetPostMetadata(postId string, ctx context.Context) (*Post, error)
+	CompleteMultipartUpload(multipartPost *MultipartPost, ctx context.Context) error
+	SaveUploadId(postId, uploadId string, ctx context.Context) error
+	PublishPost(post *Post, expectedVersion int, event *bus.Event, ctx context.Context) error
+	AbortUpload(post *Post, ctx context.Context) error
+	DeleteObjects(post *Post, ctx context.Context) error
+	StatContent(post *Post, ctx context.Context) (*objectstorage.ObjectInfo, error)
+	StatThumbnail(post *Post, ctx context.Context) (*objectstorage.ObjectInfo, error)
+	RemoveUnconfirmedPost(postId string, expectedVersion int, ctx context.Context) error
 }
 
+var postsCreated = metrics.NewCounter("postservice_posts_created_total", "Posts created and waiting for their upload, by type.", "type")
+var postsConfirmed = metrics.NewCounter("postservice_posts_confirmed_total", "Created posts confirmed after their upload, by type.", "type")
+var postsRolledBack = metrics.NewCounter("postservice_posts_rolled_back_total", "Created posts rolled back because their upload was not confirmed.")
+
 type CreatePostService struct {
 	repository Repository
-	bus        *bus.EventBus
 }
 
 type Post struct {
@@ -34,6 +49,9 @@ type Post struct {
 	HasThumbnail bool   `json:"hasThumbnail"`
 	CreatedAt    string `json:"createdAt"`
 	LastUpdated  string `json:"lastUpdated"`
+	Status       string `json:"status"`
+	Version      int    `json:"version"`
+	UploadId     string `json:"-"`
 }
 
 type CreatePostResult struct {
@@ -42,6 +60,8 @@ type CreatePostResult struct {
 }
 
 type ConfirmedCreatedPost struct {
+	User           string          `json:"-"`
+	Version        *int            `json:"-"`
 	IsConfirmed    bool            `json:"isConfirmed"`
 	PostId         string          `json:"postId"`
 	IsMultipart    bool            `json:"isMultipart"`
@@ -66,45 +86,104 @@ type PostWasCreatedEvent struct {
 }
 
 type PresignedUrl struct {
-	UploadId              string   `json:"uploadId"`
-	ContentPresignedUrls  []string `json:"contentPresignedUrls"`
-	ThumbanilPresignedUrl string   `json:"thumbanilPresignedUrl"`
+	UploadId              string       `json:"uploadId"`
+	PartSize              int          `json:"partSize"`
+	ContentPresignedUrls  []string     `json:"contentPresignedUrls"`
+	ContentParts          []UploadPart `json:"contentParts"`
+	ThumbanilPresignedUrl string       `json:"thumbanilPresignedUrl"`
+}
+
+// UploadPart is the presigned URL that uploads the bytes from FirstByte to
+// LastByte, both included, of the content.
+type UploadPart struct {
+	PartNumber int    `json:"partNumber"`
+	Url        string `json:"url"`
+	FirstByte  int    `json:"firstByte"`
+	LastByte   int    `json:"lastByte"`
+}
+
+type InvalidPostStatusError struct {
+	postId       string
+	status       string
+	targetStatus string
+}
+
+func (e *InvalidPostStatusError) Error() string {
+	return fmt.Sprintf("Post %s can not change from status %q to %q", e.postId, e.status, e.targetStatus)
+}
+
+// UploadNotVerifiedError rejects the confirmation of a post whose uploaded
+// objects are missing or don't match what was declared when it was created.
+type UploadNotVerifiedError struct {
+	postId string
+	reason string
 }
 
-func NewCreatePostService(repository Repository, bus *bus.EventBus) *CreatePostService {
+func (e *UploadNotVerifiedError) Error() string {
+	return fmt.Sprintf("Upload of Post %s can not be confirmed: %s", e.postId, e.reason)
+}
+
+func NewCreatePostService(repository Repository) *CreatePostService {
 	return &CreatePostService{
 		repository: repository,
-		bus:        bus,
 	}
 }
 
 var timeLayout string = "2006-01-02T15:04:05.000000Z"
 
-func (s *CreatePostService) CreatePost(post *Post) (CreatePostResult, error) {
-	chError := make(chan error, 2)
-	chResult := make(chan PresignedUrl, 1)
+func (s *CreatePostService) CreatePost(post *Post, ctx context.Context) (CreatePostResult, error) {
+	ctx, span := tracing.Start(ctx, "CreatePostService.CreatePost")
+	defer span.End()
 
-	post.CreatedAt = time.Now().UTC().Format(timeLayout)
+	createdAt := time.Now().UTC()
+	post.CreatedAt = createdAt.Format(timeLayout)
 	post.LastUpdated = post.CreatedAt
-	postId, err := generatePostId(post)
+	post.Status = database.PostStatusPending
+	post.Version = 1
+	postId, err := generatePostId(createdAt)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("Error generating Post Id")
+		tracing.Fail(span, err)
 		return CreatePostResult{}, err
 	}
 	post.PostId = postId
 
-	go s.savePostMetaData(post, chError)
-	go s.generetePreSignedUrl(post, chResult, chError)
+	// The metadata goes first because a collision changes the post id, which
+	// is part of the object keys the urls are signed for.
+	err = s.repository.AddNewPostMetaData(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error saving Post metadata")
+		tracing.Fail(span, err)
+		return CreatePostResult{}, err
+	}
+	postId = post.PostId
 
-	numberOfTasks := 2
-	for i := 0; i < numberOfTasks; i++ {
-		err := <-chError
+	result, err := s.repository.GetPresignedUrlsForUploading(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error generating Pre-Signed URL")
+		tracing.Fail(span, err)
+		// Nothing can be uploaded for the post, the reaper would remove it later.
+		if rollBackErr := s.rollBackUnconfirmedPost(post, ctx); rollBackErr != nil {
+			log.Warn().Err(rollBackErr).Msgf("Post %s is left for the abandoned posts reaper", postId)
+		}
+		return CreatePostResult{}, err
+	}
+
+	if result.UploadId != objectstorage.NoUploadId {
+		err := s.repository.SaveUploadId(postId, result.UploadId, ctx)
 		if err != nil {
+			log.Error().Stack().Err(err).Msg("Error saving Post upload id")
+			tracing.Fail(span, err)
+			// Without its id in the metadata the reaper could not abort the upload.
+			post.UploadId = result.UploadId
+			if rollBackErr := s.rollBackUnconfirmedPost(post, ctx); rollBackErr != nil {
+				log.Warn().Err(rollBackErr).Msgf("Multipart upload %s of Post %s is left open", result.UploadId, postId)
+			}
 			return CreatePostResult{}, err
 		}
 	}
 
-	result := <-chResult
+	postsCreated.Inc(post.Type)
 	log.Info().Msgf("Post %s was created", post.Title)
 	return CreatePostResult{
 		PostId:       postId,
@@ -112,97 +191,168 @@ func (s *CreatePostService) CreatePost(post *Post) (CreatePostResult, error) {
 	}, nil
 }
 
-func (s *CreatePostService) ConfirmCreatedPost(confirmPostData *ConfirmedCreatedPost) error {
+func (s *CreatePostService) ConfirmCreatedPost(confirmPostData *ConfirmedCreatedPost, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CreatePostService.ConfirmCreatedPost")
+	defer span.End()
+
+	post, err := s.repository.GetPostMetadata(confirmPostData.PostId, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error retrieving Post %s metadata", confirmPostData.PostId)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	if post.User != confirmPostData.User {
+		err = database.NewForbiddenError("Posts", confirmPostData.PostId, confirmPostData.User)
+		log.Error().Stack().Err(err).Msgf("User %s is not the owner of Post %s", confirmPostData.User, confirmPostData.PostId)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	if confirmPostData.Version != nil && *confirmPostData.Version != post.Version {
+		err = database.NewConflictError("Posts", confirmPostData.PostId, fmt.Sprintf("expected version %d, found %d", *confirmPostData.Version, post.Version))
+		log.Error().Stack().Err(err).Msgf("Post %s was modified since version %d", confirmPostData.PostId, *confirmPostData.Version)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	if post.Status != database.PostStatusPending {
+		targetStatus := database.PostStatusPublished
+		if !confirmPostData.IsConfirmed {
+			targetStatus = "removed"
+		}
+		err = &InvalidPostStatusError{postId: confirmPostData.PostId, status: post.Status, targetStatus: targetStatus}
+		log.Error().Stack().Err(err).Msgf("Post %s is not pending", confirmPostData.PostId)
+		tracing.Fail(span, err)
+		return err
+	}
+
 	if !confirmPostData.IsConfirmed {
-		err := s.rollBackUnconfirmedPost(confirmPostData.PostId)
+		err := s.rollBackUnconfirmedPost(post, ctx)
 		if err != nil {
+			tracing.Fail(span, err)
 			return err
 		}
 
 		return nil
 	}
 
-	post, err := s.repository.GetPostMetadata(confirmPostData.PostId)
-	if err != nil {
-		log.Error().Stack().Err(err).Msgf("Error retrieving Post %s metadata", confirmPostData.PostId)
-		return err
-	}
-
 	if confirmPostData.IsMultipart {
 		multipartPost := &MultipartPost{
 			Post:           post,
 			UploadId:       confirmPostData.UploadId,
 			CompletedParts: confirmPostData.CompletedParts,
 		}
-		err := s.repository.CompleteMultipartUpload(multipartPost)
+		err := s.repository.CompleteMultipartUpload(multipartPost, ctx)
 		log.Error().Stack().Err(err).Msgf("Error completing multipart Post %s", confirmPostData.PostId)
 		if err != nil {
+			tracing.Fail(span, err)
 			return err
 		}
 	}
 
-	err = s.publishPostWasCreatedEvent(confirmPostData.PostId, post)
+	err = s.verifyUpload(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error verifying the upload of Post %s", confirmPostData.PostId)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	post.Status = database.PostStatusPublished
+	expectedVersion := post.Version
+	post.Version++
+	event, err := createPostWasCreatedEvent(confirmPostData.PostId, post)
 	if err != nil {
+		tracing.Fail(span, err)
 		return err
 	}
 
+	err = s.repository.PublishPost(post, expectedVersion, event, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error publishing Post %s", confirmPostData.PostId)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	postsConfirmed.Inc(post.Type)
 	log.Info().Msgf("Created Post %s was confirmed", confirmPostData.PostId)
 
 	return nil
 }
 
-func (s *CreatePostService) savePostMetaData(post *Post, chError chan<- error) {
-	err := s.repository.AddNewPostMetaData(post)
+func createPostWasCreatedEvent(postId string, metadata *Post) (*bus.Event, error) {
+	event, err := bus.NewEvent("PostWasCreatedEvent", &PostWasCreatedEvent{
+		PostId:   postId,
+		Metadata: metadata,
+	})
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("Error saving Post metadata")
-		chError <- err
+		log.Error().Stack().Err(err).Msg("Creating PostWasCreatedEvent failed")
+		return nil, err
 	}
 
-	chError <- nil
+	return event, nil
 }
 
-func (s *CreatePostService) generetePreSignedUrl(post *Post, chResult chan PresignedUrl, chError chan<- error) {
-	presignedUrl, err := s.repository.GetPresignedUrlsForUploading(post)
+func (s *CreatePostService) verifyUpload(post *Post, ctx context.Context) error {
+	content, err := s.repository.StatContent(post, ctx)
+	var notFoundError *objectstorage.ObjectNotFoundError
+	if errors.As(err, &notFoundError) {
+		return &UploadNotVerifiedError{postId: post.PostId, reason: "content was not uploaded"}
+	}
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("Error generating Pre-Signed URL")
-		chError <- err
+		return err
 	}
-
-	chError <- nil
-	chResult <- presignedUrl
-}
-
-func (s *CreatePostService) publishPostWasCreatedEvent(postId string, metadata *Post) error {
-	event := &PostWasCreatedEvent{
-		PostId:   postId,
-		Metadata: metadata,
+	if reason := post.verifyContent(content); reason != "" {
+		return &UploadNotVerifiedError{postId: post.PostId, reason: reason}
 	}
 
-	err := s.bus.Publish("PostWasCreatedEvent", event)
+	if !post.HasThumbnail {
+		return nil
+	}
+	thumbnail, err := s.repository.StatThumbnail(post, ctx)
+	if errors.As(err, &notFoundError) {
+		return &UploadNotVerifiedError{postId: post.PostId, reason: "thumbnail was not uploaded"}
+	}
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("Publishing PostWasCreatedEvent failed")
 		return err
 	}
+	if reason := verifyThumbnail(thumbnail); reason != "" {
+		return &UploadNotVerifiedError{postId: post.PostId, reason: reason}
+	}
 
 	return nil
 }
 
-func (s *CreatePostService) rollBackUnconfirmedPost(postId string) error {
-	err := s.repository.RemoveUnconfirmedPost(postId)
+// rollBackUnconfirmedPost removes the metadata last, so a rollback that fails
+// midway leaves the post to be retried or reaped.
+func (s *CreatePostService) rollBackUnconfirmedPost(post *Post, ctx context.Context) error {
+	if post.UploadId != "" && post.UploadId != objectstorage.NoUploadId {
+		err := s.repository.AbortUpload(post, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error aborting upload of Post %s", post.PostId)
+			return err
+		}
+	}
+
+	err := s.repository.DeleteObjects(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error deleting objects of Post %s", post.PostId)
+		return err
+	}
+
+	err = s.repository.RemoveUnconfirmedPost(post.PostId, post.Version, ctx)
 	if err != nil {
 		log.Error().Stack().Err(err).Msg("Error removing Post metadata")
 		return err
 	}
-	log.Info().Msgf("Created Post %s failed", postId)
+	postsRolledBack.Inc()
+	log.Info().Msgf("Created Post %s failed", post.PostId)
 
 	return nil
 }
 
-func generatePostId(post *Post) (string, error) {
-	parsedCreatedAt, err := time.Parse(timeLayout, post.CreatedAt)
-	if err != nil {
-		return "", err
-	}
-	postId := post.User + "-" + post.Title + "-" + strconv.FormatInt(parsedCreatedAt.Unix(), 10)
-	return strings.ReplaceAll(strings.ReplaceAll(postId, " ", "_"), "\t", "_"), nil
+// generatePostId returns a random id that sorts by creation time. Posts
+// created before keep their user-title-seconds ids, nothing parses them.
+func generatePostId(createdAt time.Time) (string, error) {
+	return ulid.New(createdAt)
 }
@@ -2,12 +2,14 @@ package create_post_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"postservice/internal/bus"
-	mock_bus "postservice/internal/bus/mock"
+	database "postservice/internal/db"
 	"postservice/internal/features/create_post"
 	mock_create_post "postservice/internal/features/create_post/mock"
+	objectstorage "postservice/internal/objectStorage"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -17,17 +19,13 @@ import (
 
 var serviceLoggerOutput bytes.Buffer
 var serviceRepository *mock_create_post.MockRepository
-var serviceExternalBus *mock_bus.MockExternalBus
-var serviceBus *bus.EventBus
 var createPostService *create_post.CreatePostService
 
 func setUpService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	serviceRepository = mock_create_post.NewMockRepository(ctrl)
 	log.Logger = log.Output(&serviceLoggerOutput)
-	serviceExternalBus = mock_bus.NewMockExternalBus(ctrl)
-	serviceBus = bus.NewEventBus(serviceExternalBus)
-	createPostService = create_post.NewCreatePostService(serviceRepository, serviceBus)
+	createPostService = create_post.NewCreatePostService(serviceRepository)
 }
 
 func TestCreatePostWithService(t *testing.T) {
@@ -39,12 +37,14 @@ func TestCreatePostWithService(t *testing.T) {
 		Description:  "Este é o meu novo post",
 		HasThumbnail: true,
 	}
-	serviceRepository.EXPECT().AddNewPostMetaData(newPost).Return(nil)
-	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost).Return(create_post.PresignedUrl{"NoUploadId", []string{"https://presigned/url"}, "https://presignedThumbanail/url"}, nil)
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost, gomock.Any()).Return(create_post.PresignedUrl{UploadId: "NoUploadId", ContentPresignedUrls: []string{"https://presigned/url"}, ThumbanilPresignedUrl: "https://presignedThumbanail/url"}, nil)
 
-	result, err := createPostService.CreatePost(newPost)
+	result, err := createPostService.CreatePost(newPost, context.Background())
 
-	assert.Contains(t, result.PostId, "username1-Meu_Post-")
+	assert.Len(t, result.PostId, 26)
+	assert.Equal(t, newPost.PostId, result.PostId)
+	assert.Equal(t, "pending", newPost.Status)
 	assert.Contains(t, result.PresignedUrl.UploadId, "NoUploadId")
 	assert.Equal(t, "https://presigned/url", result.PresignedUrl.ContentPresignedUrls[0])
 	assert.Equal(t, "https://presignedThumbanail/url", result.PresignedUrl.ThumbanilPresignedUrl)
@@ -52,6 +52,91 @@ func TestCreatePostWithService(t *testing.T) {
 	assert.Contains(t, serviceLoggerOutput.String(), "Post Meu Post was created")
 }
 
+func TestCreateMultipartPostWithService(t *testing.T) {
+	setUpService(t)
+	newPost := &create_post.Post{
+		User:  "username1",
+		Type:  "VIDEO",
+		Title: "Meu Post",
+		Size:  500,
+	}
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost, gomock.Any()).Return(create_post.PresignedUrl{UploadId: "upload-id", ContentPresignedUrls: []string{"https://presigned/url1", "https://presigned/url2"}}, nil)
+	serviceRepository.EXPECT().SaveUploadId(gomock.Any(), "upload-id", gomock.Any()).Return(nil)
+
+	result, err := createPostService.CreatePost(newPost, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "upload-id", result.PresignedUrl.UploadId)
+}
+
+func TestErrorOnCreateMultipartPostWithServiceWhenSavingUploadId(t *testing.T) {
+	setUpService(t)
+	newPost := &create_post.Post{
+		User:  "username1",
+		Type:  "VIDEO",
+		Title: "Meu Post",
+		Size:  500,
+	}
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost, gomock.Any()).Return(create_post.PresignedUrl{UploadId: "upload-id", ContentPresignedUrls: []string{"https://presigned/url1"}}, nil)
+	serviceRepository.EXPECT().SaveUploadId(gomock.Any(), "upload-id", gomock.Any()).Return(errors.New("some error"))
+	serviceRepository.EXPECT().AbortUpload(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeleteObjects(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemoveUnconfirmedPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	result, err := createPostService.CreatePost(newPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Empty(t, result.PostId)
+	assert.Equal(t, "upload-id", newPost.UploadId)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error saving Post upload id")
+}
+
+func TestCreatePostWithServiceUsesIdOfSavedMetadata(t *testing.T) {
+	setUpService(t)
+	newPost := &create_post.Post{
+		User:  "username1",
+		Type:  "VIDEO",
+		Title: "Meu Post",
+		Size:  500,
+	}
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).DoAndReturn(func(post *create_post.Post, ctx context.Context) error {
+		post.PostId = "regenerated-post-id"
+		return nil
+	})
+	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost, gomock.Any()).Return(create_post.PresignedUrl{UploadId: "upload-id", ContentPresignedUrls: []string{"https://presigned/url1"}}, nil)
+	serviceRepository.EXPECT().SaveUploadId("regenerated-post-id", "upload-id", gomock.Any()).Return(nil)
+
+	result, err := createPostService.CreatePost(newPost, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "regenerated-post-id", result.PostId)
+}
+
+func TestErrorOnCreatePostWithServiceRemovesMetadataWhenUrlsFail(t *testing.T) {
+	setUpService(t)
+	newPost := &create_post.Post{
+		User:  "username1",
+		Type:  "VIDEO",
+		Title: "Meu Post",
+		Size:  6 << 30,
+	}
+	tooLargeError := &objectstorage.ObjectTooLargeError{Size: 6 << 30, MaxSize: 5 << 30}
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).DoAndReturn(func(post *create_post.Post, ctx context.Context) error {
+		post.Version = 1
+		return nil
+	})
+	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost, gomock.Any()).Return(create_post.PresignedUrl{}, tooLargeError)
+	serviceRepository.EXPECT().DeleteObjects(newPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemoveUnconfirmedPost(gomock.Any(), 1, gomock.Any()).Return(nil)
+
+	result, err := createPostService.CreatePost(newPost, context.Background())
+
+	assert.ErrorIs(t, err, tooLargeError)
+	assert.Empty(t, result.PostId)
+}
+
 func TestErrorOnCreatePostWithService(t *testing.T) {
 	setUpService(t)
 	newPost := &create_post.Post{
@@ -60,10 +145,9 @@ func TestErrorOnCreatePostWithService(t *testing.T) {
 		Type:        "Text",
 		Description: "Este é o meu novo post",
 	}
-	serviceRepository.EXPECT().AddNewPostMetaData(newPost).Return(errors.New("some error"))
-	serviceRepository.EXPECT().GetPresignedUrlsForUploading(newPost)
+	serviceRepository.EXPECT().AddNewPostMetaData(newPost, gomock.Any()).Return(errors.New("some error"))
 
-	result, err := createPostService.CreatePost(newPost)
+	result, err := createPostService.CreatePost(newPost, context.Background())
 
 	assert.Empty(t, result.PostId)
 	assert.Empty(t, result.PresignedUrl.UploadId)
@@ -77,24 +161,32 @@ func TestConfirmCreatedPostWithServiceWhenIsConfirmed(t *testing.T) {
 	setUpService(t)
 	postId := "postId"
 	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      postId,
 	}
 	postMetadata := &create_post.Post{
 		User:        "username1",
 		Title:       "Meu Post",
-		Type:        "Text",
+		Type:        "TEXT",
 		Description: "Este é o meu novo post",
+		Size:        120,
+		Status:      "pending",
+		Version:     1,
 	}
+	publishedPostMetadata := *postMetadata
+	publishedPostMetadata.Status = "published"
+	publishedPostMetadata.Version = 2
 	expectedPostWasCreatedEvent := &create_post.PostWasCreatedEvent{
 		PostId:   postId,
-		Metadata: postMetadata,
+		Metadata: &publishedPostMetadata,
 	}
 	expectedEvent, _ := createEvent("PostWasCreatedEvent", expectedPostWasCreatedEvent)
-	serviceRepository.EXPECT().GetPostMetadata(postId).Return(postMetadata, nil)
-	serviceExternalBus.EXPECT().Publish(expectedEvent)
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(postMetadata, nil)
+	serviceRepository.EXPECT().StatContent(postMetadata, gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 120, ContentType: "text/plain"}, nil)
+	serviceRepository.EXPECT().PublishPost(&publishedPostMetadata, 1, expectedEvent, gomock.Any()).Return(nil)
 
-	err := createPostService.ConfirmCreatedPost(confirmedPost)
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
 
 	assert.Nil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Created Post postId was confirmed")
@@ -104,48 +196,23 @@ func TestErrorOnConfirmCreatedPostWithServiceWhenIsConfirmed(t *testing.T) {
 	setUpService(t)
 	postId := "postId"
 	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      postId,
 	}
-	serviceRepository.EXPECT().GetPostMetadata(postId).Return(nil, errors.New("some error"))
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(nil, errors.New("some error"))
 
-	err := createPostService.ConfirmCreatedPost(notConfirmedPost)
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
 
 	assert.NotNil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Error retrieving Post postId metadata")
 }
 
-func TestErrorOnConfirmCreatedPostWithServiceWhenSendingEvent(t *testing.T) {
-	setUpService(t)
-	postId := "postId"
-	notConfirmedPost := &create_post.ConfirmedCreatedPost{
-		IsConfirmed: true,
-		PostId:      postId,
-	}
-	postMetadata := &create_post.Post{
-		User:        "username1",
-		Title:       "Meu Post",
-		Type:        "Text",
-		Description: "Este é o meu novo post",
-	}
-	expectedPostWasCreatedEvent := &create_post.PostWasCreatedEvent{
-		PostId:   postId,
-		Metadata: postMetadata,
-	}
-	expectedEvent, _ := createEvent("PostWasCreatedEvent", expectedPostWasCreatedEvent)
-	serviceRepository.EXPECT().GetPostMetadata(postId).Return(postMetadata, nil)
-	serviceExternalBus.EXPECT().Publish(expectedEvent).Return(errors.New("some error"))
-
-	err := createPostService.ConfirmCreatedPost(notConfirmedPost)
-
-	assert.NotNil(t, err)
-	assert.Contains(t, serviceLoggerOutput.String(), "Publishing PostWasCreatedEvent failed")
-}
-
 func TestConfirmCreatedPostWithServiceWhenIsConfirmedAndIsMultipart(t *testing.T) {
 	setUpService(t)
 	postId := "postId"
 	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      postId,
 		IsMultipart: true,
@@ -164,24 +231,31 @@ func TestConfirmCreatedPostWithServiceWhenIsConfirmedAndIsMultipart(t *testing.T
 	postMetadata := &create_post.Post{
 		User:        "username1",
 		Title:       "Meu Post",
-		Type:        "Text",
+		Type:        "TEXT",
 		Description: "Este é o meu novo post",
+		Size:        120,
+		Status:      "pending",
+		Version:     1,
 	}
 	expectedMultipartPost := &create_post.MultipartPost{
 		Post:           postMetadata,
 		UploadId:       confirmedPost.UploadId,
 		CompletedParts: confirmedPost.CompletedParts,
 	}
+	publishedPostMetadata := *postMetadata
+	publishedPostMetadata.Status = "published"
+	publishedPostMetadata.Version = 2
 	expectedPostWasCreatedEvent := &create_post.PostWasCreatedEvent{
 		PostId:   postId,
-		Metadata: postMetadata,
+		Metadata: &publishedPostMetadata,
 	}
 	expectedEvent, _ := createEvent("PostWasCreatedEvent", expectedPostWasCreatedEvent)
-	serviceRepository.EXPECT().GetPostMetadata(postId).Return(postMetadata, nil)
-	serviceRepository.EXPECT().CompleteMultipartUpload(expectedMultipartPost).Return(nil)
-	serviceExternalBus.EXPECT().Publish(expectedEvent)
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(postMetadata, nil)
+	serviceRepository.EXPECT().CompleteMultipartUpload(expectedMultipartPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().StatContent(postMetadata, gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 120, ContentType: "text/plain"}, nil)
+	serviceRepository.EXPECT().PublishPost(&publishedPostMetadata, 1, expectedEvent, gomock.Any()).Return(nil)
 
-	err := createPostService.ConfirmCreatedPost(confirmedPost)
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
 
 	assert.Nil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Created Post postId was confirmed")
@@ -191,6 +265,7 @@ func TestErrorOnConfirmCreatedPostWithServiceWhenCompleteMultipartUpload(t *test
 	setUpService(t)
 	postId := "postId"
 	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: true,
 		PostId:      postId,
 		IsMultipart: true,
@@ -211,16 +286,17 @@ func TestErrorOnConfirmCreatedPostWithServiceWhenCompleteMultipartUpload(t *test
 		Title:       "Meu Post",
 		Type:        "Text",
 		Description: "Este é o meu novo post",
+		Status:      "pending",
 	}
 	expectedMultipartPost := &create_post.MultipartPost{
 		Post:           postMetadata,
 		UploadId:       confirmedPost.UploadId,
 		CompletedParts: confirmedPost.CompletedParts,
 	}
-	serviceRepository.EXPECT().GetPostMetadata(postId).Return(postMetadata, nil)
-	serviceRepository.EXPECT().CompleteMultipartUpload(expectedMultipartPost).Return(errors.New("some error"))
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(postMetadata, nil)
+	serviceRepository.EXPECT().CompleteMultipartUpload(expectedMultipartPost, gomock.Any()).Return(errors.New("some error"))
 
-	err := createPostService.ConfirmCreatedPost(confirmedPost)
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
 
 	assert.NotNil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Error completing multipart Post")
@@ -229,31 +305,242 @@ func TestErrorOnConfirmCreatedPostWithServiceWhenCompleteMultipartUpload(t *test
 func TestConfirmCreatedPostWithServiceWhenIsNotConfirmed(t *testing.T) {
 	setUpService(t)
 	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: false,
 		PostId:      "postId",
 	}
-	serviceRepository.EXPECT().RemoveUnconfirmedPost(notConfirmedPost.PostId).Return(nil)
+	post := &create_post.Post{PostId: "postId", User: "username1", Status: "pending", Version: 1, UploadId: objectstorage.NoUploadId}
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(post, nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemoveUnconfirmedPost(notConfirmedPost.PostId, 1, gomock.Any()).Return(nil)
 
-	err := createPostService.ConfirmCreatedPost(notConfirmedPost)
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
 
 	assert.Nil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Created Post postId failed")
 }
 
+func TestConfirmCreatedPostWithServiceWhenMultipartIsNotConfirmed(t *testing.T) {
+	setUpService(t)
+	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: false,
+		PostId:      "postId",
+	}
+	post := &create_post.Post{PostId: "postId", User: "username1", Status: "pending", Version: 1, UploadId: "upload-id"}
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(post, nil)
+	gomock.InOrder(
+		serviceRepository.EXPECT().AbortUpload(post, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().RemoveUnconfirmedPost(notConfirmedPost.PostId, 1, gomock.Any()).Return(nil),
+	)
+
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenAbortingUploadFails(t *testing.T) {
+	setUpService(t)
+	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: false,
+		PostId:      "postId",
+	}
+	post := &create_post.Post{PostId: "postId", User: "username1", Status: "pending", Version: 1, UploadId: "upload-id"}
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(post, nil)
+	serviceRepository.EXPECT().AbortUpload(post, gomock.Any()).Return(errors.New("some error"))
+
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error aborting upload of Post postId")
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenDeletingObjectsFails(t *testing.T) {
+	setUpService(t)
+	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: false,
+		PostId:      "postId",
+	}
+	post := &create_post.Post{PostId: "postId", User: "username1", Status: "pending", Version: 1}
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(post, nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(errors.New("some error"))
+
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error deleting objects of Post postId")
+}
+
 func TestErrorOnConfirmCreatedPostWithServiceWhenIsNotConfirmed(t *testing.T) {
 	setUpService(t)
 	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
 		IsConfirmed: false,
 		PostId:      "postId",
 	}
-	serviceRepository.EXPECT().RemoveUnconfirmedPost(notConfirmedPost.PostId).Return(errors.New("some error"))
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(&create_post.Post{PostId: "postId", User: "username1", Status: "pending", Version: 1}, nil)
+	serviceRepository.EXPECT().DeleteObjects(gomock.Any(), gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemoveUnconfirmedPost(notConfirmedPost.PostId, 1, gomock.Any()).Return(errors.New("some error"))
 
-	err := createPostService.ConfirmCreatedPost(notConfirmedPost)
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
 
 	assert.NotNil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "Error removing Post metadata")
 }
 
+func TestErrorOnConfirmCreatedPostWithServiceWhenUserIsNotTheOwner(t *testing.T) {
+	setUpService(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username2",
+		IsConfirmed: false,
+		PostId:      "postId",
+	}
+	serviceRepository.EXPECT().GetPostMetadata(confirmedPost.PostId, gomock.Any()).Return(&create_post.Post{User: "username1"}, nil)
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	var forbiddenError *database.ForbiddenError
+	assert.ErrorAs(t, err, &forbiddenError)
+	assert.Contains(t, serviceLoggerOutput.String(), "User username2 is not the owner of Post postId")
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenPostIsAlreadyPublished(t *testing.T) {
+	setUpService(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	serviceRepository.EXPECT().GetPostMetadata(confirmedPost.PostId, gomock.Any()).Return(&create_post.Post{User: "username1", Status: "published"}, nil)
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	var invalidPostStatusError *create_post.InvalidPostStatusError
+	assert.ErrorAs(t, err, &invalidPostStatusError)
+	assert.Contains(t, serviceLoggerOutput.String(), "Post postId is not pending")
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenRollingBackPublishedPost(t *testing.T) {
+	setUpService(t)
+	notConfirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: false,
+		PostId:      "postId",
+	}
+	serviceRepository.EXPECT().GetPostMetadata(notConfirmedPost.PostId, gomock.Any()).Return(&create_post.Post{User: "username1", Status: "published"}, nil)
+
+	err := createPostService.ConfirmCreatedPost(notConfirmedPost, context.Background())
+
+	var invalidPostStatusError *create_post.InvalidPostStatusError
+	assert.ErrorAs(t, err, &invalidPostStatusError)
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenPublishingPost(t *testing.T) {
+	setUpService(t)
+	postId := "postId"
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		IsConfirmed: true,
+		PostId:      postId,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&create_post.Post{User: "username1", Type: "TEXT", Size: 120, Status: "pending", Version: 1}, nil)
+	serviceRepository.EXPECT().StatContent(gomock.Any(), gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 120, ContentType: "text/plain"}, nil)
+	serviceRepository.EXPECT().PublishPost(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error publishing Post postId")
+}
+
+func TestConfirmCreatedPostWithServiceAcceptsSizeWithinTolerance(t *testing.T) {
+	setUpService(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{User: "username1", IsConfirmed: true, PostId: "postId"}
+	post := &create_post.Post{PostId: "postId", User: "username1", Type: "IMAGE", Size: 1000, HasThumbnail: true, Status: "pending", Version: 1}
+	serviceRepository.EXPECT().GetPostMetadata("postId", gomock.Any()).Return(post, nil)
+	serviceRepository.EXPECT().StatContent(post, gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 1010, ContentType: "image/png"}, nil)
+	serviceRepository.EXPECT().StatThumbnail(post, gomock.Any()).Return(&objectstorage.ObjectInfo{Size: 50, ContentType: "image/jpeg"}, nil)
+	serviceRepository.EXPECT().PublishPost(gomock.Any(), 1, gomock.Any(), gomock.Any()).Return(nil)
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenUploadIsNotVerified(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        *objectstorage.ObjectInfo
+		contentErr     error
+		thumbnail      *objectstorage.ObjectInfo
+		thumbnailErr   error
+		expectedReason string
+	}{
+		{
+			name:           "content not uploaded",
+			contentErr:     &objectstorage.ObjectNotFoundError{Key: "username1/IMAGE/postId"},
+			expectedReason: "content was not uploaded",
+		},
+		{
+			name:           "content size differs",
+			content:        &objectstorage.ObjectInfo{Size: 1011, ContentType: "image/png"},
+			expectedReason: "content has 1011 bytes but 1000 were declared",
+		},
+		{
+			name:           "content type not allowed",
+			content:        &objectstorage.ObjectInfo{Size: 1000, ContentType: "text/html"},
+			expectedReason: `content type "text/html" is not allowed for IMAGE posts`,
+		},
+		{
+			name:           "thumbnail not uploaded",
+			content:        &objectstorage.ObjectInfo{Size: 1000, ContentType: "image/png"},
+			thumbnailErr:   &objectstorage.ObjectNotFoundError{Key: "username1/IMAGE/THUMBNAILS/postId"},
+			expectedReason: "thumbnail was not uploaded",
+		},
+		{
+			name:           "thumbnail type not allowed",
+			content:        &objectstorage.ObjectInfo{Size: 1000, ContentType: "image/png"},
+			thumbnail:      &objectstorage.ObjectInfo{Size: 50, ContentType: "video/mp4"},
+			expectedReason: `thumbnail content type "video/mp4" is not allowed`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setUpService(t)
+			confirmedPost := &create_post.ConfirmedCreatedPost{User: "username1", IsConfirmed: true, PostId: "postId"}
+			post := &create_post.Post{PostId: "postId", User: "username1", Type: "IMAGE", Size: 1000, HasThumbnail: true, Status: "pending", Version: 1}
+			serviceRepository.EXPECT().GetPostMetadata("postId", gomock.Any()).Return(post, nil)
+			serviceRepository.EXPECT().StatContent(post, gomock.Any()).Return(test.content, test.contentErr)
+			if test.thumbnail != nil || test.thumbnailErr != nil {
+				serviceRepository.EXPECT().StatThumbnail(post, gomock.Any()).Return(test.thumbnail, test.thumbnailErr)
+			}
+
+			err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+			var uploadNotVerifiedError *create_post.UploadNotVerifiedError
+			assert.ErrorAs(t, err, &uploadNotVerifiedError)
+			assert.EqualError(t, err, "Upload of Post postId can not be confirmed: "+test.expectedReason)
+		})
+	}
+}
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenStatFails(t *testing.T) {
+	setUpService(t)
+	confirmedPost := &create_post.ConfirmedCreatedPost{User: "username1", IsConfirmed: true, PostId: "postId"}
+	post := &create_post.Post{PostId: "postId", User: "username1", Type: "TEXT", Size: 10, Status: "pending", Version: 1}
+	expectedError := errors.New("some error")
+	serviceRepository.EXPECT().GetPostMetadata("postId", gomock.Any()).Return(post, nil)
+	serviceRepository.EXPECT().StatContent(post, gomock.Any()).Return(nil, expectedError)
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	assert.ErrorIs(t, err, expectedError)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error verifying the upload of Post postId")
+}
+
 func createEvent(eventName string, eventData any) (*bus.Event, error) {
 	dataEvent, err := serialize(eventData)
 	if err != nil {
@@ -269,3 +556,21 @@ func createEvent(eventName string, eventData any) (*bus.Event, error) {
 func serialize(data any) ([]byte, error) {
 	return json.Marshal(data)
 }
+
+func TestErrorOnConfirmCreatedPostWithServiceWhenVersionDoesNotMatch(t *testing.T) {
+	setUpService(t)
+	version := 1
+	confirmedPost := &create_post.ConfirmedCreatedPost{
+		User:        "username1",
+		Version:     &version,
+		IsConfirmed: true,
+		PostId:      "postId",
+	}
+	serviceRepository.EXPECT().GetPostMetadata(confirmedPost.PostId, gomock.Any()).Return(&create_post.Post{User: "username1", Status: "pending", Version: 2}, nil)
+
+	err := createPostService.ConfirmCreatedPost(confirmedPost, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	assert.Contains(t, serviceLoggerOutput.String(), "Post postId was modified since version 1")
+}
@@ -0,0 +1,96 @@
+package create_post
+
+import (
+	"fmt"
+	"postservice/internal/api"
+	objectstorage "postservice/internal/objectStorage"
+	"regexp"
+	"slices"
+)
+
+const (
+	PostTypeText  = "TEXT"
+	PostTypeImage = "IMAGE"
+	PostTypeVideo = "VIDEO"
+)
+
+// maxSizeByType also defines the allowed post types.
+var maxSizeByType = map[string]int64{
+	PostTypeText:  1 << 20,
+	PostTypeImage: 20 << 20,
+	PostTypeVideo: 5 << 30,
+}
+
+// contentTypesByType are the media types the uploaded content of each post
+// type can have, and thumbnailContentTypes the ones of the thumbnails. Parts
+// of multipart uploads carry no content type, so the content of those is
+// sniffed: every post type allows a media type that
+// objectstorage.SniffContentType detects for its content, as markdown is
+// detected as text/plain.
+var contentTypesByType = map[string][]string{
+	PostTypeText:  {"text/plain", "text/markdown"},
+	PostTypeImage: {"image/jpeg", "image/png", "image/gif", "image/webp"},
+	PostTypeVideo: {"video/mp4", "video/webm"},
+}
+
+var thumbnailContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// uploadSizeTolerance is the fraction of the declared size that the uploaded
+// content can differ by.
+const uploadSizeTolerance = 0.01
+
+// The username ends up in the object keys, so it can't contain separators or
+// characters that need escaping.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Validate returns an error for every field of the post that can't be stored.
+func (post *Post) Validate() []api.FieldError {
+	fieldErrors := []api.FieldError{}
+	addError := func(field, message string, args ...any) {
+		fieldErrors = append(fieldErrors, api.FieldError{Field: field, Message: fmt.Sprintf(message, args...)})
+	}
+
+	if !usernamePattern.MatchString(post.User) {
+		addError("username", "has to have between 1 and 64 letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+
+	maxSize, knownType := maxSizeByType[post.Type]
+	if !knownType {
+		addError("type", "has to be one of %s, %s or %s", PostTypeText, PostTypeImage, PostTypeVideo)
+	}
+
+	fieldErrors = append(fieldErrors, api.ValidatePostText(&post.Title, &post.Description)...)
+
+	if post.Size <= 0 {
+		addError("size", "has to be positive")
+	} else if knownType && int64(post.Size) > maxSize {
+		addError("size", "can be at most %d bytes for %s posts", maxSize, post.Type)
+	}
+
+	return fieldErrors
+}
+
+// verifyContent returns why the uploaded content of the post can't be
+// published, or an empty string when it can.
+func (post *Post) verifyContent(content *objectstorage.ObjectInfo) string {
+	tolerance := int(float64(post.Size) * uploadSizeTolerance)
+	if content.Size < post.Size-tolerance || content.Size > post.Size+tolerance {
+		return fmt.Sprintf("content has %d bytes but %d were declared", content.Size, post.Size)
+	}
+	if maxSize, knownType := maxSizeByType[post.Type]; knownType && int64(content.Size) > maxSize {
+		return fmt.Sprintf("content can be at most %d bytes for %s posts", maxSize, post.Type)
+	}
+	if !slices.Contains(contentTypesByType[post.Type], content.ContentType) {
+		return fmt.Sprintf("content type %q is not allowed for %s posts", content.ContentType, post.Type)
+	}
+
+	return ""
+}
+
+func verifyThumbnail(thumbnail *objectstorage.ObjectInfo) string {
+	if !slices.Contains(thumbnailContentTypes, thumbnail.ContentType) {
+		return fmt.Sprintf("thumbnail content type %q is not allowed", thumbnail.ContentType)
+	}
+
+	return ""
+}
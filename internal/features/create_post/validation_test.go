@@ -0,0 +1,81 @@
+package create_post_test
+
+import (
+	"postservice/internal/api"
+	"postservice/internal/features/create_post"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validPost() *create_post.Post {
+	return &create_post.Post{
+		User:        "username1",
+		Type:        create_post.PostTypeImage,
+		Title:       "Meu Post, é o primeiro!",
+		Description: "Este é o meu novo post",
+		Size:        120,
+	}
+}
+
+func TestValidatePost(t *testing.T) {
+	testCases := map[string]struct {
+		change         func(post *create_post.Post)
+		expectedErrors []api.FieldError
+	}{
+		"valid post": {
+			change:         func(post *create_post.Post) {},
+			expectedErrors: []api.FieldError{},
+		},
+		"missing username": {
+			change:         func(post *create_post.Post) { post.User = "" },
+			expectedErrors: []api.FieldError{{Field: "username", Message: "has to have between 1 and 64 letters, digits, '.', '_' or '-', starting with a letter or digit"}},
+		},
+		"username escaping key": {
+			change:         func(post *create_post.Post) { post.User = "../username1" },
+			expectedErrors: []api.FieldError{{Field: "username", Message: "has to have between 1 and 64 letters, digits, '.', '_' or '-', starting with a letter or digit"}},
+		},
+		"missing type": {
+			change:         func(post *create_post.Post) { post.Type = "" },
+			expectedErrors: []api.FieldError{{Field: "type", Message: "has to be one of TEXT, IMAGE or VIDEO"}},
+		},
+		"blank title": {
+			change:         func(post *create_post.Post) { post.Title = "  " },
+			expectedErrors: []api.FieldError{{Field: "title", Message: "is required"}},
+		},
+		"long title": {
+			change:         func(post *create_post.Post) { post.Title = strings.Repeat("é", 101) },
+			expectedErrors: []api.FieldError{{Field: "title", Message: "can have at most 100 characters"}},
+		},
+		"title with control character": {
+			change:         func(post *create_post.Post) { post.Title = "Meu\nPost" },
+			expectedErrors: []api.FieldError{{Field: "title", Message: "can't contain '/', '\\' or control characters"}},
+		},
+		"long description": {
+			change:         func(post *create_post.Post) { post.Description = strings.Repeat("a", 10<<20) },
+			expectedErrors: []api.FieldError{{Field: "description", Message: "can have at most 2000 characters"}},
+		},
+		"size over the limit of its type": {
+			change:         func(post *create_post.Post) { post.Type, post.Size = create_post.PostTypeText, 2<<20 },
+			expectedErrors: []api.FieldError{{Field: "size", Message: "can be at most 1048576 bytes for TEXT posts"}},
+		},
+		"video over the limit of images": {
+			change:         func(post *create_post.Post) { post.Type, post.Size = create_post.PostTypeVideo, 1<<30 },
+			expectedErrors: []api.FieldError{},
+		},
+		"zero size": {
+			change:         func(post *create_post.Post) { post.Size = 0 },
+			expectedErrors: []api.FieldError{{Field: "size", Message: "has to be positive"}},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			post := validPost()
+			testCase.change(post)
+
+			assert.Equal(t, testCase.expectedErrors, post.Validate())
+		})
+	}
+}
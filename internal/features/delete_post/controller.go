@@ -4,20 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"postservice/internal/api"
-	"postservice/internal/bus"
 	database "postservice/internal/db"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+// MaxPostIdsPerRequest keeps the removals and their event in a single
+// transaction, which DynamoDB limits to 100 items.
+const MaxPostIdsPerRequest = 99
+
 type DeletePostController struct {
 	service *DeletePostService
 }
 
-func NewDeletePostController(repository Repository, bus *bus.EventBus) *DeletePostController {
+func NewDeletePostController(repository Repository) *DeletePostController {
 	return &DeletePostController{
-		service: NewDeletePostService(repository, bus),
+		service: NewDeletePostService(repository),
 	}
 }
 
@@ -27,22 +30,46 @@ func (controller *DeletePostController) Routes(routerGroup *gin.RouterGroup) {
 
 func (controller *DeletePostController) DeletePosts(c *gin.Context) {
 	log.Info().Msg("Handling Request Delete Posts")
-	username := c.Param("username")
+	username := api.GetUsername(c)
+	if c.Param("username") != username {
+		message := fmt.Sprintf("User %s is not allowed to delete posts of user %s", username, c.Param("username"))
+		api.SendError(c, api.NewForbiddenError(message, nil))
+		return
+	}
 	postIds := c.QueryArray("postId")
 	if len(postIds) == 0 {
-		api.SendBadRequest(c, "Missing postId parameters")
+		api.SendError(c, api.NewValidationError("Missing postId parameters"))
+		return
+	}
+	if len(postIds) > MaxPostIdsPerRequest {
+		api.SendError(c, api.NewValidationError(fmt.Sprintf("At most %d postId parameters can be deleted at once", MaxPostIdsPerRequest)))
+		return
+	}
+	version, err := api.IfMatchVersion(c)
+	if err != nil {
+		api.SendError(c, err)
+		return
+	}
+	if version != nil && len(postIds) != 1 {
+		api.SendError(c, api.NewValidationError("If-Match needs a single postId"))
 		return
 	}
 
-	err := controller.service.DeletePosts(username, postIds)
+	err = controller.service.DeletePosts(username, postIds, version, c.Request.Context())
 	if err != nil {
 		var notFoundError *database.NotFoundError
+		var forbiddenError *database.ForbiddenError
+		var conflictError *database.ConflictError
 		if errors.As(err, &notFoundError) {
-			message := fmt.Sprintf("Some posts were not found for post ids %v", postIds)
-			api.SendNotFound(c, message)
-		} else {
-			api.SendInternalServerError(c, err.Error())
+			err = api.NewNotFoundError(fmt.Sprintf("Some posts were not found for post ids %v", postIds), err)
+		} else if errors.As(err, &forbiddenError) {
+			err = api.NewForbiddenError(fmt.Sprintf("Some posts do not belong to user %s for post ids %v", username, postIds), err)
+		} else if errors.As(err, &conflictError) && version != nil {
+			err = api.NewPreconditionFailedError(fmt.Sprintf("Post %s does not have the version of If-Match", postIds[0]), err)
+		} else if errors.As(err, &conflictError) {
+			err = api.NewConflictError(fmt.Sprintf("Some posts were modified concurrently for post ids %v, try again", postIds), err)
 		}
+		api.SendError(c, err)
 		return
 	}
 
@@ -6,8 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"postservice/internal/bus"
-	mock_bus "postservice/internal/bus/mock"
+	"postservice/internal/api"
 	database "postservice/internal/db"
 	"postservice/internal/features/delete_post"
 	mock_delete_post "postservice/internal/features/delete_post/mock"
@@ -22,8 +21,6 @@ import (
 
 var controllerLoggerOutput bytes.Buffer
 var controllerRepository *mock_delete_post.MockRepository
-var controllerExternalBus *mock_bus.MockExternalBus
-var controllerBus *bus.EventBus
 var controller *delete_post.DeletePostController
 var apiResponse *httptest.ResponseRecorder
 var ginContext *gin.Context
@@ -32,12 +29,11 @@ func setUpHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	controllerRepository = mock_delete_post.NewMockRepository(ctrl)
 	log.Logger = log.Output(&controllerLoggerOutput)
-	controllerExternalBus = mock_bus.NewMockExternalBus(ctrl)
-	controllerBus = bus.NewEventBus(controllerExternalBus)
-	controller = delete_post.NewDeletePostController(controllerRepository, controllerBus)
+	controller = delete_post.NewDeletePostController(controllerRepository)
 	gin.SetMode(gin.TestMode)
 	apiResponse = httptest.NewRecorder()
 	ginContext, _ = gin.CreateTestContext(apiResponse)
+	api.SetIdentity(ginContext, &api.Identity{Username: "username1"})
 }
 
 func TestDeletePosts(t *testing.T) {
@@ -46,13 +42,19 @@ func TestDeletePosts(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1&postId=2&postId=3", nil)
 	ginContext.Params = []gin.Param{{Key: "username", Value: username}}
 	ginContext.Request = req
-	controllerRepository.EXPECT().DeletePosts([]string{"1", "2", "3"}).Return(nil)
 	expectedPostsWereDeletedEvent := &delete_post.PostsWereDeletedEvent{
 		Username: username,
 		PostIds:  []string{"1", "2", "3"},
 	}
 	expectedEvent := createEvent("PostsWereDeletedEvent", expectedPostsWereDeletedEvent)
-	controllerExternalBus.EXPECT().Publish(expectedEvent).Return(nil)
+	posts := []*delete_post.Post{
+		{PostId: "1", User: username, Status: database.PostStatusPublished},
+		{PostId: "2", User: username, Status: database.PostStatusPublished},
+		{PostId: "3", User: username, Status: database.PostStatusPublished},
+	}
+	controllerRepository.EXPECT().GetPostsToDelete(username, []string{"1", "2", "3"}, nil, gomock.Any()).Return(posts, nil)
+	controllerRepository.EXPECT().MarkPostsDeleted(posts, expectedEvent, gomock.Any()).Return(nil)
+	controllerRepository.EXPECT().DeletePostContent(gomock.Any(), gomock.Any()).Return(nil).Times(3)
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -65,12 +67,32 @@ func TestDeletePosts(t *testing.T) {
 	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
 }
 
+func TestDeletePosts_OtherUser(t *testing.T) {
+	setUpHandler(t)
+	req, _ := http.NewRequest("DELETE", "/posts/username2?postId=1", nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username2"}}
+	ginContext.Request = req
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "FORBIDDEN",
+		"message": "User username1 is not allowed to delete posts of user username2",
+		"content": null
+	}`
+
+	controller.DeletePosts(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 403)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
 func TestDeletePosts_MissingPostID(t *testing.T) {
 	setUpHandler(t)
 	req, _ := http.NewRequest("DELETE", "/posts/username1", nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
 	ginContext.Request = req
 	expectedBodyResponse := `{
 		"error": true,
+		"code": "VALIDATION_FAILED",
 		"message": "Missing postId parameters",
 		"content": null
 	}`
@@ -81,13 +103,74 @@ func TestDeletePosts_MissingPostID(t *testing.T) {
 	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
 }
 
+func TestDeletePosts_TooManyPostIds(t *testing.T) {
+	setUpHandler(t)
+	query := strings.Repeat("postId=1&", delete_post.MaxPostIdsPerRequest) + "postId=2"
+	req, _ := http.NewRequest("DELETE", "/posts/username1?"+query, nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
+	ginContext.Request = req
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "At most 99 postId parameters can be deleted at once",
+		"content": null
+	}`
+
+	controller.DeletePosts(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestDeletePosts_IfMatchWithSeveralPostIds(t *testing.T) {
+	setUpHandler(t)
+	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1&postId=2", nil)
+	req.Header.Set("If-Match", `"2"`)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
+	ginContext.Request = req
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "If-Match needs a single postId",
+		"content": null
+	}`
+
+	controller.DeletePosts(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestDeletePosts_IfMatchDoesNotMatch(t *testing.T) {
+	setUpHandler(t)
+	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1", nil)
+	req.Header.Set("If-Match", `"2"`)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
+	ginContext.Request = req
+	expectedVersion := 2
+	controllerRepository.EXPECT().GetPostsToDelete("username1", []string{"1"}, &expectedVersion, gomock.Any()).Return(nil, database.NewConflictError("Posts", "1", "expected version 2, found 3"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "PRECONDITION_FAILED",
+		"message": "Post 1 does not have the version of If-Match",
+		"content": null
+	}`
+
+	controller.DeletePosts(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 412)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
 func TestDeletePosts_NotFound(t *testing.T) {
 	setUpHandler(t)
 	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1&postId=2&postId=3", nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
 	ginContext.Request = req
-	controllerRepository.EXPECT().DeletePosts([]string{"1", "2", "3"}).Return(database.NewNotFoundError("", "2"))
+	controllerRepository.EXPECT().GetPostsToDelete("username1", []string{"1", "2", "3"}, nil, gomock.Any()).Return(nil, database.NewNotFoundError("Posts", []string{"2"}))
 	expectedBodyResponse := `{
 		"error": true,
+		"code": "NOT_FOUND",
 		"message": "` + fmt.Sprintf("Some posts were not found for post ids %v", []string{"1", "2", "3"}) + `",
 		"content": null
 	}`
@@ -98,14 +181,35 @@ func TestDeletePosts_NotFound(t *testing.T) {
 	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
 }
 
+func TestDeletePosts_Forbidden(t *testing.T) {
+	setUpHandler(t)
+	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1&postId=2", nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
+	ginContext.Request = req
+	controllerRepository.EXPECT().GetPostsToDelete("username1", []string{"1", "2"}, nil, gomock.Any()).Return(nil, database.NewForbiddenError("Posts", []string{"2"}, "username1"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "FORBIDDEN",
+		"message": "` + fmt.Sprintf("Some posts do not belong to user username1 for post ids %v", []string{"1", "2"}) + `",
+		"content": null
+	}`
+
+	controller.DeletePosts(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 403)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
 func TestDeletePosts_InternalServerError(t *testing.T) {
 	setUpHandler(t)
 	req, _ := http.NewRequest("DELETE", "/posts/username1?postId=1&postId=2&postId=3", nil)
+	ginContext.Params = []gin.Param{{Key: "username", Value: "username1"}}
 	ginContext.Request = req
-	controllerRepository.EXPECT().DeletePosts([]string{"1", "2", "3"}).Return(errors.New("Some error"))
+	controllerRepository.EXPECT().GetPostsToDelete("username1", []string{"1", "2", "3"}, nil, gomock.Any()).Return(nil, errors.New("Some error"))
 	expectedBodyResponse := `{
 		"error": true,
-		"message": "Some error",
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
 		"content": null
 	}`
 
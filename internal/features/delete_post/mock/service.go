@@ -5,6 +5,9 @@
 package mock_delete_post
 
 import (
+	context "context"
+	bus "postservice/internal/bus"
+	delete_post "postservice/internal/features/delete_post"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -33,16 +36,45 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// DeletePosts mocks base method.
-func (m *MockRepository) DeletePosts(postIds []string) error {
+// DeletePostContent mocks base method.
+func (m *MockRepository) DeletePostContent(post *delete_post.Post, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePosts", postIds)
+	ret := m.ctrl.Call(m, "DeletePostContent", post, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeletePosts indicates an expected call of DeletePosts.
-func (mr *MockRepositoryMockRecorder) DeletePosts(postIds interface{}) *gomock.Call {
+// DeletePostContent indicates an expected call of DeletePostContent.
+func (mr *MockRepositoryMockRecorder) DeletePostContent(post, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePosts", reflect.TypeOf((*MockRepository)(nil).DeletePosts), postIds)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostContent", reflect.TypeOf((*MockRepository)(nil).DeletePostContent), post, ctx)
+}
+
+// GetPostsToDelete mocks base method.
+func (m *MockRepository) GetPostsToDelete(username string, postIds []string, expectedVersion *int, ctx context.Context) ([]*delete_post.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsToDelete", username, postIds, expectedVersion, ctx)
+	ret0, _ := ret[0].([]*delete_post.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsToDelete indicates an expected call of GetPostsToDelete.
+func (mr *MockRepositoryMockRecorder) GetPostsToDelete(username, postIds, expectedVersion, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsToDelete", reflect.TypeOf((*MockRepository)(nil).GetPostsToDelete), username, postIds, expectedVersion, ctx)
+}
+
+// MarkPostsDeleted mocks base method.
+func (m *MockRepository) MarkPostsDeleted(posts []*delete_post.Post, event *bus.Event, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPostsDeleted", posts, event, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPostsDeleted indicates an expected call of MarkPostsDeleted.
+func (mr *MockRepositoryMockRecorder) MarkPostsDeleted(posts, event, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPostsDeleted", reflect.TypeOf((*MockRepository)(nil).MarkPostsDeleted), posts, event, ctx)
 }
@@ -1,8 +1,12 @@
 package delete_post
 
 import (
+	"context"
+	"fmt"
+	"postservice/internal/bus"
 	database "postservice/internal/db"
 	objectstorage "postservice/internal/objectStorage"
+	"postservice/internal/tracing"
 
 	"github.com/rs/zerolog/log"
 )
@@ -19,45 +23,171 @@ func NewDeletePostRepository(dataRepository *database.Database, objectRepository
 	}
 }
 
-func (r *DeletePostRepository) DeletePosts(postIds []string) error {
-	posts, err := r.dataRepository.Client.GetPostsByIds(postIds)
+func (r *DeletePostRepository) GetPostsToDelete(username string, postIds []string, expectedVersion *int, ctx context.Context) ([]*Post, error) {
+	ctx, span := tracing.Start(ctx, "DeletePostRepository.GetPostsToDelete")
+	defer span.End()
+
+	data, err := r.dataRepository.Client.GetPostsByIds(postIds, ctx)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Error getting post metadatas for postIds %v", postIds)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	posts := make([]*Post, 0, len(data))
+	for _, post := range data {
+		// A post already being deleted is gone for its owner.
+		if post.Status == database.PostStatusDeleting {
+			continue
+		}
+		posts = append(posts, &Post{
+			PostId:   post.PostId,
+			User:     post.User,
+			Type:     post.Type,
+			Status:   post.Status,
+			UploadId: post.UploadId,
+			Version:  post.Version,
+		})
+	}
+
+	err = checkPostsCanBeDeleted(username, postIds, posts)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Posts %v can not be deleted by user %s", postIds, username)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	err = checkPostsVersion(posts, expectedVersion)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Posts %v were modified since version %d", postIds, *expectedVersion)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// MarkPostsDeleted moves the posts to the deleting status and saves event,
+// when there is one, only if none of the posts changed since they were read.
+func (r *DeletePostRepository) MarkPostsDeleted(posts []*Post, event *bus.Event, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DeletePostRepository.MarkPostsDeleted")
+	defer span.End()
+
+	operations := make([]database.TransactionOperation, 0, len(posts)+1)
+	for _, post := range posts {
+		readVersion := post.Version
+		operations = append(operations, &database.UpdateOperation{
+			TableName: "Posts",
+			Key: &database.PostKey{
+				PostId: post.PostId,
+			},
+			Attributes: map[string]any{
+				"Status": database.PostStatusDeleting,
+			},
+			ExpectedVersion: &readVersion,
+		})
+	}
+	if event != nil {
+		outboxEvent, err := database.NewOutboxEvent(event.Type, event.Data, tracing.Inject(ctx))
+		if err != nil {
+			tracing.Fail(span, err)
+			return err
+		}
+		operations = append(operations, &database.InsertOperation{
+			TableName: "Outbox",
+			Item:      outboxEvent,
+		})
+	}
+
+	err := r.dataRepository.Client.ExecuteTransaction(operations, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
 		return err
 	}
 
-	var objectKeys []string
 	for _, post := range posts {
-		objectKey := post.User + "/" + post.Type + "/" + post.PostId
-		thumbnailObjectKey := post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
-		objectKeys = append(objectKeys, objectKey)
-		objectKeys = append(objectKeys, thumbnailObjectKey)
+		post.Version++
 	}
+	return nil
+}
+
+// DeletePostContent aborts the upload of a pending post and deletes its
+// objects, then its metadata.
+func (r *DeletePostRepository) DeletePostContent(post *Post, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DeletePostRepository.DeletePostContent")
+	defer span.End()
 
-	err = r.objectRepository.Client.DeleteObjects(objectKeys)
+	if post.Status == database.PostStatusPending && post.UploadId != "" {
+		err := r.objectRepository.Client.AbortMultipartUpload(contentKey(post), post.UploadId, ctx)
+		if err != nil {
+			tracing.Fail(span, err)
+			return err
+		}
+	}
+
+	err := r.objectRepository.Client.DeleteObjects([]string{contentKey(post), thumbnailKey(post)}, ctx)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("Error deletting posts for postIds %v", postIds)
+		tracing.Fail(span, err)
 		return err
 	}
 
-	err = r.removePosts(postIds)
+	postKey := &database.PostKey{
+		PostId: post.PostId,
+	}
+	err = r.dataRepository.Client.RemoveDataIfVersion("Posts", postKey, post.Version, ctx)
 	if err != nil {
-		log.Error().Stack().Err(err).Msgf("Error deletting post metadatas for postIds %v", postIds)
+		tracing.Fail(span, err)
 		return err
 	}
 
 	return nil
 }
 
-func (r *DeletePostRepository) removePosts(postIds []string) error {
-	postKeys := make([]any, len(postIds))
-	for i, v := range postIds {
-		postKeys[i] = &database.PostKey{
-			PostId: v,
+func checkPostsCanBeDeleted(username string, postIds []string, posts []*Post) error {
+	foundPosts := make(map[string]*Post, len(posts))
+	for _, post := range posts {
+		foundPosts[post.PostId] = post
+	}
+
+	var missingPostIds []string
+	var notOwnedPostIds []string
+	for _, postId := range postIds {
+		post, found := foundPosts[postId]
+		if !found {
+			missingPostIds = append(missingPostIds, postId)
+		} else if post.User != username {
+			notOwnedPostIds = append(notOwnedPostIds, postId)
 		}
 	}
 
-	err := r.dataRepository.Client.RemoveMultipleData("Posts", postKeys)
+	if len(missingPostIds) > 0 {
+		return database.NewNotFoundError("Posts", missingPostIds)
+	}
+	if len(notOwnedPostIds) > 0 {
+		return database.NewForbiddenError("Posts", notOwnedPostIds, username)
+	}
+
+	return nil
+}
+
+func checkPostsVersion(posts []*Post, expectedVersion *int) error {
+	if expectedVersion == nil {
+		return nil
+	}
+
+	for _, post := range posts {
+		if post.Version != *expectedVersion {
+			return database.NewConflictError("Posts", post.PostId, fmt.Sprintf("expected version %d, found %d", *expectedVersion, post.Version))
+		}
+	}
+
+	return nil
+}
+
+func contentKey(post *Post) string {
+	return post.User + "/" + post.Type + "/" + post.PostId
+}
 
-	return err
+func thumbnailKey(post *Post) string {
+	return post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
 }
@@ -2,8 +2,10 @@ package delete_post_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"postservice/internal/bus"
 	database "postservice/internal/db"
 	mock_database "postservice/internal/db/mock"
 	"postservice/internal/features/delete_post"
@@ -30,127 +32,209 @@ func setUp(t *testing.T) {
 	deletePostRepository = delete_post.NewDeletePostRepository(database.NewDatabase(dataClient), objectStorage.NewObjectStorage(objectClient))
 }
 
-func TestDeletePostsWithRepository(t *testing.T) {
+func TestGetPostsToDeleteWithRepository(t *testing.T) {
 	setUp(t)
-	postIds := []string{"1", "2", "3"}
+	username := "usernam1"
+	postIds := []string{"usernam1-meuPost-170948521", "usernam1-meuPost2-184639321"}
 	data := []*database.Post{
 		{
 			PostId:    "usernam1-meuPost-170948521",
-			User:      "usernam1",
+			User:      username,
+			Type:      "VIDEO",
 			Title:     "meuPost",
 			CreatedAt: time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC(),
+			Status:    database.PostStatusPending,
+			UploadId:  "uploadId",
+			Version:   2,
 		},
 		{
 			PostId:    "usernam1-meuPost2-184639321",
-			User:      "usernam1",
+			User:      username,
+			Type:      "TEXT",
 			Title:     "meuPost2",
 			CreatedAt: time.Date(2024, 7, 24, 20, 51, 20, 33, time.UTC).UTC(),
+			Status:    database.PostStatusPublished,
+			Version:   5,
 		},
 	}
-	expectedKeys := []string{
-		data[0].User + "/" + data[0].Type + "/" + data[0].PostId,
-		data[0].User + "/" + data[0].Type + "/THUMBNAILS/" + data[0].PostId,
-		data[1].User + "/" + data[1].Type + "/" + data[1].PostId,
-		data[1].User + "/" + data[1].Type + "/THUMBNAILS/" + data[1].PostId,
-	}
-	expectedPostKeys := []any{
-		&database.PostKey{
-			PostId: "1",
-		},
-		&database.PostKey{
-			PostId: "2",
-		},
-		&database.PostKey{
-			PostId: "3",
-		},
-	}
-	dataClient.EXPECT().GetPostsByIds(postIds).Return(data, nil)
-	objectClient.EXPECT().DeleteObjects(expectedKeys)
-	dataClient.EXPECT().RemoveMultipleData("Posts", expectedPostKeys).Return(nil)
+	dataClient.EXPECT().GetPostsByIds(postIds, gomock.Any()).Return(data, nil)
+
+	posts, err := deletePostRepository.GetPostsToDelete(username, postIds, nil, context.Background())
 
-	deletePostRepository.DeletePosts(postIds)
+	assert.Nil(t, err)
+	assert.Equal(t, []*delete_post.Post{
+		{PostId: postIds[0], User: username, Type: "VIDEO", Status: database.PostStatusPending, UploadId: "uploadId", Version: 2},
+		{PostId: postIds[1], User: username, Type: "TEXT", Status: database.PostStatusPublished, Version: 5},
+	}, posts)
 }
 
-func TestDeletePostsWithRepository_GettingPostMetadataError(t *testing.T) {
+func TestGetPostsToDeleteWithRepository_SomePostsNotFound(t *testing.T) {
 	setUp(t)
-	postIds := []string{"1", "2", "3"}
-	dataClient.EXPECT().GetPostsByIds(postIds).Return(nil, errors.New("some error"))
+	username := "usernam1"
+	postIds := []string{"usernam1-meuPost-170948521", "missing-post", "deleting-post"}
+	data := []*database.Post{
+		{
+			PostId: "usernam1-meuPost-170948521",
+			User:   username,
+		},
+		{
+			PostId: "deleting-post",
+			User:   username,
+			Status: database.PostStatusDeleting,
+		},
+	}
+	dataClient.EXPECT().GetPostsByIds(postIds, gomock.Any()).Return(data, nil)
 
-	deletePostRepository.DeletePosts(postIds)
+	_, err := deletePostRepository.GetPostsToDelete(username, postIds, nil, context.Background())
 
-	assert.Contains(t, repositoryLoggerOutput.String(), fmt.Sprintf("Error getting post metadatas for postIds %v", postIds))
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	assert.Contains(t, err.Error(), "[missing-post deleting-post]")
 }
 
-func TestDeletePostsWithRepository_DeletingObjectsError(t *testing.T) {
+func TestGetPostsToDeleteWithRepository_SomePostsOwnedByOtherUser(t *testing.T) {
 	setUp(t)
-	postIds := []string{"1", "2", "3"}
+	username := "usernam1"
+	postIds := []string{"usernam1-meuPost-170948521", "usernam2-meuPost-170948521"}
 	data := []*database.Post{
 		{
-			PostId:    "usernam1-meuPost-170948521",
-			User:      "usernam1",
-			Title:     "meuPost",
-			CreatedAt: time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC(),
+			PostId: "usernam1-meuPost-170948521",
+			User:   username,
 		},
 		{
-			PostId:    "usernam1-meuPost2-184639321",
-			User:      "usernam1",
-			Title:     "meuPost2",
-			CreatedAt: time.Date(2024, 7, 24, 20, 51, 20, 33, time.UTC).UTC(),
+			PostId: "usernam2-meuPost-170948521",
+			User:   "usernam2",
 		},
 	}
-	expectedKeys := []string{
-		data[0].User + "/" + data[0].Type + "/" + data[0].PostId,
-		data[0].User + "/" + data[0].Type + "/THUMBNAILS/" + data[0].PostId,
-		data[1].User + "/" + data[1].Type + "/" + data[1].PostId,
-		data[1].User + "/" + data[1].Type + "/THUMBNAILS/" + data[1].PostId,
-	}
-	dataClient.EXPECT().GetPostsByIds(postIds).Return(data, nil)
-	objectClient.EXPECT().DeleteObjects(expectedKeys).Return(errors.New("some error"))
+	dataClient.EXPECT().GetPostsByIds(postIds, gomock.Any()).Return(data, nil)
 
-	deletePostRepository.DeletePosts(postIds)
+	_, err := deletePostRepository.GetPostsToDelete(username, postIds, nil, context.Background())
 
-	assert.Contains(t, repositoryLoggerOutput.String(), fmt.Sprintf("Error deletting posts for postIds %v", postIds))
+	var forbiddenError *database.ForbiddenError
+	assert.ErrorAs(t, err, &forbiddenError)
+	assert.Contains(t, err.Error(), "[usernam2-meuPost-170948521]")
 }
 
-func TestDeletePostsWithRepository_RemovingPostMetadataError(t *testing.T) {
+func TestGetPostsToDeleteWithRepository_VersionDoesNotMatch(t *testing.T) {
 	setUp(t)
-	postIds := []string{"1", "2", "3"}
+	username := "usernam1"
+	postIds := []string{"usernam1-meuPost-170948521"}
 	data := []*database.Post{
 		{
-			PostId:    "usernam1-meuPost-170948521",
-			User:      "usernam1",
-			Title:     "meuPost",
-			CreatedAt: time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC(),
-		},
-		{
-			PostId:    "usernam1-meuPost2-184639321",
-			User:      "usernam1",
-			Title:     "meuPost2",
-			CreatedAt: time.Date(2024, 7, 24, 20, 51, 20, 33, time.UTC).UTC(),
+			PostId:  "usernam1-meuPost-170948521",
+			User:    username,
+			Version: 3,
 		},
 	}
-	expectedKeys := []string{
-		data[0].User + "/" + data[0].Type + "/" + data[0].PostId,
-		data[0].User + "/" + data[0].Type + "/THUMBNAILS/" + data[0].PostId,
-		data[1].User + "/" + data[1].Type + "/" + data[1].PostId,
-		data[1].User + "/" + data[1].Type + "/THUMBNAILS/" + data[1].PostId,
+	expectedVersion := 2
+	dataClient.EXPECT().GetPostsByIds(postIds, gomock.Any()).Return(data, nil)
+
+	_, err := deletePostRepository.GetPostsToDelete(username, postIds, &expectedVersion, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	assert.Contains(t, repositoryLoggerOutput.String(), "were modified since version 2")
+}
+
+func TestGetPostsToDeleteWithRepository_GettingPostMetadataError(t *testing.T) {
+	setUp(t)
+	postIds := []string{"1", "2", "3"}
+	dataClient.EXPECT().GetPostsByIds(postIds, gomock.Any()).Return(nil, errors.New("some error"))
+
+	_, err := deletePostRepository.GetPostsToDelete("usernam1", postIds, nil, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, repositoryLoggerOutput.String(), fmt.Sprintf("Error getting post metadatas for postIds %v", postIds))
+}
+
+func TestMarkPostsDeletedWithRepository(t *testing.T) {
+	setUp(t)
+	posts := []*delete_post.Post{
+		{PostId: "post1", User: "usernam1", Version: 2},
+		{PostId: "post2", User: "usernam1", Version: 5},
 	}
-	expectedPostKeys := []any{
-		&database.PostKey{
-			PostId: "1",
-		},
-		&database.PostKey{
-			PostId: "2",
-		},
-		&database.PostKey{
-			PostId: "3",
-		},
+	event := &bus.Event{
+		Type: "PostsWereDeletedEvent",
+		Data: []byte(`{"username":"usernam1"}`),
 	}
-	dataClient.EXPECT().GetPostsByIds(postIds).Return(data, nil)
-	objectClient.EXPECT().DeleteObjects(expectedKeys)
-	dataClient.EXPECT().RemoveMultipleData("Posts", expectedPostKeys).Return(errors.New("some error"))
+	dataClient.EXPECT().ExecuteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(operations []database.TransactionOperation, ctx context.Context) error {
+		assert.Len(t, operations, 3)
+		firstVersion, secondVersion := 2, 5
+		deleting := map[string]any{"Status": database.PostStatusDeleting}
+		assert.Equal(t, &database.UpdateOperation{TableName: "Posts", Key: &database.PostKey{PostId: "post1"}, Attributes: deleting, ExpectedVersion: &firstVersion}, operations[0])
+		assert.Equal(t, &database.UpdateOperation{TableName: "Posts", Key: &database.PostKey{PostId: "post2"}, Attributes: deleting, ExpectedVersion: &secondVersion}, operations[1])
+		outboxInsert := operations[2].(*database.InsertOperation)
+		outboxEvent := outboxInsert.Item.(*database.OutboxEvent)
+		assert.Equal(t, "Outbox", outboxInsert.TableName)
+		assert.Equal(t, event.Type, outboxEvent.Type)
+		assert.Equal(t, event.Data, outboxEvent.Data)
+		return nil
+	})
+
+	err := deletePostRepository.MarkPostsDeleted(posts, event, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, posts[0].Version)
+	assert.Equal(t, 6, posts[1].Version)
+}
+
+func TestMarkPostsDeletedWithRepository_WithoutEvent(t *testing.T) {
+	setUp(t)
+	posts := []*delete_post.Post{{PostId: "post1", User: "usernam1", Status: database.PostStatusPending, Version: 1}}
+	dataClient.EXPECT().ExecuteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(operations []database.TransactionOperation, ctx context.Context) error {
+		assert.Len(t, operations, 1)
+		return nil
+	})
+
+	err := deletePostRepository.MarkPostsDeleted(posts, nil, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestMarkPostsDeletedWithRepository_Error(t *testing.T) {
+	setUp(t)
+	posts := []*delete_post.Post{{PostId: "post1", User: "usernam1", Version: 1}}
+	dataClient.EXPECT().ExecuteTransaction(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+	err := deletePostRepository.MarkPostsDeleted(posts, &bus.Event{}, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, posts[0].Version)
+}
+
+func TestDeletePostContentWithRepository(t *testing.T) {
+	setUp(t)
+	post := &delete_post.Post{PostId: "post1", User: "usernam1", Type: "TEXT", Status: database.PostStatusPublished, UploadId: "uploadId", Version: 3}
+	gomock.InOrder(
+		objectClient.EXPECT().DeleteObjects([]string{"usernam1/TEXT/post1", "usernam1/TEXT/THUMBNAILS/post1"}, gomock.Any()).Return(nil),
+		dataClient.EXPECT().RemoveDataIfVersion("Posts", &database.PostKey{PostId: "post1"}, 3, gomock.Any()).Return(nil),
+	)
+
+	err := deletePostRepository.DeletePostContent(post, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestDeletePostContentWithRepository_AbortsUploadOfPendingPost(t *testing.T) {
+	setUp(t)
+	post := &delete_post.Post{PostId: "post1", User: "usernam1", Type: "VIDEO", Status: database.PostStatusPending, UploadId: "uploadId", Version: 2}
+	gomock.InOrder(
+		objectClient.EXPECT().AbortMultipartUpload("usernam1/VIDEO/post1", "uploadId", gomock.Any()).Return(nil),
+		objectClient.EXPECT().DeleteObjects([]string{"usernam1/VIDEO/post1", "usernam1/VIDEO/THUMBNAILS/post1"}, gomock.Any()).Return(nil),
+		dataClient.EXPECT().RemoveDataIfVersion("Posts", &database.PostKey{PostId: "post1"}, 2, gomock.Any()).Return(nil),
+	)
+
+	err := deletePostRepository.DeletePostContent(post, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestDeletePostContentWithRepository_DeletingObjectsError(t *testing.T) {
+	setUp(t)
+	post := &delete_post.Post{PostId: "post1", User: "usernam1", Type: "TEXT", Version: 3}
+	objectClient.EXPECT().DeleteObjects(gomock.Any(), gomock.Any()).Return(errors.New("some error"))
 
-	deletePostRepository.DeletePosts(postIds)
+	err := deletePostRepository.DeletePostContent(post, context.Background())
 
-	assert.Contains(t, repositoryLoggerOutput.String(), fmt.Sprintf("Error deletting post metadatas for postIds %v", postIds))
+	assert.NotNil(t, err)
 }
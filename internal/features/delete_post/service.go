@@ -1,7 +1,11 @@
 package delete_post
 
 import (
+	"context"
 	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"postservice/internal/metrics"
+	"postservice/internal/tracing"
 
 	"github.com/rs/zerolog/log"
 )
@@ -9,12 +13,26 @@ import (
 //go:generate mockgen -source=service.go -destination=mock/service.go
 
 type Repository interface {
-	DeletePosts(postIds []string) error
+	// GetPostsToDelete fails unless every post exists, belongs to username and
+	// has expectedVersion, when it is not nil.
+	GetPostsToDelete(username string, postIds []string, expectedVersion *int, ctx context.Context) ([]*Post, error)
+	MarkPostsDeleted(posts []*Post, event *bus.Event, ctx context.Context) error
+	DeletePostContent(post *Post, ctx context.Context) error
 }
 
+var postsDeleted = metrics.NewCounter("postservice_posts_deleted_total", "Posts deleted by their owners.")
+
 type DeletePostService struct {
 	repository Repository
-	bus        *bus.EventBus
+}
+
+type Post struct {
+	PostId   string
+	User     string
+	Type     string
+	Status   string
+	UploadId string
+	Version  int
 }
 
 type PostsWereDeletedEvent struct {
@@ -22,36 +40,86 @@ type PostsWereDeletedEvent struct {
 	PostIds  []string `json:"postIds"`
 }
 
-func NewDeletePostService(repository Repository, bus *bus.EventBus) *DeletePostService {
+func NewDeletePostService(repository Repository) *DeletePostService {
 	return &DeletePostService{
 		repository: repository,
-		bus:        bus,
 	}
 }
 
-func (s *DeletePostService) DeletePosts(username string, postIds []string) error {
-	err := s.repository.DeletePosts(postIds)
+// DeletePosts removes the posts of username. A non nil expectedVersion is the
+// only version the posts can have to be deleted. Only the published posts were
+// announced, so only those are in the PostsWereDeletedEvent.
+func (s *DeletePostService) DeletePosts(username string, postIds []string, expectedVersion *int, ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "DeletePostService.DeletePosts")
+	defer span.End()
+
+	postIds = removeDuplicates(postIds)
+	posts, err := s.repository.GetPostsToDelete(username, postIds, expectedVersion, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		log.Error().Stack().Err(err).Msgf("Error deleting posts for postIds %v", postIds)
+		return err
+	}
+
+	var publishedPostIds []string
+	for _, post := range posts {
+		if database.IsPublishedStatus(post.Status) {
+			publishedPostIds = append(publishedPostIds, post.PostId)
+		}
+	}
+	var event *bus.Event
+	if len(publishedPostIds) > 0 {
+		event, err = createPostsWereDeletedEvent(username, publishedPostIds)
+		if err != nil {
+			tracing.Fail(span, err)
+			return err
+		}
+	}
+
+	err = s.repository.MarkPostsDeleted(posts, event, ctx)
 	if err != nil {
+		tracing.Fail(span, err)
 		log.Error().Stack().Err(err).Msgf("Error deleting posts for postIds %v", postIds)
 		return err
 	}
 
-	s.publishPostsWereDeletedEvent(username, postIds)
+	// The posts are deleted for their owner already, the reaper deletes the
+	// content of those that fail here.
+	for _, post := range posts {
+		err = s.repository.DeletePostContent(post, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error deleting content of deleted Post %s, it is left to the reaper", post.PostId)
+		}
+	}
 
+	postsDeleted.Add(float64(len(postIds)))
 	log.Info().Msgf("%v were deleted", postIds)
 	return nil
 }
 
-func (s *DeletePostService) publishPostsWereDeletedEvent(username string, postIds []string) error {
-	event := &PostsWereDeletedEvent{
+func createPostsWereDeletedEvent(username string, postIds []string) (*bus.Event, error) {
+	event, err := bus.NewEvent("PostsWereDeletedEvent", &PostsWereDeletedEvent{
 		Username: username,
 		PostIds:  postIds,
-	}
-	err := s.bus.Publish("PostsWereDeletedEvent", event)
+	})
 	if err != nil {
-		log.Error().Stack().Err(err).Msg("Publishing PostsWereDeletedEvent failed")
-		return err
+		log.Error().Stack().Err(err).Msg("Creating PostsWereDeletedEvent failed")
+		return nil, err
 	}
 
-	return nil
+	return event, nil
+}
+
+func removeDuplicates(postIds []string) []string {
+	seen := make(map[string]bool, len(postIds))
+	var result []string
+	for _, postId := range postIds {
+		if seen[postId] {
+			continue
+		}
+		seen[postId] = true
+		result = append(result, postId)
+	}
+
+	return result
 }
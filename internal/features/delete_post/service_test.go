@@ -2,11 +2,12 @@ package delete_post_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"postservice/internal/bus"
-	mock_bus "postservice/internal/bus/mock"
+	database "postservice/internal/db"
 	"postservice/internal/features/delete_post"
 	mock_delete_post "postservice/internal/features/delete_post/mock"
 	"testing"
@@ -18,62 +19,136 @@ import (
 
 var serviceLoggerOutput bytes.Buffer
 var serviceRepository *mock_delete_post.MockRepository
-var serviceExternalBus *mock_bus.MockExternalBus
-var serviceBus *bus.EventBus
 var deletePostService *delete_post.DeletePostService
 
 func setUpService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	serviceRepository = mock_delete_post.NewMockRepository(ctrl)
 	log.Logger = log.Output(&serviceLoggerOutput)
-	serviceExternalBus = mock_bus.NewMockExternalBus(ctrl)
-	serviceBus = bus.NewEventBus(serviceExternalBus)
-	deletePostService = delete_post.NewDeletePostService(serviceRepository, serviceBus)
+	deletePostService = delete_post.NewDeletePostService(serviceRepository)
 }
 
 func TestDeletePostsWithService(t *testing.T) {
 	setUpService(t)
 	username := "username1"
 	postIds := []string{"1", "2", "3"}
-	serviceRepository.EXPECT().DeletePosts(postIds).Return(nil)
+	posts := []*delete_post.Post{
+		{PostId: "1", User: username, Status: database.PostStatusPublished},
+		{PostId: "2", User: username},
+		{PostId: "3", User: username, Status: database.PostStatusPublished},
+	}
 	expectedPostsWereDeletedEvent := &delete_post.PostsWereDeletedEvent{
 		Username: username,
 		PostIds:  postIds,
 	}
 	expectedEvent := createEvent("PostsWereDeletedEvent", expectedPostsWereDeletedEvent)
-	serviceExternalBus.EXPECT().Publish(expectedEvent)
+	gomock.InOrder(
+		serviceRepository.EXPECT().GetPostsToDelete(username, postIds, nil, gomock.Any()).Return(posts, nil),
+		serviceRepository.EXPECT().MarkPostsDeleted(posts, expectedEvent, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().DeletePostContent(posts[0], gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().DeletePostContent(posts[1], gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().DeletePostContent(posts[2], gomock.Any()).Return(nil),
+	)
 
-	deletePostService.DeletePosts(username, postIds)
+	err := deletePostService.DeletePosts(username, postIds, nil, context.Background())
 
+	assert.Nil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), "[1 2 3] were deleted")
 }
 
+func TestDeletePostsWithService_OnlyAnnouncesPublishedPosts(t *testing.T) {
+	setUpService(t)
+	username := "username1"
+	postIds := []string{"1", "2"}
+	posts := []*delete_post.Post{
+		{PostId: "1", User: username, Status: database.PostStatusPending, UploadId: "uploadId"},
+		{PostId: "2", User: username, Status: database.PostStatusPublished},
+	}
+	expectedEvent := createEvent("PostsWereDeletedEvent", &delete_post.PostsWereDeletedEvent{Username: username, PostIds: []string{"2"}})
+	serviceRepository.EXPECT().GetPostsToDelete(username, postIds, nil, gomock.Any()).Return(posts, nil)
+	serviceRepository.EXPECT().MarkPostsDeleted(posts, expectedEvent, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeletePostContent(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	err := deletePostService.DeletePosts(username, postIds, nil, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestDeletePostsWithService_PendingPostsAreNotAnnounced(t *testing.T) {
+	setUpService(t)
+	username := "username1"
+	posts := []*delete_post.Post{
+		{PostId: "1", User: username, Status: database.PostStatusPending},
+	}
+	serviceRepository.EXPECT().GetPostsToDelete(username, []string{"1"}, nil, gomock.Any()).Return(posts, nil)
+	serviceRepository.EXPECT().MarkPostsDeleted(posts, nil, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeletePostContent(posts[0], gomock.Any()).Return(nil)
+
+	err := deletePostService.DeletePosts(username, []string{"1"}, nil, context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestDeletePostsWithService_ContentLeftToTheReaper(t *testing.T) {
+	setUpService(t)
+	username := "username1"
+	posts := []*delete_post.Post{
+		{PostId: "1", User: username, Status: database.PostStatusPublished},
+		{PostId: "2", User: username, Status: database.PostStatusPublished},
+	}
+	serviceRepository.EXPECT().GetPostsToDelete(username, []string{"1", "2"}, nil, gomock.Any()).Return(posts, nil)
+	serviceRepository.EXPECT().MarkPostsDeleted(posts, gomock.Any(), gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeletePostContent(posts[0], gomock.Any()).Return(errors.New("some error"))
+	serviceRepository.EXPECT().DeletePostContent(posts[1], gomock.Any()).Return(nil)
+
+	err := deletePostService.DeletePosts(username, []string{"1", "2"}, nil, context.Background())
+
+	assert.Nil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error deleting content of deleted Post 1, it is left to the reaper")
+}
+
 func TestDeletePostsWithService_Error(t *testing.T) {
 	setUpService(t)
 	username := "username1"
 	postIds := []string{"1", "2", "3", "4"}
-	serviceRepository.EXPECT().DeletePosts(postIds).Return(errors.New("Some error"))
+	serviceRepository.EXPECT().GetPostsToDelete(username, postIds, nil, gomock.Any()).Return(nil, errors.New("Some error"))
 
-	deletePostService.DeletePosts(username, postIds)
+	err := deletePostService.DeletePosts(username, postIds, nil, context.Background())
 
+	assert.NotNil(t, err)
 	assert.Contains(t, serviceLoggerOutput.String(), fmt.Sprintf("Error deleting posts for postIds %v", postIds))
 }
 
-func TestDeletePostsWithService_ErrorPublishingEvent(t *testing.T) {
+func TestDeletePostsWithService_MarkingPostsDeletedError(t *testing.T) {
 	setUpService(t)
 	username := "username1"
-	postIds := []string{"1", "2", "3", "4"}
-	serviceRepository.EXPECT().DeletePosts(postIds).Return(nil)
+	posts := []*delete_post.Post{{PostId: "1", User: username}}
+	serviceRepository.EXPECT().GetPostsToDelete(username, []string{"1"}, nil, gomock.Any()).Return(posts, nil)
+	serviceRepository.EXPECT().MarkPostsDeleted(posts, gomock.Any(), gomock.Any()).Return(errors.New("Some error"))
+
+	err := deletePostService.DeletePosts(username, []string{"1"}, nil, context.Background())
+
+	assert.NotNil(t, err)
+}
+
+func TestDeletePostsWithService_DuplicatedPostIds(t *testing.T) {
+	setUpService(t)
+	username := "username1"
+	postIds := []string{"1", "2", "1"}
+	deletedPostIds := []string{"1", "2"}
+	posts := []*delete_post.Post{{PostId: "1", User: username}, {PostId: "2", User: username}}
 	expectedPostsWereDeletedEvent := &delete_post.PostsWereDeletedEvent{
 		Username: username,
-		PostIds:  postIds,
+		PostIds:  deletedPostIds,
 	}
 	expectedEvent := createEvent("PostsWereDeletedEvent", expectedPostsWereDeletedEvent)
-	serviceExternalBus.EXPECT().Publish(expectedEvent).Return(errors.New("some error"))
+	serviceRepository.EXPECT().GetPostsToDelete(username, deletedPostIds, nil, gomock.Any()).Return(posts, nil)
+	serviceRepository.EXPECT().MarkPostsDeleted(posts, expectedEvent, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeletePostContent(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
-	deletePostService.DeletePosts(username, postIds)
+	deletePostService.DeletePosts(username, postIds, nil, context.Background())
 
-	assert.Contains(t, serviceLoggerOutput.String(), "Publishing PostsWereDeletedEvent failed")
+	assert.Contains(t, serviceLoggerOutput.String(), "[1 2] were deleted")
 }
 
 func createEvent(eventName string, eventData any) *bus.Event {
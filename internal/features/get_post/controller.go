@@ -1,7 +1,10 @@
 package get_post
 
 import (
+	"errors"
+	"fmt"
 	"postservice/internal/api"
+	database "postservice/internal/db"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -27,28 +30,43 @@ func NewGetPostController(repository Repository) *GetPostController {
 
 func (controller *GetPostController) Routes(routerGroup *gin.RouterGroup) {
 	routerGroup.GET("/user-posts/:username", controller.GetUserPosts)
+	routerGroup.GET("/posts/type/:type", controller.GetPostsByType)
+	routerGroup.GET("/post/:postId", controller.GetPost)
 }
 
 func (controller *GetPostController) GetUserPosts(c *gin.Context) {
 	log.Info().Msg("Handling Request GET UserPosts")
 	username := c.Param("username")
-	lastPostId := c.DefaultQuery("lastPostId", "")
-	lastPostCreatedAt := c.DefaultQuery("lastPostCreatedAt", "")
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "6"))
+	lastPostId, lastPostCreatedAt, limit, ok := getPaginationParameters(c)
+	if !ok {
+		return
+	}
 
-	if err != nil || limit <= 0 {
-		api.SendBadRequest(c, "Invalid pagination parameters, limit has to be greater than 0")
+	postUrls, lastPostId, lastPostCreatedAt, err := controller.service.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit, c.Request.Context())
+	if err != nil {
+		api.SendError(c, err)
 		return
 	}
 
-	if (lastPostId != "" && lastPostCreatedAt == "") || (lastPostId == "" && lastPostCreatedAt != "") {
-		api.SendBadRequest(c, "Invalid pagination parameters, lastPostId and lastPostCreatedAt both have to have value or both have to be empty")
+	api.SendOKWithResult(c, &GetPostResponse{
+		PostUrls:          postUrls,
+		Limit:             limit,
+		LastPostId:        lastPostId,
+		LastPostCreatedAt: lastPostCreatedAt,
+	})
+}
+
+func (controller *GetPostController) GetPostsByType(c *gin.Context) {
+	log.Info().Msg("Handling Request GET PostsByType")
+	postType := c.Param("type")
+	lastPostId, lastPostCreatedAt, limit, ok := getPaginationParameters(c)
+	if !ok {
 		return
 	}
 
-	postUrls, lastPostId, lastPostCreatedAt, err := controller.service.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit)
+	postUrls, lastPostId, lastPostCreatedAt, err := controller.service.GetPostsByType(postType, lastPostId, lastPostCreatedAt, limit, c.Request.Context())
 	if err != nil {
-		api.SendInternalServerError(c, err.Error())
+		api.SendError(c, err)
 		return
 	}
 
@@ -59,3 +77,39 @@ func (controller *GetPostController) GetUserPosts(c *gin.Context) {
 		LastPostCreatedAt: lastPostCreatedAt,
 	})
 }
+
+func (controller *GetPostController) GetPost(c *gin.Context) {
+	log.Info().Msg("Handling Request GET Post")
+	postId := c.Param("postId")
+
+	post, err := controller.service.GetPost(postId, c.Request.Context())
+	if err != nil {
+		var notFoundError *database.NotFoundError
+		if errors.As(err, &notFoundError) {
+			err = api.NewNotFoundError(fmt.Sprintf("Post not found for post id %s", postId), err)
+		}
+		api.SendError(c, err)
+		return
+	}
+
+	api.SetETag(c, post.Version)
+	api.SendOKWithResult(c, post)
+}
+
+func getPaginationParameters(c *gin.Context) (string, string, int, bool) {
+	lastPostId := c.DefaultQuery("lastPostId", "")
+	lastPostCreatedAt := c.DefaultQuery("lastPostCreatedAt", "")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "6"))
+
+	if err != nil || limit <= 0 {
+		api.SendError(c, api.NewValidationError("Invalid pagination parameters, limit has to be greater than 0"))
+		return "", "", 0, false
+	}
+
+	if (lastPostId != "" && lastPostCreatedAt == "") || (lastPostId == "" && lastPostCreatedAt != "") {
+		api.SendError(c, api.NewValidationError("Invalid pagination parameters, lastPostId and lastPostCreatedAt both have to have value or both have to be empty"))
+		return "", "", 0, false
+	}
+
+	return lastPostId, lastPostCreatedAt, limit, true
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	database "postservice/internal/db"
 	"postservice/internal/features/get_post"
 	mock_get_post "postservice/internal/features/get_post/mock"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
@@ -68,7 +70,7 @@ func TestGetUserPost(t *testing.T) {
 			PresignedThumbnailUrl: "thumbnailUrl3",
 		},
 	}
-	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, 4).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
+	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, 4, gomock.Any()).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -84,6 +86,11 @@ func TestGetUserPost(t *testing.T) {
 func TestGetUserPostWithDefaultPaginationParameters(t *testing.T) {
 	setUpHandler(t)
 	username := "username1"
+	ginContext.Request = &http.Request{
+		Header: make(http.Header),
+		URL:    &url.URL{},
+	}
+	ginContext.Request.Method = "GET"
 	ginContext.Params = []gin.Param{{Key: "username", Value: username}}
 	expectedPresignedUrls := []get_post.PostUrl{
 		{
@@ -105,7 +112,7 @@ func TestGetUserPostWithDefaultPaginationParameters(t *testing.T) {
 	expectedDefaultLastPostId := ""
 	expectedDefaultLastPostCreatedAt := ""
 	expectedDefaultLimit := 6
-	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, expectedDefaultLastPostId, expectedDefaultLastPostCreatedAt, expectedDefaultLimit).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
+	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, expectedDefaultLastPostId, expectedDefaultLastPostCreatedAt, expectedDefaultLimit, gomock.Any()).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
 	expectedBodyResponse := `{
 		"error": false,
 		"message": "200 OK",
@@ -137,10 +144,11 @@ func TestInternalServerErrorOnGetUserPosts(t *testing.T) {
 	u.Add("limit", limit)
 	ginContext.Request.URL.RawQuery = u.Encode()
 	expectedError := errors.New("some error")
-	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, 4).Return([]get_post.PostUrl{}, "", "", expectedError)
+	controllerRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, 4, gomock.Any()).Return([]get_post.PostUrl{}, "", "", expectedError)
 	expectedBodyResponse := `{
 		"error": true,
-		"message": "` + expectedError.Error() + `",
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
 		"content":null
 	}`
 
@@ -171,6 +179,7 @@ func TestBadRequestErrorOnGetUserPostsWhenLimitSmallerThanZero(t *testing.T) {
 	expectedError := "Invalid pagination parameters, limit has to be greater than 0"
 	expectedBodyResponse := `{
 		"error": true,
+		"code": "VALIDATION_FAILED",
 		"message": "` + expectedError + `",
 		"content":null
 	}`
@@ -202,6 +211,7 @@ func TestBadRequestErrorOnGetUserPostsWhenLastPostIdIsNotEmptyButLastPostCreated
 	expectedError := "Invalid pagination parameters, lastPostId and lastPostCreatedAt both have to have value or both have to be empty"
 	expectedBodyResponse := `{
 		"error": true,
+		"code": "VALIDATION_FAILED",
 		"message": "` + expectedError + `",
 		"content":null
 	}`
@@ -212,6 +222,148 @@ func TestBadRequestErrorOnGetUserPostsWhenLastPostIdIsNotEmptyButLastPostCreated
 	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
 }
 
+func TestGetPostsByType(t *testing.T) {
+	setUpHandler(t)
+	postType := "VIDEO"
+	lastPostId := "post4"
+	lastPostCreatedAt := "0001-01-03T00:00:00Z"
+	ginContext.Request, _ = http.NewRequest("GET", "/posts/type/"+postType+"?lastPostId="+lastPostId+"&lastPostCreatedAt="+lastPostCreatedAt+"&limit=2", nil)
+	ginContext.Params = []gin.Param{{Key: "type", Value: postType}}
+	expectedPresignedUrls := []get_post.PostUrl{
+		{
+			PostId:                "post1",
+			PresignedUrl:          "url1",
+			PresignedThumbnailUrl: "thumbnailUrl1",
+		},
+		{
+			PostId:                "post2",
+			PresignedUrl:          "url2",
+			PresignedThumbnailUrl: "thumbnailUrl2",
+		},
+	}
+	controllerRepository.EXPECT().GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt, 2, gomock.Any()).Return(expectedPresignedUrls, "post2", "0001-01-02T00:00:00Z", nil)
+	expectedBodyResponse := `{
+		"error": false,
+		"message": "200 OK",
+		"content": {"urlPosts":[{"postId":"post1","url":"url1","thumbnailUrl":"thumbnailUrl1"},{"postId":"post2","url":"url2","thumbnailUrl":"thumbnailUrl2"}],"limit":2,"lastPostId":"post2","lastPostCreatedAt":"0001-01-02T00:00:00Z"}
+	}`
+
+	controller.GetPostsByType(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 200)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestBadRequestErrorOnGetPostsByTypeWhenLimitSmallerThanZero(t *testing.T) {
+	setUpHandler(t)
+	postType := "VIDEO"
+	ginContext.Request, _ = http.NewRequest("GET", "/posts/type/"+postType+"?limit=-1", nil)
+	ginContext.Params = []gin.Param{{Key: "type", Value: postType}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid pagination parameters, limit has to be greater than 0",
+		"content":null
+	}`
+
+	controller.GetPostsByType(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestInternalServerErrorOnGetPostsByType(t *testing.T) {
+	setUpHandler(t)
+	postType := "VIDEO"
+	ginContext.Request, _ = http.NewRequest("GET", "/posts/type/"+postType, nil)
+	ginContext.Params = []gin.Param{{Key: "type", Value: postType}}
+	expectedError := errors.New("some error")
+	controllerRepository.EXPECT().GetPresignedUrlsForDownloadingByType(postType, "", "", 6, gomock.Any()).Return([]get_post.PostUrl{}, "", "", expectedError)
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
+		"content":null
+	}`
+
+	controller.GetPostsByType(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 500)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestGetPost(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	ginContext.Request, _ = http.NewRequest("GET", "/post/"+postId, nil)
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	expectedPost := &get_post.PostDetails{
+		PostId:                postId,
+		User:                  "username1",
+		Type:                  "IMAGE",
+		Title:                 "Meu Post",
+		Description:           "Descricion",
+		Size:                  2048,
+		HasThumbnail:          true,
+		CreatedAt:             time.Date(2024, 8, 8, 21, 51, 20, 0, time.UTC),
+		LastUpdated:           time.Date(2024, 8, 9, 21, 51, 20, 0, time.UTC),
+		Version:               2,
+		PresignedUrl:          "url1",
+		PresignedThumbnailUrl: "thumbnailUrl1",
+	}
+	controllerRepository.EXPECT().GetPostWithPresignedUrls(postId, gomock.Any()).Return(expectedPost, nil)
+	expectedBodyResponse := `{
+		"error": false,
+		"message": "200 OK",
+		"content": {"postId":"post1","username":"username1","type":"IMAGE","title":"Meu Post","description":"Descricion","size":2048,"hasThumbnail":true,"createdAt":"2024-08-08T21:51:20Z","lastUpdated":"2024-08-09T21:51:20Z","version":2,"url":"url1","thumbnailUrl":"thumbnailUrl1"}
+	}`
+
+	controller.GetPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 200)
+	assert.Equal(t, apiResponse.Header().Get("ETag"), `"2"`)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestNotFoundErrorOnGetPost(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	ginContext.Request, _ = http.NewRequest("GET", "/post/"+postId, nil)
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	controllerRepository.EXPECT().GetPostWithPresignedUrls(postId, gomock.Any()).Return(nil, database.NewNotFoundError("Posts", postId))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "NOT_FOUND",
+		"message": "Post not found for post id post1",
+		"content": null
+	}`
+
+	controller.GetPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 404)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestInternalServerErrorOnGetPost(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	ginContext.Request, _ = http.NewRequest("GET", "/post/"+postId, nil)
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	expectedError := errors.New("some error")
+	controllerRepository.EXPECT().GetPostWithPresignedUrls(postId, gomock.Any()).Return(nil, expectedError)
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
+		"content": null
+	}`
+
+	controller.GetPost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 500)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
 func removeSpace(s string) string {
 	return strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), "\t", ""), "\n", "")
 }
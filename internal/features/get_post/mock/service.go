@@ -5,6 +5,7 @@
 package mock_get_post
 
 import (
+	context "context"
 	get_post "postservice/internal/features/get_post"
 	reflect "reflect"
 
@@ -34,10 +35,25 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// GetPostWithPresignedUrls mocks base method.
+func (m *MockRepository) GetPostWithPresignedUrls(postId string, ctx context.Context) (*get_post.PostDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostWithPresignedUrls", postId, ctx)
+	ret0, _ := ret[0].(*get_post.PostDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostWithPresignedUrls indicates an expected call of GetPostWithPresignedUrls.
+func (mr *MockRepositoryMockRecorder) GetPostWithPresignedUrls(postId, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostWithPresignedUrls", reflect.TypeOf((*MockRepository)(nil).GetPostWithPresignedUrls), postId, ctx)
+}
+
 // GetPresignedUrlsForDownloading mocks base method.
-func (m *MockRepository) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int) ([]get_post.PostUrl, string, string, error) {
+func (m *MockRepository) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]get_post.PostUrl, string, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPresignedUrlsForDownloading", username, lastPostId, lastPostCreatedAt, limit)
+	ret := m.ctrl.Call(m, "GetPresignedUrlsForDownloading", username, lastPostId, lastPostCreatedAt, limit, ctx)
 	ret0, _ := ret[0].([]get_post.PostUrl)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(string)
@@ -46,7 +62,24 @@ func (m *MockRepository) GetPresignedUrlsForDownloading(username, lastPostId, la
 }
 
 // GetPresignedUrlsForDownloading indicates an expected call of GetPresignedUrlsForDownloading.
-func (mr *MockRepositoryMockRecorder) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresignedUrlsForDownloading", reflect.TypeOf((*MockRepository)(nil).GetPresignedUrlsForDownloading), username, lastPostId, lastPostCreatedAt, limit, ctx)
+}
+
+// GetPresignedUrlsForDownloadingByType mocks base method.
+func (m *MockRepository) GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]get_post.PostUrl, string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPresignedUrlsForDownloadingByType", postType, lastPostId, lastPostCreatedAt, limit, ctx)
+	ret0, _ := ret[0].([]get_post.PostUrl)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(string)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// GetPresignedUrlsForDownloadingByType indicates an expected call of GetPresignedUrlsForDownloadingByType.
+func (mr *MockRepositoryMockRecorder) GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt, limit, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresignedUrlsForDownloading", reflect.TypeOf((*MockRepository)(nil).GetPresignedUrlsForDownloading), username, lastPostId, lastPostCreatedAt, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresignedUrlsForDownloadingByType", reflect.TypeOf((*MockRepository)(nil).GetPresignedUrlsForDownloadingByType), postType, lastPostId, lastPostCreatedAt, limit, ctx)
 }
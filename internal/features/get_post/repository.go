@@ -1,8 +1,10 @@
 package get_post
 
 import (
+	"context"
 	database "postservice/internal/db"
 	objectstorage "postservice/internal/objectStorage"
+	"postservice/internal/tracing"
 
 	"github.com/rs/zerolog/log"
 )
@@ -19,19 +21,87 @@ func NewGetPostRepository(dataRepository *database.Database, objectRepository *o
 	}
 }
 
-func (r *GetPostRepository) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int) ([]PostUrl, string, string, error) {
-	posts, lastPostId, lastPostCreatedAt, err := r.getPostMetadatas(username, lastPostId, lastPostCreatedAt, limit)
+func (r *GetPostRepository) GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error) {
+	ctx, span := tracing.Start(ctx, "GetPostRepository.GetPresignedUrlsForDownloading")
+	defer span.End()
+
+	posts, lastPostId, lastPostCreatedAt, err := r.getPostMetadatas(username, lastPostId, lastPostCreatedAt, limit, ctx)
 	if err != nil {
+		tracing.Fail(span, err)
 		return []PostUrl{}, "", "", err
 	}
 
-	postUrls := r.getPostPresignedUrls(posts)
+	postUrls := r.getPostPresignedUrls(posts, ctx)
 
 	return postUrls, lastPostId, lastPostCreatedAt, nil
 }
 
-func (r *GetPostRepository) getPostMetadatas(username, lastPostId, lastPostCreatedAt string, limit int) ([]*database.Post, string, string, error) {
-	posts, lastPostId, lastPostCreatedAt, err := r.dataRepository.Client.GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit)
+func (r *GetPostRepository) GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error) {
+	ctx, span := tracing.Start(ctx, "GetPostRepository.GetPresignedUrlsForDownloadingByType")
+	defer span.End()
+
+	posts, lastPostId, lastPostCreatedAt, err := r.getPostMetadatasByType(postType, lastPostId, lastPostCreatedAt, limit, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return []PostUrl{}, "", "", err
+	}
+
+	postUrls := r.getPostPresignedUrls(posts, ctx)
+
+	return postUrls, lastPostId, lastPostCreatedAt, nil
+}
+
+func (r *GetPostRepository) GetPostWithPresignedUrls(postId string, ctx context.Context) (*PostDetails, error) {
+	ctx, span := tracing.Start(ctx, "GetPostRepository.GetPostWithPresignedUrls")
+	defer span.End()
+
+	post, err := r.getPostMetadata(postId, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	postUrl, err := r.getPostUrl(post, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return &PostDetails{
+		PostId:                post.PostId,
+		User:                  post.User,
+		Type:                  post.Type,
+		Title:                 post.Title,
+		Description:           post.Description,
+		Size:                  post.Size,
+		HasThumbnail:          post.HasThumbnail,
+		CreatedAt:             post.CreatedAt,
+		LastUpdated:           post.LastUpdated,
+		Version:               post.Version,
+		PresignedUrl:          postUrl.PresignedUrl,
+		PresignedThumbnailUrl: postUrl.PresignedThumbnailUrl,
+	}, nil
+}
+
+func (r *GetPostRepository) getPostMetadata(postId string, ctx context.Context) (*database.Post, error) {
+	postKey := &database.PostKey{
+		PostId: postId,
+	}
+	var post database.Post
+	err := r.dataRepository.Client.GetData("Posts", postKey, &post, ctx)
+	if err == nil && !post.IsPublished() {
+		err = database.NewNotFoundError("Posts", postKey)
+	}
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error getting post metadata for postId %s", postId)
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+func (r *GetPostRepository) getPostMetadatas(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	posts, lastPostId, lastPostCreatedAt, err := r.dataRepository.Client.GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, ctx)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Error getting post metadatas for username %s", username)
 	}
@@ -39,11 +109,20 @@ func (r *GetPostRepository) getPostMetadatas(username, lastPostId, lastPostCreat
 	return posts, lastPostId, lastPostCreatedAt, err
 }
 
-func (r *GetPostRepository) getPostPresignedUrls(posts []*database.Post) []PostUrl {
+func (r *GetPostRepository) getPostMetadatasByType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]*database.Post, string, string, error) {
+	posts, lastPostId, lastPostCreatedAt, err := r.dataRepository.Client.GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt, limit, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error getting post metadatas for type %s", postType)
+	}
+
+	return posts, lastPostId, lastPostCreatedAt, err
+}
+
+func (r *GetPostRepository) getPostPresignedUrls(posts []*database.Post, ctx context.Context) []PostUrl {
 	var postUrls []PostUrl
 
 	for _, post := range posts {
-		postUrl, err := r.getPostUrl(post)
+		postUrl, err := r.getPostUrl(post, ctx)
 		if err != nil {
 			log.Error().Stack().Err(err).Msgf("Error getting presigned URLs for Post %s", post.PostId)
 			continue
@@ -54,14 +133,14 @@ func (r *GetPostRepository) getPostPresignedUrls(posts []*database.Post) []PostU
 	return postUrls
 }
 
-func (r *GetPostRepository) getPostUrl(post *database.Post) (PostUrl, error) {
+func (r *GetPostRepository) getPostUrl(post *database.Post, ctx context.Context) (PostUrl, error) {
 	var postUrl PostUrl
 
-	url, err := r.getPresignedUrl(post)
+	url, err := r.getPresignedUrl(post, ctx)
 	if err != nil {
 		return postUrl, err
 	}
-	thumbnailUrl := r.getPresignedThumbnailUrlIfExists(post)
+	thumbnailUrl := r.getPresignedThumbnailUrlIfExists(post, ctx)
 
 	postUrl = PostUrl{
 		PostId:                post.PostId,
@@ -71,10 +150,10 @@ func (r *GetPostRepository) getPostUrl(post *database.Post) (PostUrl, error) {
 	return postUrl, err
 }
 
-func (r *GetPostRepository) getPresignedUrl(post *database.Post) (string, error) {
+func (r *GetPostRepository) getPresignedUrl(post *database.Post, ctx context.Context) (string, error) {
 	key := post.User + "/" + post.Type + "/" + post.PostId
 
-	url, err := r.objectRepository.Client.GetPreSignedUrlForGettingObject(key)
+	url, err := r.objectRepository.Client.GetPreSignedUrlForGettingObject(key, ctx)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Error getting presigned URLs for Post %s", post.PostId)
 		return "", err
@@ -83,14 +162,14 @@ func (r *GetPostRepository) getPresignedUrl(post *database.Post) (string, error)
 	return url, err
 }
 
-func (r *GetPostRepository) getPresignedThumbnailUrlIfExists(post *database.Post) string {
+func (r *GetPostRepository) getPresignedThumbnailUrlIfExists(post *database.Post, ctx context.Context) string {
 	if !post.HasThumbnail {
 		return ""
 	}
 
 	thumbnailKey := post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
 
-	thumbnailUrl, err := r.objectRepository.Client.GetPreSignedUrlForGettingObject(thumbnailKey)
+	thumbnailUrl, err := r.objectRepository.Client.GetPreSignedUrlForGettingObject(thumbnailKey, ctx)
 	if err != nil {
 		log.Error().Stack().Err(err).Msgf("Error getting presigned thumbnail URLs for Post %s", post.PostId)
 		return ""
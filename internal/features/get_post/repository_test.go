@@ -2,6 +2,7 @@ package get_post_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	database "postservice/internal/db"
 	mock_database "postservice/internal/db/mock"
@@ -63,14 +64,14 @@ func TestGetPresignedUrlsForDownloadingInRepository(t *testing.T) {
 	expectedKey2 := data[1].User + "/" + data[1].Type + "/" + data[1].PostId
 	expectedThumbnailKey2 := data[1].User + "/" + data[1].Type + "/THUMBNAILS/" + data[1].PostId
 	expectedKey3 := data[2].User + "/" + data[2].Type + "/" + data[2].PostId
-	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit).Return(data, "post7", "0001-01-06T00:00:00Z", nil)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey1)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey1)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey2)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey2)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey3)
-
-	getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit)
+	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(data, "post7", "0001-01-06T00:00:00Z", nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey1, gomock.Any())
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey1, gomock.Any())
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey2, gomock.Any())
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey2, gomock.Any())
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey3, gomock.Any())
+
+	getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, context.Background())
 }
 
 func TestErrorOnGetPresignedUrlsForDownloadingInRepositoryWhenGettingPostMetadataByIndexuser(t *testing.T) {
@@ -79,9 +80,9 @@ func TestErrorOnGetPresignedUrlsForDownloadingInRepositoryWhenGettingPostMetadat
 	lastPostId := "post4"
 	lastPostCreatedAt := "0001-01-03T00:00:00Z"
 	limit := 3
-	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit).Return(nil, "", "", errors.New("some error"))
+	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(nil, "", "", errors.New("some error"))
 
-	getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit)
+	getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, context.Background())
 
 	assert.Contains(t, repositoryLoggerOutput.String(), "Error getting post metadatas for username "+username)
 }
@@ -120,12 +121,12 @@ func TestErrorOnGetPresignedUrlsForDownloadingInRepositoryWhenGettingUrls(t *tes
 	}
 	expectedLastPostId := "post7"
 	expectedLastPostCreatedAt := "0001-01-06T00:00:00Z"
-	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit).Return(data, expectedLastPostId, expectedLastPostCreatedAt, nil)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey1).Return("", errors.New("some error"))
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey2).Return(expectedResult[0].PresignedUrl, nil)
-	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey2).Return(expectedResult[0].PresignedThumbnailUrl, nil)
+	dataClient.EXPECT().GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(data, expectedLastPostId, expectedLastPostCreatedAt, nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey1, gomock.Any()).Return("", errors.New("some error"))
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey2, gomock.Any()).Return(expectedResult[0].PresignedUrl, nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey2, gomock.Any()).Return(expectedResult[0].PresignedThumbnailUrl, nil)
 
-	result, lastPostId, lastPostCreatedAt, err := getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit)
+	result, lastPostId, lastPostCreatedAt, err := getPostRepository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, context.Background())
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(result))
@@ -134,3 +135,128 @@ func TestErrorOnGetPresignedUrlsForDownloadingInRepositoryWhenGettingUrls(t *tes
 	assert.Equal(t, expectedLastPostCreatedAt, lastPostCreatedAt)
 	assert.Contains(t, repositoryLoggerOutput.String(), "Error getting presigned URLs for Post "+data[0].PostId)
 }
+
+func TestGetPresignedUrlsForDownloadingByTypeInRepository(t *testing.T) {
+	setUp(t)
+	postType := "VIDEO"
+	lastPostId := "post4"
+	lastPostCreatedAt := "0001-01-03T00:00:00Z"
+	limit := 2
+	data := []*database.Post{
+		{
+			PostId:       "usernam1-meuPost-170948521",
+			User:         "username1",
+			Type:         postType,
+			HasThumbnail: true,
+		},
+		{
+			PostId:       "usernam2-meuPost2-184639321",
+			User:         "username2",
+			Type:         postType,
+			HasThumbnail: false,
+		},
+	}
+	expectedResult := []get_post.PostUrl{
+		{
+			PostId:                data[0].PostId,
+			PresignedUrl:          "url1",
+			PresignedThumbnailUrl: "thumbnailUrl1",
+		},
+		{
+			PostId:       data[1].PostId,
+			PresignedUrl: "url2",
+		},
+	}
+	dataClient.EXPECT().GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(data, "post7", "0001-01-06T00:00:00Z", nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject("username1/VIDEO/"+data[0].PostId, gomock.Any()).Return("url1", nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject("username1/VIDEO/THUMBNAILS/"+data[0].PostId, gomock.Any()).Return("thumbnailUrl1", nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject("username2/VIDEO/"+data[1].PostId, gomock.Any()).Return("url2", nil)
+
+	result, lastPostId, lastPostCreatedAt, err := getPostRepository.GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt, limit, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResult, result)
+	assert.Equal(t, "post7", lastPostId)
+	assert.Equal(t, "0001-01-06T00:00:00Z", lastPostCreatedAt)
+}
+
+func TestErrorOnGetPresignedUrlsForDownloadingByTypeInRepository(t *testing.T) {
+	setUp(t)
+	postType := "VIDEO"
+	dataClient.EXPECT().GetPostsByIndexType(postType, "", "", 2, gomock.Any()).Return(nil, "", "", errors.New("some error"))
+
+	_, _, _, err := getPostRepository.GetPresignedUrlsForDownloadingByType(postType, "", "", 2, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, repositoryLoggerOutput.String(), "Error getting post metadatas for type "+postType)
+}
+
+func TestGetPostWithPresignedUrlsInRepository(t *testing.T) {
+	setUp(t)
+	postId := "usernam1-meuPost-170948521"
+	data := database.Post{
+		PostId:       postId,
+		User:         "username1",
+		Type:         "IMAGE",
+		Title:        "meuPost",
+		Description:  "description",
+		Size:         2048,
+		HasThumbnail: true,
+		CreatedAt:    time.Date(2024, 8, 8, 21, 51, 20, 33, time.UTC).UTC(),
+		LastUpdated:  time.Date(2024, 8, 9, 21, 51, 20, 33, time.UTC).UTC(),
+		Status:       database.PostStatusPublished,
+	}
+	expectedKey := data.User + "/" + data.Type + "/" + data.PostId
+	expectedThumbnailKey := data.User + "/" + data.Type + "/THUMBNAILS/" + data.PostId
+	expectedResult := &get_post.PostDetails{
+		PostId:                data.PostId,
+		User:                  data.User,
+		Type:                  data.Type,
+		Title:                 data.Title,
+		Description:           data.Description,
+		Size:                  data.Size,
+		HasThumbnail:          data.HasThumbnail,
+		CreatedAt:             data.CreatedAt,
+		LastUpdated:           data.LastUpdated,
+		PresignedUrl:          "url",
+		PresignedThumbnailUrl: "thumbnailUrl",
+	}
+	dataClient.EXPECT().GetData("Posts", &database.PostKey{PostId: postId}, &database.Post{}, gomock.Any()).SetArg(2, data)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedKey, gomock.Any()).Return("url", nil)
+	objectClient.EXPECT().GetPreSignedUrlForGettingObject(expectedThumbnailKey, gomock.Any()).Return("thumbnailUrl", nil)
+
+	result, err := getPostRepository.GetPostWithPresignedUrls(postId, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedResult, result)
+}
+
+func TestErrorOnGetPostWithPresignedUrlsInRepositoryWhenPostIsPending(t *testing.T) {
+	setUp(t)
+	postId := "usernam1-meuPost-170948521"
+	data := database.Post{
+		PostId: postId,
+		User:   "username1",
+		Status: database.PostStatusPending,
+	}
+	dataClient.EXPECT().GetData("Posts", &database.PostKey{PostId: postId}, &database.Post{}, gomock.Any()).SetArg(2, data)
+
+	result, err := getPostRepository.GetPostWithPresignedUrls(postId, context.Background())
+
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	assert.Nil(t, result)
+}
+
+func TestErrorOnGetPostWithPresignedUrlsInRepositoryWhenPostIsNotFound(t *testing.T) {
+	setUp(t)
+	postId := "usernam1-meuPost-170948521"
+	dataClient.EXPECT().GetData("Posts", &database.PostKey{PostId: postId}, &database.Post{}, gomock.Any()).Return(database.NewNotFoundError("Posts", postId))
+
+	result, err := getPostRepository.GetPostWithPresignedUrls(postId, context.Background())
+
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	assert.Nil(t, result)
+	assert.Contains(t, repositoryLoggerOutput.String(), "Error getting post metadata for postId "+postId)
+}
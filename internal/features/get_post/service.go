@@ -1,13 +1,19 @@
 package get_post
 
 import (
+	"context"
+	"postservice/internal/tracing"
+	"time"
+
 	"github.com/rs/zerolog/log"
 )
 
 //go:generate mockgen -source=service.go -destination=mock/service.go
 
 type Repository interface {
-	GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int) ([]PostUrl, string, string, error)
+	GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error)
+	GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error)
+	GetPostWithPresignedUrls(postId string, ctx context.Context) (*PostDetails, error)
 }
 
 type GetPostService struct {
@@ -20,18 +26,66 @@ type PostUrl struct {
 	PresignedThumbnailUrl string `json:"thumbnailUrl"`
 }
 
+type PostDetails struct {
+	PostId                string    `json:"postId"`
+	User                  string    `json:"username"`
+	Type                  string    `json:"type"`
+	Title                 string    `json:"title"`
+	Description           string    `json:"description"`
+	Size                  int       `json:"size"`
+	HasThumbnail          bool      `json:"hasThumbnail"`
+	CreatedAt             time.Time `json:"createdAt"`
+	LastUpdated           time.Time `json:"lastUpdated"`
+	Version               int       `json:"version"`
+	PresignedUrl          string    `json:"url"`
+	PresignedThumbnailUrl string    `json:"thumbnailUrl"`
+}
+
 func NewGetPostService(repository Repository) *GetPostService {
 	return &GetPostService{
 		repository: repository,
 	}
 }
 
-func (s *GetPostService) GetUserPosts(username, lastPostId, lastPostCreatedAt string, limit int) ([]PostUrl, string, string, error) {
-	postUrls, lastPostId, lastPostCreatedAt, err := s.repository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit)
+func (s *GetPostService) GetUserPosts(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error) {
+	ctx, span := tracing.Start(ctx, "GetPostService.GetUserPosts")
+	defer span.End()
+
+	postUrls, lastPostId, lastPostCreatedAt, err := s.repository.GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, ctx)
 	if err != nil {
+		tracing.Fail(span, err)
 		return postUrls, lastPostId, lastPostCreatedAt, err
 	}
 
 	log.Info().Msgf("%s's Pre-Signed Url Posts were generated", username)
 	return postUrls, lastPostId, lastPostCreatedAt, nil
 }
+
+func (s *GetPostService) GetPostsByType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) ([]PostUrl, string, string, error) {
+	ctx, span := tracing.Start(ctx, "GetPostService.GetPostsByType")
+	defer span.End()
+
+	postUrls, lastPostId, lastPostCreatedAt, err := s.repository.GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt, limit, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return postUrls, lastPostId, lastPostCreatedAt, err
+	}
+
+	log.Info().Msgf("%s Pre-Signed Url Posts were generated", postType)
+	return postUrls, lastPostId, lastPostCreatedAt, nil
+}
+
+func (s *GetPostService) GetPost(postId string, ctx context.Context) (*PostDetails, error) {
+	ctx, span := tracing.Start(ctx, "GetPostService.GetPost")
+	defer span.End()
+
+	post, err := s.repository.GetPostWithPresignedUrls(postId, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error getting Post %s", postId)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	log.Info().Msgf("Post %s Pre-Signed Urls were generated", postId)
+	return post, nil
+}
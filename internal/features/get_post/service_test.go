@@ -2,6 +2,7 @@ package get_post_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"postservice/internal/features/get_post"
 	mock_get_post "postservice/internal/features/get_post/mock"
@@ -47,9 +48,9 @@ func TestGetUserPostsWithService(t *testing.T) {
 			PresignedThumbnailUrl: "thumbnailUrl3",
 		},
 	}
-	serviceRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
+	serviceRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(expectedPresignedUrls, "post7", "0001-01-06T00:00:00Z", nil)
 
-	getPostService.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit)
+	getPostService.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit, context.Background())
 
 	assert.Contains(t, serviceLoggerOutput.String(), username+"'s Pre-Signed Url Posts were generated")
 }
@@ -60,9 +61,58 @@ func TestErrorOnGetUserPostsWithServiceWhenGettingUrls(t *testing.T) {
 	lastPostId := "post4"
 	lastPostCreatedAt := "0001-01-03T00:00:00Z"
 	limit := 2
-	serviceRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit).Return(nil, "", "", errors.New("some error"))
+	serviceRepository.EXPECT().GetPresignedUrlsForDownloading(username, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(nil, "", "", errors.New("some error"))
 
-	getPostService.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit)
+	getPostService.GetUserPosts(username, lastPostId, lastPostCreatedAt, limit, context.Background())
 
 	assert.NotContains(t, serviceLoggerOutput.String(), username+"'s Pre-Signed Url Posts were generated")
 }
+
+func TestGetPostsByTypeWithService(t *testing.T) {
+	setUpService(t)
+	postType := "VIDEO"
+	lastPostId := "post4"
+	lastPostCreatedAt := "0001-01-03T00:00:00Z"
+	limit := 3
+	expectedPresignedUrls := []get_post.PostUrl{
+		{
+			PostId:                "post1",
+			PresignedUrl:          "url1",
+			PresignedThumbnailUrl: "thumbnailUrl1",
+		},
+	}
+	serviceRepository.EXPECT().GetPresignedUrlsForDownloadingByType(postType, lastPostId, lastPostCreatedAt, limit, gomock.Any()).Return(expectedPresignedUrls, "", "", nil)
+
+	getPostService.GetPostsByType(postType, lastPostId, lastPostCreatedAt, limit, context.Background())
+
+	assert.Contains(t, serviceLoggerOutput.String(), postType+" Pre-Signed Url Posts were generated")
+}
+
+func TestGetPostWithService(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	expectedPost := &get_post.PostDetails{
+		PostId:       postId,
+		User:         "username1",
+		PresignedUrl: "url1",
+	}
+	serviceRepository.EXPECT().GetPostWithPresignedUrls(postId, gomock.Any()).Return(expectedPost, nil)
+
+	post, err := getPostService.GetPost(postId, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedPost, post)
+	assert.Contains(t, serviceLoggerOutput.String(), "Post post1 Pre-Signed Urls were generated")
+}
+
+func TestErrorOnGetPostWithService(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	serviceRepository.EXPECT().GetPostWithPresignedUrls(postId, gomock.Any()).Return(nil, errors.New("some error"))
+
+	post, err := getPostService.GetPost(postId, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Nil(t, post)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error getting Post post1")
+}
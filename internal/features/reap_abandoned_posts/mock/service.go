@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_reap_abandoned_posts is a generated GoMock package.
+package mock_reap_abandoned_posts
+
+import (
+	context "context"
+	reap_abandoned_posts "postservice/internal/features/reap_abandoned_posts"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AbortUpload mocks base method.
+func (m *MockRepository) AbortUpload(post *reap_abandoned_posts.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortUpload", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortUpload indicates an expected call of AbortUpload.
+func (mr *MockRepositoryMockRecorder) AbortUpload(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortUpload", reflect.TypeOf((*MockRepository)(nil).AbortUpload), post, ctx)
+}
+
+// ClaimPost mocks base method.
+func (m *MockRepository) ClaimPost(post *reap_abandoned_posts.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPost", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimPost indicates an expected call of ClaimPost.
+func (mr *MockRepositoryMockRecorder) ClaimPost(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPost", reflect.TypeOf((*MockRepository)(nil).ClaimPost), post, ctx)
+}
+
+// DeleteObjects mocks base method.
+func (m *MockRepository) DeleteObjects(post *reap_abandoned_posts.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObjects", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObjects indicates an expected call of DeleteObjects.
+func (mr *MockRepositoryMockRecorder) DeleteObjects(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockRepository)(nil).DeleteObjects), post, ctx)
+}
+
+// GetAbandonedPosts mocks base method.
+func (m *MockRepository) GetAbandonedPosts(createdBefore time.Time, ctx context.Context) ([]*reap_abandoned_posts.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAbandonedPosts", createdBefore, ctx)
+	ret0, _ := ret[0].([]*reap_abandoned_posts.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAbandonedPosts indicates an expected call of GetAbandonedPosts.
+func (mr *MockRepositoryMockRecorder) GetAbandonedPosts(createdBefore, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAbandonedPosts", reflect.TypeOf((*MockRepository)(nil).GetAbandonedPosts), createdBefore, ctx)
+}
+
+// RemovePostMetadata mocks base method.
+func (m *MockRepository) RemovePostMetadata(post *reap_abandoned_posts.Post, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemovePostMetadata", post, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePostMetadata indicates an expected call of RemovePostMetadata.
+func (mr *MockRepositoryMockRecorder) RemovePostMetadata(post, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePostMetadata", reflect.TypeOf((*MockRepository)(nil).RemovePostMetadata), post, ctx)
+}
@@ -0,0 +1,90 @@
+package reap_abandoned_posts
+
+import (
+	"context"
+	database "postservice/internal/db"
+	objectstorage "postservice/internal/objectStorage"
+	"time"
+)
+
+type ReapAbandonedPostsRepository struct {
+	dataRepository   *database.Database
+	objectRepository *objectstorage.ObjectStorage
+}
+
+func NewReapAbandonedPostsRepository(dataRepository *database.Database, objectRepository *objectstorage.ObjectStorage) *ReapAbandonedPostsRepository {
+	return &ReapAbandonedPostsRepository{
+		dataRepository:   dataRepository,
+		objectRepository: objectRepository,
+	}
+}
+
+var timeLayout string = "2006-01-02T15:04:05.000000Z"
+
+// GetAbandonedPosts also returns the posts whose reaping was interrupted and
+// the deleted posts whose content could not be deleted.
+func (r *ReapAbandonedPostsRepository) GetAbandonedPosts(createdBefore time.Time, ctx context.Context) ([]*Post, error) {
+	var posts []*Post
+	for _, status := range []string{database.PostStatusPending, database.PostStatusReaping, database.PostStatusDeleting} {
+		data, err := r.dataRepository.Client.GetPostsByStatusCreatedBefore(status, createdBefore.UTC().Format(timeLayout), ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, post := range data {
+			posts = append(posts, &Post{
+				PostId:    post.PostId,
+				User:      post.User,
+				Type:      post.Type,
+				CreatedAt: post.CreatedAt,
+				Status:    post.Status,
+				UploadId:  post.UploadId,
+				Version:   post.Version,
+			})
+		}
+	}
+
+	return posts, nil
+}
+
+// ClaimPost moves the post to the reaping status unless it changed since it
+// was read, like when it was confirmed meanwhile.
+func (r *ReapAbandonedPostsRepository) ClaimPost(post *Post, ctx context.Context) error {
+	postKey := &database.PostKey{
+		PostId: post.PostId,
+	}
+	attributes := map[string]any{
+		"Status": database.PostStatusReaping,
+	}
+	err := r.dataRepository.Client.UpdateDataIfVersion("Posts", postKey, attributes, post.Version, ctx)
+	if err != nil {
+		return err
+	}
+
+	post.Status = database.PostStatusReaping
+	post.Version++
+	return nil
+}
+
+func (r *ReapAbandonedPostsRepository) AbortUpload(post *Post, ctx context.Context) error {
+	return r.objectRepository.Client.AbortMultipartUpload(contentKey(post), post.UploadId, ctx)
+}
+
+func (r *ReapAbandonedPostsRepository) DeleteObjects(post *Post, ctx context.Context) error {
+	return r.objectRepository.Client.DeleteObjects([]string{contentKey(post), thumbnailKey(post)}, ctx)
+}
+
+func (r *ReapAbandonedPostsRepository) RemovePostMetadata(post *Post, ctx context.Context) error {
+	postKey := &database.PostKey{
+		PostId: post.PostId,
+	}
+	return r.dataRepository.Client.RemoveDataIfVersion("Posts", postKey, post.Version, ctx)
+}
+
+func contentKey(post *Post) string {
+	return post.User + "/" + post.Type + "/" + post.PostId
+}
+
+func thumbnailKey(post *Post) string {
+	return post.User + "/" + post.Type + "/THUMBNAILS/" + post.PostId
+}
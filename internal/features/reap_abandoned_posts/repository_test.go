@@ -0,0 +1,114 @@
+package reap_abandoned_posts_test
+
+import (
+	"context"
+	database "postservice/internal/db"
+	mock_database "postservice/internal/db/mock"
+	"postservice/internal/features/reap_abandoned_posts"
+	objectstorage "postservice/internal/objectStorage"
+	mock_objectstorage "postservice/internal/objectStorage/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var dbClient *mock_database.MockDatabaseClient
+var osClient *mock_objectstorage.MockObjectStorageClient
+var reapAbandonedPostsRepository *reap_abandoned_posts.ReapAbandonedPostsRepository
+
+func setUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbClient = mock_database.NewMockDatabaseClient(ctrl)
+	osClient = mock_objectstorage.NewMockObjectStorageClient(ctrl)
+	reapAbandonedPostsRepository = reap_abandoned_posts.NewReapAbandonedPostsRepository(database.NewDatabase(dbClient), objectstorage.NewObjectStorage(osClient))
+}
+
+func TestGetAbandonedPostsInRepository(t *testing.T) {
+	setUp(t)
+	createdBefore := time.Date(2024, 8, 8, 21, 51, 20, 33000, time.UTC)
+	data := []*database.Post{
+		{
+			PostId:    "post1",
+			User:      "username1",
+			Type:      "VIDEO",
+			CreatedAt: time.Date(2024, 8, 7, 21, 51, 20, 0, time.UTC),
+			Status:    database.PostStatusPending,
+			UploadId:  "upload-id",
+			Version:   1,
+		},
+	}
+	reapingData := []*database.Post{
+		{
+			PostId:    "post2",
+			User:      "username1",
+			Type:      "IMAGE",
+			CreatedAt: time.Date(2024, 8, 6, 21, 51, 20, 0, time.UTC),
+			Status:    database.PostStatusReaping,
+			Version:   2,
+		},
+	}
+	dbClient.EXPECT().GetPostsByStatusCreatedBefore(database.PostStatusPending, "2024-08-08T21:51:20.000033Z", gomock.Any()).Return(data, nil)
+	dbClient.EXPECT().GetPostsByStatusCreatedBefore(database.PostStatusReaping, "2024-08-08T21:51:20.000033Z", gomock.Any()).Return(reapingData, nil)
+	dbClient.EXPECT().GetPostsByStatusCreatedBefore(database.PostStatusDeleting, "2024-08-08T21:51:20.000033Z", gomock.Any()).Return(nil, nil)
+
+	posts, err := reapAbandonedPostsRepository.GetAbandonedPosts(createdBefore, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*reap_abandoned_posts.Post{
+		{
+			PostId:    "post1",
+			User:      "username1",
+			Type:      "VIDEO",
+			CreatedAt: data[0].CreatedAt,
+			Status:    database.PostStatusPending,
+			UploadId:  "upload-id",
+			Version:   1,
+		},
+		{
+			PostId:    "post2",
+			User:      "username1",
+			Type:      "IMAGE",
+			CreatedAt: reapingData[0].CreatedAt,
+			Status:    database.PostStatusReaping,
+			Version:   2,
+		},
+	}, posts)
+}
+
+func TestClaimPostInRepository(t *testing.T) {
+	setUp(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", Status: database.PostStatusPending, Version: 1}
+	dbClient.EXPECT().UpdateDataIfVersion("Posts", &database.PostKey{PostId: "post1"}, map[string]any{"Status": database.PostStatusReaping}, 1, gomock.Any()).Return(nil)
+
+	err := reapAbandonedPostsRepository.ClaimPost(post, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, database.PostStatusReaping, post.Status)
+	assert.Equal(t, 2, post.Version)
+}
+
+func TestAbortUploadInRepository(t *testing.T) {
+	setUp(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", UploadId: "upload-id"}
+	osClient.EXPECT().AbortMultipartUpload("username1/VIDEO/post1", "upload-id", gomock.Any())
+
+	reapAbandonedPostsRepository.AbortUpload(post, context.Background())
+}
+
+func TestDeleteObjectsInRepository(t *testing.T) {
+	setUp(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO"}
+	osClient.EXPECT().DeleteObjects([]string{"username1/VIDEO/post1", "username1/VIDEO/THUMBNAILS/post1"}, gomock.Any())
+
+	reapAbandonedPostsRepository.DeleteObjects(post, context.Background())
+}
+
+func TestRemovePostMetadataInRepository(t *testing.T) {
+	setUp(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", Version: 1}
+	dbClient.EXPECT().RemoveDataIfVersion("Posts", &database.PostKey{PostId: "post1"}, 1, gomock.Any())
+
+	reapAbandonedPostsRepository.RemovePostMetadata(post, context.Background())
+}
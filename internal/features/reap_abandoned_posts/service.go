@@ -0,0 +1,129 @@
+package reap_abandoned_posts
+
+import (
+	"context"
+	"errors"
+	database "postservice/internal/db"
+	"postservice/internal/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:generate mockgen -source=service.go -destination=mock/service.go
+
+type Repository interface {
+	GetAbandonedPosts(createdBefore time.Time, ctx context.Context) ([]*Post, error)
+	ClaimPost(post *Post, ctx context.Context) error
+	AbortUpload(post *Post, ctx context.Context) error
+	DeleteObjects(post *Post, ctx context.Context) error
+	RemovePostMetadata(post *Post, ctx context.Context) error
+}
+
+var postsReaped = metrics.NewCounter("postservice_posts_reaped_total", "Abandoned pending posts reaped.")
+
+type ReapAbandonedPostsService struct {
+	repository  Repository
+	ttl         time.Duration
+	interval    time.Duration
+	reapedPosts atomic.Int64
+}
+
+type Post struct {
+	PostId    string
+	User      string
+	Type      string
+	CreatedAt time.Time
+	Status    string
+	UploadId  string
+	Version   int
+}
+
+func NewReapAbandonedPostsService(repository Repository, ttl, interval time.Duration) *ReapAbandonedPostsService {
+	return &ReapAbandonedPostsService{
+		repository: repository,
+		ttl:        ttl,
+		interval:   interval,
+	}
+}
+
+func (s *ReapAbandonedPostsService) Run(ctx context.Context) {
+	log.Info().Msgf("Reaping posts not confirmed after %s every %s", s.ttl, s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.ReapAbandonedPosts(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *ReapAbandonedPostsService) ReapAbandonedPosts(ctx context.Context) {
+	createdBefore := time.Now().UTC().Add(-s.ttl)
+	posts, err := s.repository.GetAbandonedPosts(createdBefore, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error getting abandoned posts")
+		return
+	}
+
+	for _, post := range posts {
+		err := s.reapPost(post, ctx)
+		if err != nil {
+			continue
+		}
+
+		reapedPosts := s.reapedPosts.Add(1)
+		postsReaped.Inc()
+		log.Info().Msgf("Abandoned Post %s created at %s was reaped (%d reaped so far)", post.PostId, post.CreatedAt.Format(time.RFC3339), reapedPosts)
+	}
+}
+
+func (s *ReapAbandonedPostsService) ReapedPosts() int64 {
+	return s.reapedPosts.Load()
+}
+
+// reapPost claims the post before touching its objects, so a post confirmed
+// meanwhile keeps them. Deleted posts can't be confirmed anymore.
+func (s *ReapAbandonedPostsService) reapPost(post *Post, ctx context.Context) error {
+	if post.Status != database.PostStatusReaping && post.Status != database.PostStatusDeleting {
+		err := s.repository.ClaimPost(post, ctx)
+		var conflictError *database.ConflictError
+		if errors.As(err, &conflictError) {
+			log.Info().Msgf("Abandoned Post %s changed since it was read, it is not reaped", post.PostId)
+			return err
+		}
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error claiming abandoned Post %s", post.PostId)
+			return err
+		}
+	}
+
+	if post.UploadId != "" {
+		err := s.repository.AbortUpload(post, ctx)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Error aborting upload of abandoned Post %s", post.PostId)
+			return err
+		}
+	}
+
+	err := s.repository.DeleteObjects(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error deleting objects of abandoned Post %s", post.PostId)
+		return err
+	}
+
+	err = s.repository.RemovePostMetadata(post, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error removing metadata of abandoned Post %s", post.PostId)
+		return err
+	}
+
+	return nil
+}
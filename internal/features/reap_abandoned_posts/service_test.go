@@ -0,0 +1,149 @@
+package reap_abandoned_posts_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	database "postservice/internal/db"
+	"postservice/internal/features/reap_abandoned_posts"
+	mock_reap_abandoned_posts "postservice/internal/features/reap_abandoned_posts/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var serviceLoggerOutput bytes.Buffer
+var serviceRepository *mock_reap_abandoned_posts.MockRepository
+var reaper *reap_abandoned_posts.ReapAbandonedPostsService
+
+func setUpService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	serviceRepository = mock_reap_abandoned_posts.NewMockRepository(ctrl)
+	serviceLoggerOutput.Reset()
+	log.Logger = log.Output(&serviceLoggerOutput)
+	reaper = reap_abandoned_posts.NewReapAbandonedPostsService(serviceRepository, time.Hour, time.Minute)
+}
+
+func TestReapAbandonedPostsWithService(t *testing.T) {
+	setUpService(t)
+	multipartPost := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", UploadId: "upload-id"}
+	singlePartPost := &reap_abandoned_posts.Post{PostId: "post2", User: "username1", Type: "IMAGE"}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).DoAndReturn(func(createdBefore time.Time, ctx context.Context) ([]*reap_abandoned_posts.Post, error) {
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), createdBefore, time.Minute)
+		return []*reap_abandoned_posts.Post{multipartPost, singlePartPost}, nil
+	})
+	gomock.InOrder(
+		serviceRepository.EXPECT().ClaimPost(multipartPost, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().AbortUpload(multipartPost, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().DeleteObjects(multipartPost, gomock.Any()).Return(nil),
+		serviceRepository.EXPECT().RemovePostMetadata(multipartPost, gomock.Any()).Return(nil),
+	)
+	serviceRepository.EXPECT().ClaimPost(singlePartPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeleteObjects(singlePartPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemovePostMetadata(singlePartPost, gomock.Any()).Return(nil)
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(2), reaper.ReapedPosts())
+	assert.Contains(t, serviceLoggerOutput.String(), "Abandoned Post post1")
+	assert.Contains(t, serviceLoggerOutput.String(), "Abandoned Post post2")
+}
+
+func TestReapAbandonedPostsWithService_KeepsMetadataWhenAbortFails(t *testing.T) {
+	setUpService(t)
+	failingPost := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", UploadId: "upload-id"}
+	post := &reap_abandoned_posts.Post{PostId: "post2", User: "username1", Type: "IMAGE"}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return([]*reap_abandoned_posts.Post{failingPost, post}, nil)
+	serviceRepository.EXPECT().ClaimPost(failingPost, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().AbortUpload(failingPost, gomock.Any()).Return(errors.New("some error"))
+	serviceRepository.EXPECT().ClaimPost(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemovePostMetadata(post, gomock.Any()).Return(nil)
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(1), reaper.ReapedPosts())
+	assert.Contains(t, serviceLoggerOutput.String(), "Error aborting upload of abandoned Post post1")
+}
+
+func TestReapAbandonedPostsWithService_ErrorDeletingObjects(t *testing.T) {
+	setUpService(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "IMAGE"}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return([]*reap_abandoned_posts.Post{post}, nil)
+	serviceRepository.EXPECT().ClaimPost(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(errors.New("some error"))
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(0), reaper.ReapedPosts())
+	assert.Contains(t, serviceLoggerOutput.String(), "Error deleting objects of abandoned Post post1")
+}
+
+func TestReapAbandonedPostsWithService_KeepsObjectsOfPostsConfirmedMeanwhile(t *testing.T) {
+	setUpService(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "VIDEO", UploadId: "upload-id", Version: 1}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return([]*reap_abandoned_posts.Post{post}, nil)
+	serviceRepository.EXPECT().ClaimPost(post, gomock.Any()).Return(database.NewConflictError("Posts", "post1", "expected version 1, found 2"))
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(0), reaper.ReapedPosts())
+	assert.Contains(t, serviceLoggerOutput.String(), "Abandoned Post post1 changed since it was read")
+}
+
+func TestReapAbandonedPostsWithService_ResumesClaimedPosts(t *testing.T) {
+	setUpService(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "IMAGE", Status: database.PostStatusReaping, Version: 2}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return([]*reap_abandoned_posts.Post{post}, nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemovePostMetadata(post, gomock.Any()).Return(nil)
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(1), reaper.ReapedPosts())
+}
+
+func TestReapAbandonedPostsWithService_DeletesContentOfDeletedPosts(t *testing.T) {
+	setUpService(t)
+	post := &reap_abandoned_posts.Post{PostId: "post1", User: "username1", Type: "IMAGE", Status: database.PostStatusDeleting, Version: 4}
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return([]*reap_abandoned_posts.Post{post}, nil)
+	serviceRepository.EXPECT().DeleteObjects(post, gomock.Any()).Return(nil)
+	serviceRepository.EXPECT().RemovePostMetadata(post, gomock.Any()).Return(nil)
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Equal(t, int64(1), reaper.ReapedPosts())
+}
+
+func TestReapAbandonedPostsWithService_ErrorGettingPosts(t *testing.T) {
+	setUpService(t)
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).Return(nil, errors.New("some error"))
+
+	reaper.ReapAbandonedPosts(context.Background())
+
+	assert.Contains(t, serviceLoggerOutput.String(), "Error getting abandoned posts")
+}
+
+func TestRunReapAbandonedPostsWithServiceStopsOnCancel(t *testing.T) {
+	setUpService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	serviceRepository.EXPECT().GetAbandonedPosts(gomock.Any(), gomock.Any()).DoAndReturn(func(createdBefore time.Time, ctx context.Context) ([]*reap_abandoned_posts.Post, error) {
+		cancel()
+		return nil, nil
+	})
+	done := make(chan struct{})
+
+	go func() {
+		reaper.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,74 @@
+package update_post
+
+import (
+	"errors"
+	"fmt"
+	"postservice/internal/api"
+	database "postservice/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type UpdatePostController struct {
+	service *UpdatePostService
+}
+
+func NewUpdatePostController(repository Repository) *UpdatePostController {
+	return &UpdatePostController{
+		service: NewUpdatePostService(repository),
+	}
+}
+
+func (controller *UpdatePostController) Routes(routerGroup *gin.RouterGroup) {
+	routerGroup.PUT("/post/:postId", controller.UpdatePost)
+}
+
+func (controller *UpdatePostController) UpdatePost(c *gin.Context) {
+	log.Info().Msg("Handling Request PUT UpdatePost")
+	postId := c.Param("postId")
+
+	var updatedPost UpdatedPost
+	if err := c.BindJSON(&updatedPost); err != nil {
+		log.Error().Stack().Err(err).Msg("Invalid Data")
+		api.SendError(c, api.NewValidationError("Invalid Json Request"))
+		return
+	}
+	if updatedPost.Title == nil && updatedPost.Description == nil {
+		api.SendError(c, api.NewValidationError("Nothing to update, title or description has to have value"))
+		return
+	}
+	if fieldErrors := api.ValidatePostText(updatedPost.Title, updatedPost.Description); len(fieldErrors) > 0 {
+		api.SendError(c, api.NewFieldsValidationError(fieldErrors))
+		return
+	}
+	updatedPost.PostId = postId
+	updatedPost.User = api.GetUsername(c)
+	version, err := api.IfMatchVersion(c)
+	if err != nil {
+		api.SendError(c, err)
+		return
+	}
+	updatedPost.Version = version
+
+	post, err := controller.service.UpdatePost(&updatedPost, c.Request.Context())
+	if err != nil {
+		var notFoundError *database.NotFoundError
+		var forbiddenError *database.ForbiddenError
+		var conflictError *database.ConflictError
+		if errors.As(err, &notFoundError) {
+			err = api.NewNotFoundError(fmt.Sprintf("Post not found for post id %s", postId), err)
+		} else if errors.As(err, &forbiddenError) {
+			err = api.NewForbiddenError(fmt.Sprintf("Post %s does not belong to user %s", postId, updatedPost.User), err)
+		} else if errors.As(err, &conflictError) && updatedPost.Version != nil {
+			err = api.NewPreconditionFailedError(fmt.Sprintf("Post %s does not have the version of If-Match", postId), err)
+		} else if errors.As(err, &conflictError) {
+			err = api.NewConflictError(fmt.Sprintf("Post %s was modified concurrently, try again", postId), err)
+		}
+		api.SendError(c, err)
+		return
+	}
+
+	api.SetETag(c, post.Version)
+	api.SendOKWithResult(c, post)
+}
@@ -0,0 +1,282 @@
+package update_post_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"postservice/internal/api"
+	database "postservice/internal/db"
+	"postservice/internal/features/update_post"
+	mock_update_post "postservice/internal/features/update_post/mock"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog/log"
+)
+
+var controllerLoggerOutput bytes.Buffer
+var controllerRepository *mock_update_post.MockRepository
+var controller *update_post.UpdatePostController
+var apiResponse *httptest.ResponseRecorder
+var ginContext *gin.Context
+
+func setUpHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	controllerRepository = mock_update_post.NewMockRepository(ctrl)
+	log.Logger = log.Output(&controllerLoggerOutput)
+	controller = update_post.NewUpdatePostController(controllerRepository)
+	gin.SetMode(gin.TestMode)
+	apiResponse = httptest.NewRecorder()
+	ginContext, _ = gin.CreateTestContext(apiResponse)
+	api.SetIdentity(ginContext, &api.Identity{Username: "username1"})
+}
+
+func TestUpdatePost(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	storedPost := &update_post.Post{
+		PostId:      postId,
+		User:        "username1",
+		Type:        "TEXT",
+		Title:       "Meu Post",
+		Description: "Descricion",
+		CreatedAt:   "2024-08-08T21:51:20.000000Z",
+		LastUpdated: "2024-08-08T21:51:20.000000Z",
+		Version:     3,
+	}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(storedPost, nil)
+	controllerRepository.EXPECT().UpdatePostMetadata(storedPost, 3, gomock.Any(), gomock.Any()).Return(nil)
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 200)
+	assert.Equal(t, apiResponse.Header().Get("ETag"), `"4"`)
+	var response struct {
+		Content update_post.Post `json:"content"`
+	}
+	json.Unmarshal(apiResponse.Body.Bytes(), &response)
+	assert.Equal(t, response.Content.Title, "Novo titulo")
+	assert.Equal(t, response.Content.Description, "Descricion")
+}
+
+func TestUpdatePost_InvalidJson(t *testing.T) {
+	setUpHandler(t)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBufferString("{"))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid Json Request",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_NothingToUpdate(t *testing.T) {
+	setUpHandler(t)
+	data := []byte(`{}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Nothing to update, title or description has to have value",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_InvalidFields(t *testing.T) {
+	setUpHandler(t)
+	data := []byte(`{"title":"Meu/Post","description":"` + strings.Repeat("a", 2001) + `"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid request fields",
+		"fields": [
+			{"field": "title", "message": "can't contain '/', '\\' or control characters"},
+			{"field": "description", "message": "can have at most 2000 characters"}
+		],
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_BlankTitle(t *testing.T) {
+	setUpHandler(t)
+	data := []byte(`{"title":"  "}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "VALIDATION_FAILED",
+		"message": "Invalid request fields",
+		"fields": [
+			{"field": "title", "message": "is required"}
+		],
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_NotFound(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(nil, database.NewNotFoundError("Posts", postId))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "NOT_FOUND",
+		"message": "Post not found for post id post1",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 404)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_Forbidden(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&update_post.Post{PostId: postId, User: "username2"}, nil)
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "FORBIDDEN",
+		"message": "Post post1 does not belong to user username1",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 403)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_IfMatchDoesNotMatch(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Request.Header.Set("If-Match", `"2"`)
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&update_post.Post{PostId: postId, User: "username1", Version: 3}, nil)
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "PRECONDITION_FAILED",
+		"message": "Post post1 does not have the version of If-Match",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 412)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_InvalidIfMatch(t *testing.T) {
+	setUpHandler(t)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBufferString(`{"title":"Novo titulo"}`))
+	ginContext.Request.Header.Set("If-Match", "version-2")
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 400)
+}
+
+func TestUpdatePost_WeakIfMatch(t *testing.T) {
+	setUpHandler(t)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/post1", bytes.NewBufferString(`{"title":"Novo titulo"}`))
+	ginContext.Request.Header.Set("If-Match", `W/"3"`)
+	ginContext.Params = []gin.Param{{Key: "postId", Value: "post1"}}
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "PRECONDITION_FAILED",
+		"message": "If-Match can't match a weak entity tag",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 412)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_ConcurrentUpdate(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	storedPost := &update_post.Post{PostId: postId, User: "username1", Version: 3}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(storedPost, nil)
+	controllerRepository.EXPECT().UpdatePostMetadata(storedPost, 3, gomock.Any(), gomock.Any()).Return(database.NewConflictError("Posts", postId, "expected version 3, found 4"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "CONFLICT",
+		"message": "Post post1 was modified concurrently, try again",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 409)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func TestUpdatePost_InternalServerError(t *testing.T) {
+	setUpHandler(t)
+	postId := "post1"
+	data := []byte(`{"title":"Novo titulo"}`)
+	ginContext.Request = httptest.NewRequest(http.MethodPut, "/post/"+postId, bytes.NewBuffer(data))
+	ginContext.Params = []gin.Param{{Key: "postId", Value: postId}}
+	controllerRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(nil, errors.New("some error"))
+	expectedBodyResponse := `{
+		"error": true,
+		"code": "INTERNAL_ERROR",
+		"message": "Internal server error",
+		"content": null
+	}`
+
+	controller.UpdatePost(ginContext)
+
+	assert.Equal(t, apiResponse.Code, 500)
+	assert.Equal(t, removeSpace(apiResponse.Body.String()), removeSpace(expectedBodyResponse))
+}
+
+func removeSpace(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), "\t", ""), "\n", "")
+}
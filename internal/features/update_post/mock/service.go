@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_update_post is a generated GoMock package.
+package mock_update_post
+
+import (
+	context "context"
+	bus "postservice/internal/bus"
+	update_post "postservice/internal/features/update_post"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetPostMetadata mocks base method.
+func (m *MockRepository) GetPostMetadata(postId string, ctx context.Context) (*update_post.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostMetadata", postId, ctx)
+	ret0, _ := ret[0].(*update_post.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostMetadata indicates an expected call of GetPostMetadata.
+func (mr *MockRepositoryMockRecorder) GetPostMetadata(postId, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostMetadata", reflect.TypeOf((*MockRepository)(nil).GetPostMetadata), postId, ctx)
+}
+
+// UpdatePostMetadata mocks base method.
+func (m *MockRepository) UpdatePostMetadata(post *update_post.Post, expectedVersion int, event *bus.Event, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostMetadata", post, expectedVersion, event, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePostMetadata indicates an expected call of UpdatePostMetadata.
+func (mr *MockRepositoryMockRecorder) UpdatePostMetadata(post, expectedVersion, event, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostMetadata", reflect.TypeOf((*MockRepository)(nil).UpdatePostMetadata), post, expectedVersion, event, ctx)
+}
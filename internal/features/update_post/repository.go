@@ -0,0 +1,57 @@
+package update_post
+
+import (
+	"context"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"postservice/internal/tracing"
+)
+
+type UpdatePostRepository struct {
+	dataRepository *database.Database
+}
+
+func NewUpdatePostRepository(dataRepository *database.Database) *UpdatePostRepository {
+	return &UpdatePostRepository{
+		dataRepository: dataRepository,
+	}
+}
+
+func (r *UpdatePostRepository) GetPostMetadata(postId string, ctx context.Context) (*Post, error) {
+	postKey := &database.PostKey{
+		PostId: postId,
+	}
+	var post Post
+	err := r.dataRepository.Client.GetData("Posts", postKey, &post, ctx)
+
+	return &post, err
+}
+
+func (r *UpdatePostRepository) UpdatePostMetadata(post *Post, expectedVersion int, event *bus.Event, ctx context.Context) error {
+	outboxEvent, err := database.NewOutboxEvent(event.Type, event.Data, tracing.Inject(ctx))
+	if err != nil {
+		return err
+	}
+
+	postKey := &database.PostKey{
+		PostId: post.PostId,
+	}
+	operations := []database.TransactionOperation{
+		&database.UpdateOperation{
+			TableName: "Posts",
+			Key:       postKey,
+			Attributes: map[string]any{
+				"Title":       post.Title,
+				"Description": post.Description,
+				"LastUpdated": post.LastUpdated,
+			},
+			ExpectedVersion: &expectedVersion,
+		},
+		&database.InsertOperation{
+			TableName: "Outbox",
+			Item:      outboxEvent,
+		},
+	}
+
+	return r.dataRepository.Client.ExecuteTransaction(operations, ctx)
+}
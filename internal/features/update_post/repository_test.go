@@ -0,0 +1,72 @@
+package update_post_test
+
+import (
+	"context"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	mock_database "postservice/internal/db/mock"
+	"postservice/internal/features/update_post"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var dbClient *mock_database.MockDatabaseClient
+var updatePostRepository *update_post.UpdatePostRepository
+
+func setUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dbClient = mock_database.NewMockDatabaseClient(ctrl)
+	updatePostRepository = update_post.NewUpdatePostRepository(database.NewDatabase(dbClient))
+}
+
+func TestGetPostMetadataInRepository(t *testing.T) {
+	setUp(t)
+	var post update_post.Post
+	postId := "username1-Meu_Post-1723153880"
+	expectedKey := &database.PostKey{
+		PostId: postId,
+	}
+	dbClient.EXPECT().GetData("Posts", expectedKey, &post, gomock.Any())
+
+	updatePostRepository.GetPostMetadata(postId, context.Background())
+}
+
+func TestUpdatePostMetadataInRepository(t *testing.T) {
+	setUp(t)
+	post := &update_post.Post{
+		PostId:      "username1-Meu_Post-1723153880",
+		User:        "username1",
+		Title:       "Novo titulo",
+		Description: "Nova descricion",
+		LastUpdated: "2024-08-09T21:51:20.000000Z",
+	}
+	expectedKey := &database.PostKey{
+		PostId: post.PostId,
+	}
+	expectedAttributes := map[string]any{
+		"Title":       post.Title,
+		"Description": post.Description,
+		"LastUpdated": post.LastUpdated,
+	}
+	expectedVersion := 2
+	event := &bus.Event{
+		Type: "PostWasUpdatedEvent",
+		Data: []byte(`{"post_id":"username1-Meu_Post-1723153880"}`),
+	}
+	dbClient.EXPECT().ExecuteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(operations []database.TransactionOperation, ctx context.Context) error {
+		assert.Len(t, operations, 2)
+		assert.Equal(t, &database.UpdateOperation{TableName: "Posts", Key: expectedKey, Attributes: expectedAttributes, ExpectedVersion: &expectedVersion}, operations[0])
+		outboxInsert := operations[1].(*database.InsertOperation)
+		outboxEvent := outboxInsert.Item.(*database.OutboxEvent)
+		assert.Equal(t, "Outbox", outboxInsert.TableName)
+		assert.Equal(t, event.Type, outboxEvent.Type)
+		assert.Equal(t, event.Data, outboxEvent.Data)
+		return nil
+	})
+
+	err := updatePostRepository.UpdatePostMetadata(post, expectedVersion, event, context.Background())
+
+	assert.Nil(t, err)
+}
@@ -0,0 +1,121 @@
+package update_post
+
+import (
+	"context"
+	"fmt"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:generate mockgen -source=service.go -destination=mock/service.go
+
+type Repository interface {
+	GetPostMetadata(postId string, ctx context.Context) (*Post, error)
+	UpdatePostMetadata(post *Post, expectedVersion int, event *bus.Event, ctx context.Context) error
+}
+
+type UpdatePostService struct {
+	repository Repository
+}
+
+type Post struct {
+	PostId       string `json:"postId"`
+	User         string `json:"username"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	HasThumbnail bool   `json:"hasThumbnail"`
+	CreatedAt    string `json:"createdAt"`
+	LastUpdated  string `json:"lastUpdated"`
+	Status       string `json:"-"`
+	Version      int    `json:"version"`
+}
+
+type UpdatedPost struct {
+	PostId string `json:"-"`
+	User   string `json:"-"`
+	// Version, when set, is the only version of the post the update applies to.
+	Version     *int    `json:"-"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+}
+
+type PostWasUpdatedEvent struct {
+	PostId   string `json:"post_id"`
+	Metadata *Post  `json:"metadata"`
+}
+
+func NewUpdatePostService(repository Repository) *UpdatePostService {
+	return &UpdatePostService{
+		repository: repository,
+	}
+}
+
+var timeLayout string = "2006-01-02T15:04:05.000000Z"
+
+func (s *UpdatePostService) UpdatePost(updatedPost *UpdatedPost, ctx context.Context) (*Post, error) {
+	post, err := s.repository.GetPostMetadata(updatedPost.PostId, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error retrieving Post %s metadata", updatedPost.PostId)
+		return nil, err
+	}
+
+	// Until its upload is confirmed a post is not visible, so it can't be edited either.
+	if !database.IsPublishedStatus(post.Status) {
+		err = database.NewNotFoundError("Posts", updatedPost.PostId)
+		log.Error().Stack().Err(err).Msgf("Post %s is not published", updatedPost.PostId)
+		return nil, err
+	}
+
+	if post.User != updatedPost.User {
+		err = database.NewForbiddenError("Posts", updatedPost.PostId, updatedPost.User)
+		log.Error().Stack().Err(err).Msgf("User %s is not the owner of Post %s", updatedPost.User, updatedPost.PostId)
+		return nil, err
+	}
+
+	if updatedPost.Version != nil && *updatedPost.Version != post.Version {
+		err = database.NewConflictError("Posts", updatedPost.PostId, fmt.Sprintf("expected version %d, found %d", *updatedPost.Version, post.Version))
+		log.Error().Stack().Err(err).Msgf("Post %s was modified since version %d", updatedPost.PostId, *updatedPost.Version)
+		return nil, err
+	}
+
+	if updatedPost.Title != nil {
+		post.Title = *updatedPost.Title
+	}
+	if updatedPost.Description != nil {
+		post.Description = *updatedPost.Description
+	}
+	post.LastUpdated = time.Now().UTC().Format(timeLayout)
+	expectedVersion := post.Version
+	post.Version++
+
+	event, err := createPostWasUpdatedEvent(post)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.UpdatePostMetadata(post, expectedVersion, event, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error updating Post %s metadata", updatedPost.PostId)
+		return nil, err
+	}
+
+	log.Info().Msgf("Post %s was updated", post.PostId)
+	return post, nil
+}
+
+func createPostWasUpdatedEvent(metadata *Post) (*bus.Event, error) {
+	event, err := bus.NewEvent("PostWasUpdatedEvent", &PostWasUpdatedEvent{
+		PostId:   metadata.PostId,
+		Metadata: metadata,
+	})
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Creating PostWasUpdatedEvent failed")
+		return nil, err
+	}
+
+	return event, nil
+}
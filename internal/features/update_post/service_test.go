@@ -0,0 +1,177 @@
+package update_post_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"postservice/internal/features/update_post"
+	mock_update_post "postservice/internal/features/update_post/mock"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var serviceLoggerOutput bytes.Buffer
+var serviceRepository *mock_update_post.MockRepository
+var updatePostService *update_post.UpdatePostService
+
+func setUpService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	serviceRepository = mock_update_post.NewMockRepository(ctrl)
+	log.Logger = log.Output(&serviceLoggerOutput)
+	updatePostService = update_post.NewUpdatePostService(serviceRepository)
+}
+
+func TestUpdatePostWithService(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	title := "Novo titulo"
+	storedPost := &update_post.Post{
+		PostId:      postId,
+		User:        "username1",
+		Title:       "Meu Post",
+		Description: "Descricion",
+		CreatedAt:   "2024-08-08T21:51:20.000000Z",
+		LastUpdated: "2024-08-08T21:51:20.000000Z",
+	}
+	updatedPost := &update_post.UpdatedPost{
+		PostId: postId,
+		User:   "username1",
+		Title:  &title,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(storedPost, nil)
+	serviceRepository.EXPECT().UpdatePostMetadata(storedPost, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(post *update_post.Post, expectedVersion int, event *bus.Event, ctx context.Context) error {
+		var postWasUpdatedEvent update_post.PostWasUpdatedEvent
+		json.Unmarshal(event.Data, &postWasUpdatedEvent)
+		assert.Equal(t, "PostWasUpdatedEvent", event.Type)
+		assert.Equal(t, postId, postWasUpdatedEvent.PostId)
+		assert.Equal(t, title, postWasUpdatedEvent.Metadata.Title)
+		return nil
+	})
+
+	post, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, title, post.Title)
+	assert.Equal(t, "Descricion", post.Description)
+	assert.NotEqual(t, "2024-08-08T21:51:20.000000Z", post.LastUpdated)
+	assert.Contains(t, serviceLoggerOutput.String(), "Post post1 was updated")
+}
+
+func TestUpdatePostWithService_NotOwner(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	title := "Novo titulo"
+	updatedPost := &update_post.UpdatedPost{
+		PostId: postId,
+		User:   "username2",
+		Title:  &title,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&update_post.Post{PostId: postId, User: "username1"}, nil)
+
+	post, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	var forbiddenError *database.ForbiddenError
+	assert.ErrorAs(t, err, &forbiddenError)
+	assert.Nil(t, post)
+	assert.Contains(t, serviceLoggerOutput.String(), "User username2 is not the owner of Post post1")
+}
+
+func TestUpdatePostWithService_PendingPost(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	title := "Novo titulo"
+	updatedPost := &update_post.UpdatedPost{
+		PostId: postId,
+		User:   "username1",
+		Title:  &title,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&update_post.Post{PostId: postId, User: "username1", Status: database.PostStatusPending}, nil)
+
+	post, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	var notFoundError *database.NotFoundError
+	assert.ErrorAs(t, err, &notFoundError)
+	assert.Nil(t, post)
+	assert.Contains(t, serviceLoggerOutput.String(), "Post post1 is not published")
+}
+
+func TestUpdatePostWithService_VersionDoesNotMatch(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	title := "Novo titulo"
+	expectedVersion := 2
+	updatedPost := &update_post.UpdatedPost{
+		PostId:  postId,
+		User:    "username1",
+		Version: &expectedVersion,
+		Title:   &title,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(&update_post.Post{PostId: postId, User: "username1", Version: 3}, nil)
+
+	post, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	var conflictError *database.ConflictError
+	assert.ErrorAs(t, err, &conflictError)
+	assert.Nil(t, post)
+}
+
+func TestUpdatePostWithService_IncrementsVersion(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	title := "Novo titulo"
+	expectedVersion := 3
+	storedPost := &update_post.Post{PostId: postId, User: "username1", Version: 3}
+	updatedPost := &update_post.UpdatedPost{
+		PostId:  postId,
+		User:    "username1",
+		Version: &expectedVersion,
+		Title:   &title,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(storedPost, nil)
+	serviceRepository.EXPECT().UpdatePostMetadata(storedPost, 3, gomock.Any(), gomock.Any()).Return(nil)
+
+	post, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 4, post.Version)
+}
+
+func TestUpdatePostWithService_ErrorGettingMetadata(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	updatedPost := &update_post.UpdatedPost{
+		PostId: postId,
+		User:   "username1",
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(nil, errors.New("some error"))
+
+	_, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error retrieving Post post1 metadata")
+}
+
+func TestUpdatePostWithService_ErrorUpdatingMetadata(t *testing.T) {
+	setUpService(t)
+	postId := "post1"
+	description := "Nova descricion"
+	storedPost := &update_post.Post{PostId: postId, User: "username1"}
+	updatedPost := &update_post.UpdatedPost{
+		PostId:      postId,
+		User:        "username1",
+		Description: &description,
+	}
+	serviceRepository.EXPECT().GetPostMetadata(postId, gomock.Any()).Return(storedPost, nil)
+	serviceRepository.EXPECT().UpdatePostMetadata(storedPost, gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+	_, err := updatePostService.UpdatePost(updatedPost, context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, serviceLoggerOutput.String(), "Error updating Post post1 metadata")
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 2 * time.Second
+
+const (
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateStopping = "stopping"
+
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker is implemented by the clients that can tell whether their
+// dependency is reachable.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+type CheckFunc func(ctx context.Context) error
+
+type Health struct {
+	mutex  sync.RWMutex
+	state  string
+	checks map[string]CheckFunc
+}
+
+type Report struct {
+	Ready  bool                   `json:"ready"`
+	State  string                 `json:"state"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func NewHealth() *Health {
+	return &Health{
+		state:  StateStarting,
+		checks: make(map[string]CheckFunc),
+	}
+}
+
+func (h *Health) AddCheck(name string, check CheckFunc) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.checks[name] = check
+}
+
+// SetRunning marks the end of the startup, the service is ready from then on
+// as long as its checks pass.
+func (h *Health) SetRunning() {
+	h.setState(StateRunning)
+}
+
+// SetStopping makes the service not ready for the rest of its life.
+func (h *Health) SetStopping() {
+	h.setState(StateStopping)
+}
+
+// Readiness runs every check concurrently, each one bounded by checkTimeout.
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mutex.RLock()
+	state := h.state
+	checks := make(map[string]CheckFunc, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	type namedResult struct {
+		name   string
+		result CheckResult
+	}
+	results := make(chan namedResult, len(checks))
+	for name, check := range checks {
+		go func(name string, check CheckFunc) {
+			results <- namedResult{name: name, result: runCheck(ctx, check)}
+		}(name, check)
+	}
+
+	report := Report{
+		Ready:  state == StateRunning,
+		State:  state,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	for range checks {
+		namedResult := <-results
+		report.Checks[namedResult.name] = namedResult.result
+		if namedResult.result.Status != StatusUp {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+// FailedChecks returns the names of the checks that did not pass, sorted.
+func (r Report) FailedChecks() []string {
+	failed := []string{}
+	for name, result := range r.Checks {
+		if result.Status != StatusUp {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+
+	return failed
+}
+
+func (h *Health) setState(state string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.state = state
+}
+
+// runCheck gives up on a check when ctx is done, even if the check itself
+// ignores ctx and keeps running in the background.
+func runCheck(ctx context.Context, check CheckFunc) CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,83 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"postservice/internal/health"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func succeed(ctx context.Context) error {
+	return nil
+}
+
+func fail(ctx context.Context) error {
+	return errors.New("unreachable")
+}
+
+func TestNotReadyUntilRunning(t *testing.T) {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("database", succeed)
+
+	report := serviceHealth.Readiness(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, health.StateStarting, report.State)
+	assert.Equal(t, health.StatusUp, report.Checks["database"].Status)
+}
+
+func TestReadyWhenRunningAndChecksPass(t *testing.T) {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("database", succeed)
+	serviceHealth.AddCheck("bus", succeed)
+	serviceHealth.SetRunning()
+
+	report := serviceHealth.Readiness(context.Background())
+
+	assert.True(t, report.Ready)
+	assert.Equal(t, health.StateRunning, report.State)
+	assert.Len(t, report.Checks, 2)
+	assert.Empty(t, report.FailedChecks())
+}
+
+func TestNotReadyWhenCheckFails(t *testing.T) {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("database", succeed)
+	serviceHealth.AddCheck("objectStorage", fail)
+	serviceHealth.SetRunning()
+
+	report := serviceHealth.Readiness(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, health.CheckResult{Status: health.StatusDown, LatencyMs: report.Checks["objectStorage"].LatencyMs, Error: "unreachable"}, report.Checks["objectStorage"])
+	assert.Equal(t, []string{"objectStorage"}, report.FailedChecks())
+}
+
+func TestNotReadyWhenCheckHangs(t *testing.T) {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("bus", func(ctx context.Context) error {
+		select {}
+	})
+	serviceHealth.SetRunning()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := serviceHealth.Readiness(ctx)
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, context.Canceled.Error(), report.Checks["bus"].Error)
+}
+
+func TestNotReadyWhenStopping(t *testing.T) {
+	serviceHealth := health.NewHealth()
+	serviceHealth.AddCheck("database", succeed)
+	serviceHealth.SetRunning()
+	serviceHealth.SetStopping()
+
+	report := serviceHealth.Readiness(context.Background())
+
+	assert.False(t, report.Ready)
+	assert.Equal(t, health.StateStopping, report.State)
+}
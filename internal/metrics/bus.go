@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"postservice/internal/bus"
+	"time"
+)
+
+var publishedEvents = NewCounter("postservice_bus_published_events_total", "Events published on the external bus by type and result.", "event_type", "result")
+var publishDuration = NewHistogram("postservice_bus_publish_duration_seconds", "Duration of the publications on the external bus.", DefaultBuckets, "event_type")
+
+// InstrumentedExternalBus counts and times the events published on an
+// ExternalBus.
+type InstrumentedExternalBus struct {
+	externalBus bus.ExternalBus
+}
+
+func NewInstrumentedExternalBus(externalBus bus.ExternalBus) *InstrumentedExternalBus {
+	return &InstrumentedExternalBus{
+		externalBus: externalBus,
+	}
+}
+
+func (eb *InstrumentedExternalBus) Unwrap() bus.ExternalBus {
+	return eb.externalBus
+}
+
+func (eb *InstrumentedExternalBus) Publish(event *bus.Event, ctx context.Context) error {
+	start := time.Now()
+	err := eb.externalBus.Publish(event, ctx)
+	publishDuration.Observe(time.Since(start).Seconds(), event.Type)
+	publishedEvents.Inc(event.Type, result(err))
+
+	return err
+}
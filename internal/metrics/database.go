@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	database "postservice/internal/db"
+	"time"
+)
+
+var databaseOperations = NewCounter("postservice_database_operations_total", "Database operations by operation and result.", "operation", "result")
+var databaseOperationDuration = NewHistogram("postservice_database_operation_duration_seconds", "Duration of the database operations.", DefaultBuckets, "operation")
+
+// InstrumentedDatabaseClient counts and times the calls to a DatabaseClient.
+type InstrumentedDatabaseClient struct {
+	client database.DatabaseClient
+}
+
+func NewInstrumentedDatabaseClient(client database.DatabaseClient) *InstrumentedDatabaseClient {
+	return &InstrumentedDatabaseClient{
+		client: client,
+	}
+}
+
+func (dc *InstrumentedDatabaseClient) Unwrap() database.DatabaseClient {
+	return dc.client
+}
+
+func (dc *InstrumentedDatabaseClient) TableExists(tableName string, ctx context.Context) bool {
+	defer observeDatabase("TableExists", time.Now(), nil)
+	return dc.client.TableExists(tableName, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) IndexExists(tableName, indexName string, ctx context.Context) bool {
+	defer observeDatabase("IndexExists", time.Now(), nil)
+	return dc.client.IndexExists(tableName, indexName, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) CreateTable(tableName string, keys *[]database.TableAttributes, ctx context.Context) (err error) {
+	defer observeDatabase("CreateTable", time.Now(), &err)
+	return dc.client.CreateTable(tableName, keys, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) CreateIndexesOnTable(tableName, indexName string, indexes *[]database.TableAttributes, ctx context.Context) (err error) {
+	defer observeDatabase("CreateIndexesOnTable", time.Now(), &err)
+	return dc.client.CreateIndexesOnTable(tableName, indexName, indexes, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) EnableTimeToLive(tableName, attributeName string, ctx context.Context) (err error) {
+	defer observeDatabase("EnableTimeToLive", time.Now(), &err)
+	return dc.client.EnableTimeToLive(tableName, attributeName, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) InsertData(tableName string, attributes any, ctx context.Context) (err error) {
+	defer observeDatabase("InsertData", time.Now(), &err)
+	return dc.client.InsertData(tableName, attributes, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) InsertDataIfNotExists(tableName, keyAttribute string, attributes any, ctx context.Context) (err error) {
+	defer observeDatabase("InsertDataIfNotExists", time.Now(), &err)
+	return dc.client.InsertDataIfNotExists(tableName, keyAttribute, attributes, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) InsertDataIfMatches(tableName string, attributes any, conditions map[string]any, ctx context.Context) (err error) {
+	defer observeDatabase("InsertDataIfMatches", time.Now(), &err)
+	return dc.client.InsertDataIfMatches(tableName, attributes, conditions, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetData(tableName string, key any, result any, ctx context.Context) (err error) {
+	defer observeDatabase("GetData", time.Now(), &err)
+	return dc.client.GetData(tableName, key, result, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) UpdateData(tableName string, key any, attributes map[string]any, ctx context.Context) (err error) {
+	defer observeDatabase("UpdateData", time.Now(), &err)
+	return dc.client.UpdateData(tableName, key, attributes, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) UpdateDataIfVersion(tableName string, key any, attributes map[string]any, expectedVersion int, ctx context.Context) (err error) {
+	defer observeDatabase("UpdateDataIfVersion", time.Now(), &err)
+	return dc.client.UpdateDataIfVersion(tableName, key, attributes, expectedVersion, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) RemoveData(tableName string, key any, ctx context.Context) (err error) {
+	defer observeDatabase("RemoveData", time.Now(), &err)
+	return dc.client.RemoveData(tableName, key, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) RemoveDataIfVersion(tableName string, key any, expectedVersion int, ctx context.Context) (err error) {
+	defer observeDatabase("RemoveDataIfVersion", time.Now(), &err)
+	return dc.client.RemoveDataIfVersion(tableName, key, expectedVersion, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) RemoveDataIfMatches(tableName string, key any, conditions map[string]any, ctx context.Context) (err error) {
+	defer observeDatabase("RemoveDataIfMatches", time.Now(), &err)
+	return dc.client.RemoveDataIfMatches(tableName, key, conditions, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) RemoveMultipleData(tableName string, keys []any, ctx context.Context) (err error) {
+	defer observeDatabase("RemoveMultipleData", time.Now(), &err)
+	return dc.client.RemoveMultipleData(tableName, keys, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) ExecuteTransaction(operations []database.TransactionOperation, ctx context.Context) (err error) {
+	defer observeDatabase("ExecuteTransaction", time.Now(), &err)
+	return dc.client.ExecuteTransaction(operations, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetPostsByIds(postIds []string, ctx context.Context) (posts []*database.Post, err error) {
+	defer observeDatabase("GetPostsByIds", time.Now(), &err)
+	return dc.client.GetPostsByIds(postIds, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) (posts []*database.Post, nextPostId string, nextPostCreatedAt string, err error) {
+	defer observeDatabase("GetPostsByIndexUser", time.Now(), &err)
+	return dc.client.GetPostsByIndexUser(username, lastPostId, lastPostCreatedAt, limit, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt string, limit int, ctx context.Context) (posts []*database.Post, nextPostId string, nextPostCreatedAt string, err error) {
+	defer observeDatabase("GetPostsByIndexType", time.Now(), &err)
+	return dc.client.GetPostsByIndexType(postType, lastPostId, lastPostCreatedAt, limit, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetPostsByStatusCreatedBefore(status, createdBefore string, ctx context.Context) (posts []*database.Post, err error) {
+	defer observeDatabase("GetPostsByStatusCreatedBefore", time.Now(), &err)
+	return dc.client.GetPostsByStatusCreatedBefore(status, createdBefore, ctx)
+}
+
+func (dc *InstrumentedDatabaseClient) GetPendingOutboxEvents(limit int, ctx context.Context) (events []*database.OutboxEvent, err error) {
+	defer observeDatabase("GetPendingOutboxEvents", time.Now(), &err)
+	return dc.client.GetPendingOutboxEvents(limit, ctx)
+}
+
+// observeDatabase takes a pointer to the named error result so it sees the
+// value returned by the deferring method.
+func observeDatabase(operation string, start time.Time, err *error) {
+	databaseOperationDuration.Observe(time.Since(start).Seconds(), operation)
+	databaseOperations.Inc(operation, databaseResult(err))
+}
+
+func databaseResult(err *error) string {
+	if err == nil || *err == nil {
+		return resultSuccess
+	}
+
+	var notFoundError *database.NotFoundError
+	if errors.As(*err, &notFoundError) {
+		return "not_found"
+	}
+	return resultError
+}
@@ -0,0 +1,92 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"postservice/internal/bus"
+	mock_bus "postservice/internal/bus/mock"
+	database "postservice/internal/db"
+	mock_database "postservice/internal/db/mock"
+	"postservice/internal/metrics"
+	objectstorage "postservice/internal/objectStorage"
+	mock_objectstorage "postservice/internal/objectStorage/mock"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMetrics(t *testing.T) string {
+	var output bytes.Buffer
+	err := metrics.Write(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+func TestInstrumentedDatabaseClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_database.NewMockDatabaseClient(ctrl)
+	instrumentedClient := metrics.NewInstrumentedDatabaseClient(client)
+	client.EXPECT().GetData("Posts", gomock.Any(), gomock.Any(), gomock.Any()).Return(database.NewNotFoundError("Posts", "post1"))
+	client.EXPECT().InsertData("Posts", gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+	notFoundErr := instrumentedClient.GetData("Posts", "post1", nil, context.Background())
+	insertErr := instrumentedClient.InsertData("Posts", nil, context.Background())
+
+	assert.NotNil(t, notFoundErr)
+	assert.EqualError(t, insertErr, "some error")
+	output := writeMetrics(t)
+	assert.Contains(t, output, `postservice_database_operations_total{operation="GetData",result="not_found"} 1`)
+	assert.Contains(t, output, `postservice_database_operations_total{operation="InsertData",result="error"} 1`)
+	assert.Contains(t, output, `postservice_database_operation_duration_seconds_count{operation="GetData"} 1`)
+	assert.Equal(t, database.DatabaseClient(client), instrumentedClient.Unwrap())
+}
+
+func TestInstrumentedObjectStorageClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_objectstorage.NewMockObjectStorageClient(ctrl)
+	instrumentedClient := metrics.NewInstrumentedObjectStorageClient(client)
+	presignedUpload := &objectstorage.PresignedUpload{
+		UploadId: "uploadId",
+		Parts:    []objectstorage.PresignedPart{{Url: "url1"}, {Url: "url2"}, {Url: "url3"}},
+	}
+	client.EXPECT().GetPreSignedUrlsForPuttingObject("key", 300, gomock.Any()).Return(presignedUpload, nil)
+	client.EXPECT().GetPreSignedUrlForGettingObject("key", gomock.Any()).Return("url", nil)
+	client.EXPECT().CompleteMultipartUpload(gomock.Any(), gomock.Any()).Return(nil)
+	client.EXPECT().DeleteObjects([]string{"key"}, gomock.Any()).Return(errors.New("some error"))
+
+	upload, _ := instrumentedClient.GetPreSignedUrlsForPuttingObject("key", 300, context.Background())
+	url, _ := instrumentedClient.GetPreSignedUrlForGettingObject("key", context.Background())
+	instrumentedClient.CompleteMultipartUpload(objectstorage.MultipartObject{Key: "key", UploadID: "uploadId"}, context.Background())
+	instrumentedClient.DeleteObjects([]string{"key"}, context.Background())
+
+	assert.Equal(t, presignedUpload, upload)
+	assert.Equal(t, "url", url)
+	output := writeMetrics(t)
+	assert.Contains(t, output, `postservice_presigned_urls_issued_total{method="PUT"} 3`)
+	assert.Contains(t, output, `postservice_presigned_urls_issued_total{method="GET"} 1`)
+	assert.Contains(t, output, `postservice_multipart_uploads_total{stage="started"} 1`)
+	assert.Contains(t, output, `postservice_multipart_uploads_total{stage="completed"} 1`)
+	assert.Contains(t, output, `postservice_object_storage_operations_total{operation="DeleteObjects",result="error"} 1`)
+}
+
+func TestInstrumentedExternalBus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	externalBus := mock_bus.NewMockExternalBus(ctrl)
+	instrumentedBus := metrics.NewInstrumentedExternalBus(externalBus)
+	event := &bus.Event{Type: "PostWasCreatedEvent", Data: []byte("{}")}
+	externalBus.EXPECT().Publish(event, context.Background()).Return(nil)
+	externalBus.EXPECT().Publish(event, context.Background()).Return(errors.New("some error"))
+
+	instrumentedBus.Publish(event, context.Background())
+	err := instrumentedBus.Publish(event, context.Background())
+
+	assert.EqualError(t, err, "some error")
+	output := writeMetrics(t)
+	assert.Contains(t, output, `postservice_bus_published_events_total{event_type="PostWasCreatedEvent",result="success"} 1`)
+	assert.Contains(t, output, `postservice_bus_published_events_total{event_type="PostWasCreatedEvent",result="error"} 1`)
+	assert.Contains(t, output, `postservice_bus_publish_duration_seconds_count{event_type="PostWasCreatedEvent"} 2`)
+}
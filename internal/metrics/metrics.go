@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// DefaultBuckets suit latencies in seconds, from 5ms to 10s.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var defaultRegistry = NewRegistry()
+
+// Registry renders its metrics in the Prometheus text exposition format.
+type Registry struct {
+	mutex   sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+type Counter struct {
+	name       string
+	help       string
+	labelNames []string
+	mutex      sync.Mutex
+	series     map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+type Histogram struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+	mutex      sync.Mutex
+	series     map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues  []string
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+// NewCounter registers the counter in the registry served by Write.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return defaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+// NewHistogram registers the histogram in the registry served by Write.
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	return defaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
+
+// Write renders the metrics registered with NewCounter and NewHistogram.
+func Write(w io.Writer) error {
+	return defaultRegistry.Write(w)
+}
+
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	counter := &Counter{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		series:     make(map[string]*counterSeries),
+	}
+	r.register(name, counter)
+
+	return counter
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	histogram := &Histogram{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    append([]float64(nil), buckets...),
+		series:     make(map[string]*histogramSeries),
+	}
+	sort.Float64s(histogram.buckets)
+	r.register(name, histogram)
+
+	return histogram
+}
+
+func (r *Registry) Write(w io.Writer) error {
+	r.mutex.Lock()
+	metrics := make([]metric, 0, len(r.metrics))
+	for _, name := range sortedKeys(r.metrics) {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mutex.Unlock()
+
+	writer := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		metric.write(writer)
+	}
+
+	return writer.Flush()
+}
+
+// register panics on duplicated names, as they are a programming error.
+func (r *Registry) register(name string, metric metric) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metric %s registered twice", name))
+	}
+	r.metrics[name] = metric
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(value float64, labelValues ...string) {
+	checkLabels(c.name, c.labelNames, labelValues)
+	key := strings.Join(labelValues, "\xff")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	series, exists := c.series[key]
+	if !exists {
+		series = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		c.series[key] = series
+	}
+	series.value += value
+}
+
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	series, exists := c.series[strings.Join(labelValues, "\xff")]
+	if !exists {
+		return 0
+	}
+	return series.value
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		writeSample(w, c.name, formatLabels(c.labelNames, series.labelValues, ""), series.value)
+	}
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	checkLabels(h.name, h.labelNames, labelValues)
+	key := strings.Join(labelValues, "\xff")
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	series, exists := h.series[key]
+	if !exists {
+		series = &histogramSeries{
+			labelValues:  append([]string(nil), labelValues...),
+			bucketCounts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.bucketCounts[i]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	series, exists := h.series[strings.Join(labelValues, "\xff")]
+	if !exists {
+		return 0
+	}
+	return series.count
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			le := `le="` + formatValue(bound) + `"`
+			writeSample(w, h.name+"_bucket", formatLabels(h.labelNames, series.labelValues, le), float64(series.bucketCounts[i]))
+		}
+		writeSample(w, h.name+"_bucket", formatLabels(h.labelNames, series.labelValues, `le="+Inf"`), float64(series.count))
+		writeSample(w, h.name+"_sum", formatLabels(h.labelNames, series.labelValues, ""), series.sum)
+		writeSample(w, h.name+"_count", formatLabels(h.labelNames, series.labelValues, ""), float64(series.count))
+	}
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultError
+	}
+	return resultSuccess
+}
+
+func checkLabels(name string, labelNames, labelValues []string) {
+	if len(labelNames) != len(labelValues) {
+		panic(fmt.Sprintf("metric %s expects labels %v, got %d values", name, labelNames, len(labelValues)))
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, metricType string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(value))
+}
+
+func formatLabels(labelNames, labelValues []string, extra string) string {
+	pairs := make([]string, 0, len(labelNames)+1)
+	for i, labelName := range labelNames {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labelValues[i])
+		pairs = append(pairs, labelName+`="`+value+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
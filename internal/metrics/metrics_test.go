@@ -0,0 +1,52 @@
+package metrics_test
+
+import (
+	"bytes"
+	"postservice/internal/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteCountersAndHistograms(t *testing.T) {
+	registry := metrics.NewRegistry()
+	counter := registry.NewCounter("test_events_total", "Events by type.", "type")
+	histogram := registry.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "route")
+	counter.Inc("created")
+	counter.Add(2, `with "quotes"`)
+	histogram.Observe(0.05, "/posts")
+	histogram.Observe(0.5, "/posts")
+	histogram.Observe(3, "/posts")
+	var output bytes.Buffer
+
+	err := registry.Write(&output)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/posts",le="0.1"} 1
+test_duration_seconds_bucket{route="/posts",le="1"} 2
+test_duration_seconds_bucket{route="/posts",le="+Inf"} 3
+test_duration_seconds_sum{route="/posts"} 3.55
+test_duration_seconds_count{route="/posts"} 3
+# HELP test_events_total Events by type.
+# TYPE test_events_total counter
+test_events_total{type="created"} 1
+test_events_total{type="with \"quotes\""} 2
+`, output.String())
+	assert.Equal(t, float64(1), counter.Value("created"))
+	assert.Equal(t, uint64(3), histogram.Count("/posts"))
+}
+
+func TestPanicOnDuplicatedMetric(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounter("test_events_total", "Events.")
+
+	assert.Panics(t, func() { registry.NewCounter("test_events_total", "Events.") })
+}
+
+func TestPanicOnWrongLabels(t *testing.T) {
+	counter := metrics.NewRegistry().NewCounter("test_events_total", "Events.", "type")
+
+	assert.Panics(t, func() { counter.Inc() })
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	objectstorage "postservice/internal/objectStorage"
+	"time"
+)
+
+var objectStorageOperations = NewCounter("postservice_object_storage_operations_total", "Object storage operations by operation and result.", "operation", "result")
+var objectStorageOperationDuration = NewHistogram("postservice_object_storage_operation_duration_seconds", "Duration of the object storage operations.", DefaultBuckets, "operation")
+var presignedUrlsIssued = NewCounter("postservice_presigned_urls_issued_total", "Presigned URLs issued by method.", "method")
+var multipartUploads = NewCounter("postservice_multipart_uploads_total", "Multipart uploads by stage.", "stage")
+
+// InstrumentedObjectStorageClient counts and times the calls to an
+// ObjectStorageClient, along with the URLs and multipart uploads it issues.
+type InstrumentedObjectStorageClient struct {
+	client objectstorage.ObjectStorageClient
+}
+
+func NewInstrumentedObjectStorageClient(client objectstorage.ObjectStorageClient) *InstrumentedObjectStorageClient {
+	return &InstrumentedObjectStorageClient{
+		client: client,
+	}
+}
+
+func (oc *InstrumentedObjectStorageClient) Unwrap() objectstorage.ObjectStorageClient {
+	return oc.client
+}
+
+func (oc *InstrumentedObjectStorageClient) GetPreSignedUrlsForPuttingObject(objectKey string, size int, ctx context.Context) (upload *objectstorage.PresignedUpload, err error) {
+	defer observeObjectStorage("GetPreSignedUrlsForPuttingObject", time.Now(), &err)
+	upload, err = oc.client.GetPreSignedUrlsForPuttingObject(objectKey, size, ctx)
+	if err == nil {
+		presignedUrlsIssued.Add(float64(len(upload.Parts)), "PUT")
+		if upload.UploadId != objectstorage.NoUploadId {
+			multipartUploads.Inc("started")
+		}
+	}
+	return upload, err
+}
+
+func (oc *InstrumentedObjectStorageClient) GetPreSignedUrlForGettingObject(objectKey string, ctx context.Context) (url string, err error) {
+	defer observeObjectStorage("GetPreSignedUrlForGettingObject", time.Now(), &err)
+	url, err = oc.client.GetPreSignedUrlForGettingObject(objectKey, ctx)
+	if err == nil {
+		presignedUrlsIssued.Inc("GET")
+	}
+	return url, err
+}
+
+func (oc *InstrumentedObjectStorageClient) CompleteMultipartUpload(multipartObject objectstorage.MultipartObject, ctx context.Context) (err error) {
+	defer observeObjectStorage("CompleteMultipartUpload", time.Now(), &err)
+	err = oc.client.CompleteMultipartUpload(multipartObject, ctx)
+	if err == nil {
+		multipartUploads.Inc("completed")
+	}
+	return err
+}
+
+func (oc *InstrumentedObjectStorageClient) AbortMultipartUpload(objectKey, uploadId string, ctx context.Context) (err error) {
+	defer observeObjectStorage("AbortMultipartUpload", time.Now(), &err)
+	err = oc.client.AbortMultipartUpload(objectKey, uploadId, ctx)
+	if err == nil {
+		multipartUploads.Inc("aborted")
+	}
+	return err
+}
+
+func (oc *InstrumentedObjectStorageClient) DeleteObjects(objectKeys []string, ctx context.Context) (err error) {
+	defer observeObjectStorage("DeleteObjects", time.Now(), &err)
+	return oc.client.DeleteObjects(objectKeys, ctx)
+}
+
+func (oc *InstrumentedObjectStorageClient) StatObject(objectKey string, ctx context.Context) (info *objectstorage.ObjectInfo, err error) {
+	defer observeObjectStorage("StatObject", time.Now(), &err)
+	return oc.client.StatObject(objectKey, ctx)
+}
+
+func observeObjectStorage(operation string, start time.Time, err *error) {
+	objectStorageOperationDuration.Observe(time.Since(start).Seconds(), operation)
+	objectStorageOperations.Inc(operation, result(*err))
+}
@@ -0,0 +1,35 @@
+package objectstorage
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SniffLength is the number of bytes SniffContentType looks at.
+const SniffLength = 512
+
+// MediaType drops the parameters of contentType, like its charset.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// IsGenericContentType tells if contentType says nothing about the content,
+// which is what the storages answer for objects uploaded without one.
+func IsGenericContentType(contentType string) bool {
+	return contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream"
+}
+
+// SniffContentType detects the media type of the first bytes of content.
+func SniffContentType(content io.Reader) (string, error) {
+	head, err := io.ReadAll(io.LimitReader(content, SniffLength))
+	if err != nil {
+		return "", err
+	}
+	return MediaType(http.DetectContentType(head)), nil
+}
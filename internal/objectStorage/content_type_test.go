@@ -0,0 +1,33 @@
+package objectstorage_test
+
+import (
+	objectstorage "postservice/internal/objectStorage"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaTypeDropsParameters(t *testing.T) {
+	assert.Equal(t, "text/plain", objectstorage.MediaType("Text/Plain; charset=utf-8"))
+	assert.Equal(t, "", objectstorage.MediaType(""))
+}
+
+func TestSniffContentType(t *testing.T) {
+	contentType, err := objectstorage.SniffContentType(strings.NewReader("\x89PNG\r\n\x1a\n" + strings.Repeat("a", 1000)))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", contentType)
+}
+
+func TestSniffContentTypeOfVideos(t *testing.T) {
+	mp4, err := objectstorage.SniffContentType(strings.NewReader("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"))
+	assert.Nil(t, err)
+	assert.Equal(t, "video/mp4", mp4)
+	webm, err := objectstorage.SniffContentType(strings.NewReader("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm"))
+	assert.Nil(t, err)
+	assert.Equal(t, "video/webm", webm)
+	quicktime, err := objectstorage.SniffContentType(strings.NewReader("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00qt  "))
+	assert.Nil(t, err)
+	assert.Equal(t, "application/octet-stream", quicktime)
+}
@@ -5,6 +5,7 @@
 package mock_objectstorage
 
 import (
+	context "context"
 	objectstorage "postservice/internal/objectStorage"
 	reflect "reflect"
 
@@ -34,61 +35,89 @@ func (m *MockObjectStorageClient) EXPECT() *MockObjectStorageClientMockRecorder
 	return m.recorder
 }
 
+// AbortMultipartUpload mocks base method.
+func (m *MockObjectStorageClient) AbortMultipartUpload(objectKey, uploadId string, ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortMultipartUpload", objectKey, uploadId, ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortMultipartUpload indicates an expected call of AbortMultipartUpload.
+func (mr *MockObjectStorageClientMockRecorder) AbortMultipartUpload(objectKey, uploadId, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortMultipartUpload", reflect.TypeOf((*MockObjectStorageClient)(nil).AbortMultipartUpload), objectKey, uploadId, ctx)
+}
+
 // CompleteMultipartUpload mocks base method.
-func (m *MockObjectStorageClient) CompleteMultipartUpload(multipartobject objectstorage.MultipartObject) error {
+func (m *MockObjectStorageClient) CompleteMultipartUpload(multipartobject objectstorage.MultipartObject, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CompleteMultipartUpload", multipartobject)
+	ret := m.ctrl.Call(m, "CompleteMultipartUpload", multipartobject, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CompleteMultipartUpload indicates an expected call of CompleteMultipartUpload.
-func (mr *MockObjectStorageClientMockRecorder) CompleteMultipartUpload(multipartobject interface{}) *gomock.Call {
+func (mr *MockObjectStorageClientMockRecorder) CompleteMultipartUpload(multipartobject, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUpload", reflect.TypeOf((*MockObjectStorageClient)(nil).CompleteMultipartUpload), multipartobject)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUpload", reflect.TypeOf((*MockObjectStorageClient)(nil).CompleteMultipartUpload), multipartobject, ctx)
 }
 
 // DeleteObjects mocks base method.
-func (m *MockObjectStorageClient) DeleteObjects(objectKeys []string) error {
+func (m *MockObjectStorageClient) DeleteObjects(objectKeys []string, ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteObjects", objectKeys)
+	ret := m.ctrl.Call(m, "DeleteObjects", objectKeys, ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteObjects indicates an expected call of DeleteObjects.
-func (mr *MockObjectStorageClientMockRecorder) DeleteObjects(objectKeys interface{}) *gomock.Call {
+func (mr *MockObjectStorageClientMockRecorder) DeleteObjects(objectKeys, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockObjectStorageClient)(nil).DeleteObjects), objectKeys)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockObjectStorageClient)(nil).DeleteObjects), objectKeys, ctx)
 }
 
 // GetPreSignedUrlForGettingObject mocks base method.
-func (m *MockObjectStorageClient) GetPreSignedUrlForGettingObject(objectKey string) (string, error) {
+func (m *MockObjectStorageClient) GetPreSignedUrlForGettingObject(objectKey string, ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPreSignedUrlForGettingObject", objectKey)
+	ret := m.ctrl.Call(m, "GetPreSignedUrlForGettingObject", objectKey, ctx)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPreSignedUrlForGettingObject indicates an expected call of GetPreSignedUrlForGettingObject.
-func (mr *MockObjectStorageClientMockRecorder) GetPreSignedUrlForGettingObject(objectKey interface{}) *gomock.Call {
+func (mr *MockObjectStorageClientMockRecorder) GetPreSignedUrlForGettingObject(objectKey, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreSignedUrlForGettingObject", reflect.TypeOf((*MockObjectStorageClient)(nil).GetPreSignedUrlForGettingObject), objectKey)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreSignedUrlForGettingObject", reflect.TypeOf((*MockObjectStorageClient)(nil).GetPreSignedUrlForGettingObject), objectKey, ctx)
 }
 
 // GetPreSignedUrlsForPuttingObject mocks base method.
-func (m *MockObjectStorageClient) GetPreSignedUrlsForPuttingObject(objectKey string, size int) (string, []string, error) {
+func (m *MockObjectStorageClient) GetPreSignedUrlsForPuttingObject(objectKey string, size int, ctx context.Context) (*objectstorage.PresignedUpload, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPreSignedUrlsForPuttingObject", objectKey, size)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].([]string)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "GetPreSignedUrlsForPuttingObject", objectKey, size, ctx)
+	ret0, _ := ret[0].(*objectstorage.PresignedUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetPreSignedUrlsForPuttingObject indicates an expected call of GetPreSignedUrlsForPuttingObject.
-func (mr *MockObjectStorageClientMockRecorder) GetPreSignedUrlsForPuttingObject(objectKey, size interface{}) *gomock.Call {
+func (mr *MockObjectStorageClientMockRecorder) GetPreSignedUrlsForPuttingObject(objectKey, size, ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreSignedUrlsForPuttingObject", reflect.TypeOf((*MockObjectStorageClient)(nil).GetPreSignedUrlsForPuttingObject), objectKey, size, ctx)
+}
+
+// StatObject mocks base method.
+func (m *MockObjectStorageClient) StatObject(objectKey string, ctx context.Context) (*objectstorage.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatObject", objectKey, ctx)
+	ret0, _ := ret[0].(*objectstorage.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatObject indicates an expected call of StatObject.
+func (mr *MockObjectStorageClientMockRecorder) StatObject(objectKey, ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreSignedUrlsForPuttingObject", reflect.TypeOf((*MockObjectStorageClient)(nil).GetPreSignedUrlsForPuttingObject), objectKey, size)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatObject", reflect.TypeOf((*MockObjectStorageClient)(nil).StatObject), objectKey, ctx)
 }
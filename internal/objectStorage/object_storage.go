@@ -1,7 +1,14 @@
 package objectstorage
 
+import (
+	"context"
+	"fmt"
+)
+
 //go:generate mockgen -source=object_storage.go -destination=mock/object_storage.go
 
+const NoUploadId = "NoUploadId"
+
 type ObjectStorage struct {
 	Client     ObjectStorageClient
 	BucketName string
@@ -18,11 +25,52 @@ type CompletedPart struct {
 	ETag       string `json:"eTag"`
 }
 
+// PresignedUpload has one URL per part of the object, a single one with
+// NoUploadId when the object is not uploaded in parts.
+type PresignedUpload struct {
+	UploadId string
+	PartSize int
+	Parts    []PresignedPart
+}
+
+type PresignedPart struct {
+	PartRange
+	Url string
+}
+
+func (u *PresignedUpload) Urls() []string {
+	urls := make([]string, len(u.Parts))
+	for i, part := range u.Parts {
+		urls[i] = part.Url
+	}
+	return urls
+}
+
+// ObjectInfo describes a stored object, its ContentType without parameters.
+type ObjectInfo struct {
+	Size        int
+	ContentType string
+}
+
+type ObjectNotFoundError struct {
+	Key string
+}
+
+func (e *ObjectNotFoundError) Error() string {
+	return fmt.Sprintf("object %s not found", e.Key)
+}
+
 type ObjectStorageClient interface {
-	GetPreSignedUrlsForPuttingObject(objectKey string, size int) (string, []string, error)
-	GetPreSignedUrlForGettingObject(objectKey string) (string, error)
-	CompleteMultipartUpload(multipartobject MultipartObject) error
-	DeleteObjects(objectKeys []string) error
+	// GetPreSignedUrlsForPuttingObject fails with an ObjectTooLargeError when
+	// size, in bytes, can not be uploaded.
+	GetPreSignedUrlsForPuttingObject(objectKey string, size int, ctx context.Context) (*PresignedUpload, error)
+	GetPreSignedUrlForGettingObject(objectKey string, ctx context.Context) (string, error)
+	CompleteMultipartUpload(multipartobject MultipartObject, ctx context.Context) error
+	AbortMultipartUpload(objectKey, uploadId string, ctx context.Context) error
+	DeleteObjects(objectKeys []string, ctx context.Context) error
+	// StatObject fails with an ObjectNotFoundError when nothing was uploaded
+	// to objectKey.
+	StatObject(objectKey string, ctx context.Context) (*ObjectInfo, error)
 }
 
 func NewObjectStorage(client ObjectStorageClient) *ObjectStorage {
@@ -0,0 +1,96 @@
+package objectstorage
+
+import "fmt"
+
+// PartLimits are the bounds a storage puts on the parts of a multipart upload.
+type PartLimits struct {
+	MinPartSize int
+	MaxPartSize int
+	MaxParts    int
+}
+
+// S3PartLimits are the limits of S3, the last part is the only one that can be
+// smaller than MinPartSize.
+var S3PartLimits = PartLimits{
+	MinPartSize: 5 << 20,
+	MaxPartSize: 5 << 30,
+	MaxParts:    10000,
+}
+
+// PartRange is the byte range, both ends included, that a part uploads.
+type PartRange struct {
+	PartNumber int
+	FirstByte  int
+	LastByte   int
+}
+
+type UploadPlan struct {
+	Multipart bool
+	PartSize  int
+	Parts     []PartRange
+}
+
+// ObjectTooLargeError rejects an object bigger than the configured maximum or
+// than the parts of a multipart upload can hold.
+type ObjectTooLargeError struct {
+	Size    int
+	MaxSize int
+}
+
+func (e *ObjectTooLargeError) Error() string {
+	return fmt.Sprintf("object of %d bytes exceeds the maximum of %d bytes", e.Size, e.MaxSize)
+}
+
+// UploadPlanner splits the objects bigger than a threshold, in bytes, in parts
+// of about the configured size that fit in the limits of the storage.
+type UploadPlanner struct {
+	multipartThreshold int
+	partSize           int
+	maxObjectSize      int
+	limits             PartLimits
+}
+
+func NewUploadPlanner(multipartThreshold, partSize, maxObjectSize int, limits PartLimits) *UploadPlanner {
+	return &UploadPlanner{
+		multipartThreshold: multipartThreshold,
+		partSize:           partSize,
+		maxObjectSize:      maxObjectSize,
+		limits:             limits,
+	}
+}
+
+func (p *UploadPlanner) Plan(size int) (*UploadPlan, error) {
+	if size > p.maxObjectSize {
+		return nil, &ObjectTooLargeError{Size: size, MaxSize: p.maxObjectSize}
+	}
+	if size <= p.multipartThreshold {
+		return &UploadPlan{
+			PartSize: size,
+			Parts:    []PartRange{{PartNumber: 1, FirstByte: 0, LastByte: size - 1}},
+		}, nil
+	}
+
+	partSize := max(p.partSize, p.limits.MinPartSize, ceilDiv(size, p.limits.MaxParts))
+	if partSize > p.limits.MaxPartSize {
+		return nil, &ObjectTooLargeError{Size: size, MaxSize: p.limits.MaxPartSize * p.limits.MaxParts}
+	}
+
+	parts := make([]PartRange, 0, ceilDiv(size, partSize))
+	for firstByte := 0; firstByte < size; firstByte += partSize {
+		parts = append(parts, PartRange{
+			PartNumber: len(parts) + 1,
+			FirstByte:  firstByte,
+			LastByte:   min(firstByte+partSize, size) - 1,
+		})
+	}
+
+	return &UploadPlan{
+		Multipart: true,
+		PartSize:  partSize,
+		Parts:     parts,
+	}, nil
+}
+
+func ceilDiv(dividend, divisor int) int {
+	return (dividend + divisor - 1) / divisor
+}
@@ -0,0 +1,76 @@
+package objectstorage_test
+
+import (
+	objectstorage "postservice/internal/objectStorage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPartLimits = objectstorage.PartLimits{MinPartSize: 5, MaxPartSize: 20, MaxParts: 4}
+
+func TestPlanSinglePartUpload(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 5, 100, testPartLimits)
+
+	plan, err := planner.Plan(10)
+
+	assert.Nil(t, err)
+	assert.False(t, plan.Multipart)
+	assert.Equal(t, []objectstorage.PartRange{{PartNumber: 1, FirstByte: 0, LastByte: 9}}, plan.Parts)
+}
+
+func TestPlanMultipartUploadCoversEveryByte(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 5, 100, testPartLimits)
+
+	plan, err := planner.Plan(13)
+
+	assert.Nil(t, err)
+	assert.True(t, plan.Multipart)
+	assert.Equal(t, 5, plan.PartSize)
+	assert.Equal(t, []objectstorage.PartRange{
+		{PartNumber: 1, FirstByte: 0, LastByte: 4},
+		{PartNumber: 2, FirstByte: 5, LastByte: 9},
+		{PartNumber: 3, FirstByte: 10, LastByte: 12},
+	}, plan.Parts)
+}
+
+func TestPlanRaisesPartSizeToTheMinimum(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 1, 100, testPartLimits)
+
+	plan, err := planner.Plan(12)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 5, plan.PartSize)
+	assert.Len(t, plan.Parts, 3)
+}
+
+func TestPlanRaisesPartSizeToFitTheMaximumParts(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 5, 100, testPartLimits)
+
+	plan, err := planner.Plan(50)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 13, plan.PartSize)
+	assert.Len(t, plan.Parts, 4)
+	assert.Equal(t, objectstorage.PartRange{PartNumber: 4, FirstByte: 39, LastByte: 49}, plan.Parts[3])
+}
+
+func TestErrorOnPlanObjectBiggerThanTheMaximum(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 5, 100, testPartLimits)
+
+	_, err := planner.Plan(101)
+
+	var tooLargeError *objectstorage.ObjectTooLargeError
+	assert.ErrorAs(t, err, &tooLargeError)
+	assert.Equal(t, 100, tooLargeError.MaxSize)
+}
+
+func TestErrorOnPlanObjectBiggerThanThePartsCanHold(t *testing.T) {
+	planner := objectstorage.NewUploadPlanner(10, 5, 100, testPartLimits)
+
+	_, err := planner.Plan(81)
+
+	var tooLargeError *objectstorage.ObjectTooLargeError
+	assert.ErrorAs(t, err, &tooLargeError)
+	assert.Equal(t, 80, tooLargeError.MaxSize)
+}
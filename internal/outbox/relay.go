@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"postservice/internal/bus"
+	database "postservice/internal/db"
+	"postservice/internal/tracing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const batchSize = 25
+
+// Relay delivers the events stored in the Outbox table through the external
+// bus. An event is only deleted after it was published, so it can be
+// delivered more than once but it is never lost. An event that fails
+// maxAttempts times is marked as failed and left in the table for inspection.
+type Relay struct {
+	database    *database.Database
+	externalBus bus.ExternalBus
+	interval    time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+}
+
+func NewRelay(database *database.Database, externalBus bus.ExternalBus, interval, maxBackoff time.Duration, maxAttempts int) *Relay {
+	return &Relay{
+		database:    database,
+		externalBus: externalBus,
+		interval:    interval,
+		maxBackoff:  maxBackoff,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	log.Info().Msgf("Relaying Outbox events every %s", r.interval)
+
+	failedRelays := 0
+	for {
+		err := r.RelayPendingEvents(ctx)
+		if err != nil {
+			failedRelays++
+		} else {
+			failedRelays = 0
+		}
+
+		select {
+		case <-time.After(r.backoff(failedRelays)):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RelayPendingEvents publishes a batch of pending events in creation order and
+// stops at the first failure, so that events are not delivered out of order.
+// An event that reaches the maximum attempts no longer stops the batch.
+func (r *Relay) RelayPendingEvents(ctx context.Context) error {
+	events, err := r.database.Client.GetPendingOutboxEvents(batchSize, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Error getting pending Outbox events")
+		return err
+	}
+
+	for _, event := range events {
+		err = r.relayEvent(event, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Relay) relayEvent(event *database.OutboxEvent, ctx context.Context) error {
+	eventKey := &database.OutboxEventKey{
+		EventId: event.EventId,
+	}
+
+	ctx, span := tracing.Start(tracing.Extract(ctx, event.TraceContext), "Outbox.Relay "+event.Type)
+	defer span.End()
+
+	err := r.externalBus.Publish(&bus.Event{
+		Type: event.Type,
+		Data: event.Data,
+	}, ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		log.Error().Stack().Err(err).Msgf("Error relaying Outbox event %s %s, attempt %d", event.Type, event.EventId, event.Attempts+1)
+		attributes := map[string]any{
+			"Attempts":  event.Attempts + 1,
+			"LastError": err.Error(),
+		}
+		failed := event.Attempts+1 >= r.maxAttempts
+		if failed {
+			attributes["Status"] = database.OutboxStatusFailed
+		}
+		updateErr := r.database.Client.UpdateData("Outbox", eventKey, attributes, ctx)
+		if updateErr != nil {
+			log.Error().Stack().Err(updateErr).Msgf("Error recording failed attempt of Outbox event %s", event.EventId)
+			return err
+		}
+		if failed {
+			log.Error().Msgf("Outbox event %s %s failed %d times, it is not relayed anymore", event.Type, event.EventId, event.Attempts+1)
+			return nil
+		}
+		return err
+	}
+
+	err = r.database.Client.RemoveData("Outbox", eventKey, ctx)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Error deleting relayed Outbox event %s", event.EventId)
+		return err
+	}
+
+	log.Info().Msgf("Outbox event %s %s was relayed", event.Type, event.EventId)
+	return nil
+}
+
+func (r *Relay) backoff(failedRelays int) time.Duration {
+	backoff := r.interval
+	for i := 0; i < failedRelays && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, r.maxBackoff)
+}
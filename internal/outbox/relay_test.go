@@ -0,0 +1,153 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"postservice/internal/bus"
+	mock_bus "postservice/internal/bus/mock"
+	"postservice/internal/config"
+	database "postservice/internal/db"
+	mock_database "postservice/internal/db/mock"
+	"postservice/internal/outbox"
+	"postservice/internal/tracing"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var loggerOutput bytes.Buffer
+var dbClient *mock_database.MockDatabaseClient
+var externalBus *mock_bus.MockExternalBus
+var relay *outbox.Relay
+
+func setUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	loggerOutput.Reset()
+	log.Logger = log.Output(&loggerOutput)
+	dbClient = mock_database.NewMockDatabaseClient(ctrl)
+	externalBus = mock_bus.NewMockExternalBus(ctrl)
+	relay = outbox.NewRelay(database.NewDatabase(dbClient), externalBus, time.Second, time.Minute, 3)
+}
+
+func TestRelayContinuesTraceOfStoredEvent(t *testing.T) {
+	setUp(t)
+	_, err := tracing.Setup(config.TracingConfig{Exporter: config.TracingExporterNone}, "test", context.Background())
+	assert.Nil(t, err)
+	traceContext := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending, TraceContext: traceContext},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	externalBus.EXPECT().Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte("data1")}, gomock.Any()).DoAndReturn(func(event *bus.Event, ctx context.Context) error {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+		return nil
+	})
+	dbClient.EXPECT().RemoveData("Outbox", &database.OutboxEventKey{EventId: "event1"}, gomock.Any()).Return(nil)
+
+	err = relay.RelayPendingEvents(context.Background())
+
+	assert.Nil(t, err)
+}
+
+func TestRelayPendingEvents(t *testing.T) {
+	setUp(t)
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending},
+		{EventId: "event2", Type: "PostsWereDeletedEvent", Data: []byte("data2"), Status: database.OutboxStatusPending, Attempts: 2},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	gomock.InOrder(
+		externalBus.EXPECT().Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte("data1")}, gomock.Any()).Return(nil),
+		dbClient.EXPECT().RemoveData("Outbox", &database.OutboxEventKey{EventId: "event1"}, gomock.Any()).Return(nil),
+		externalBus.EXPECT().Publish(&bus.Event{Type: "PostsWereDeletedEvent", Data: []byte("data2")}, gomock.Any()).Return(nil),
+		dbClient.EXPECT().RemoveData("Outbox", &database.OutboxEventKey{EventId: "event2"}, gomock.Any()).Return(nil),
+	)
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.Nil(t, err)
+	assert.Contains(t, loggerOutput.String(), "Outbox event PostWasCreatedEvent event1 was relayed")
+	assert.Contains(t, loggerOutput.String(), "Outbox event PostsWereDeletedEvent event2 was relayed")
+}
+
+func TestRelayPendingEvents_StopsAtFirstPublishingError(t *testing.T) {
+	setUp(t)
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending},
+		{EventId: "event2", Type: "PostsWereDeletedEvent", Data: []byte("data2"), Status: database.OutboxStatusPending},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	externalBus.EXPECT().Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte("data1")}, gomock.Any()).Return(errors.New("kafka is down"))
+	dbClient.EXPECT().UpdateData("Outbox", &database.OutboxEventKey{EventId: "event1"}, map[string]any{"Attempts": 1, "LastError": "kafka is down"}, gomock.Any())
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.EqualError(t, err, "kafka is down")
+	assert.Contains(t, loggerOutput.String(), "Error relaying Outbox event PostWasCreatedEvent event1, attempt 1")
+}
+
+func TestRelayPendingEvents_MarksEventAsFailedAfterMaxAttempts(t *testing.T) {
+	setUp(t)
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending, Attempts: 2},
+		{EventId: "event2", Type: "PostsWereDeletedEvent", Data: []byte("data2"), Status: database.OutboxStatusPending},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	gomock.InOrder(
+		externalBus.EXPECT().Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte("data1")}, gomock.Any()).Return(errors.New("message too large")),
+		dbClient.EXPECT().UpdateData("Outbox", &database.OutboxEventKey{EventId: "event1"}, map[string]any{"Attempts": 3, "LastError": "message too large", "Status": database.OutboxStatusFailed}, gomock.Any()).Return(nil),
+		externalBus.EXPECT().Publish(&bus.Event{Type: "PostsWereDeletedEvent", Data: []byte("data2")}, gomock.Any()).Return(nil),
+		dbClient.EXPECT().RemoveData("Outbox", &database.OutboxEventKey{EventId: "event2"}, gomock.Any()).Return(nil),
+	)
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.Nil(t, err)
+	assert.Contains(t, loggerOutput.String(), "Outbox event PostWasCreatedEvent event1 failed 3 times, it is not relayed anymore")
+}
+
+func TestRelayPendingEvents_StopsWhenFailedEventCanNotBeMarked(t *testing.T) {
+	setUp(t)
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending, Attempts: 2},
+		{EventId: "event2", Type: "PostsWereDeletedEvent", Data: []byte("data2"), Status: database.OutboxStatusPending},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	externalBus.EXPECT().Publish(&bus.Event{Type: "PostWasCreatedEvent", Data: []byte("data1")}, gomock.Any()).Return(errors.New("message too large"))
+	dbClient.EXPECT().UpdateData("Outbox", &database.OutboxEventKey{EventId: "event1"}, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.EqualError(t, err, "message too large")
+	assert.Contains(t, loggerOutput.String(), "Error recording failed attempt of Outbox event event1")
+}
+
+func TestRelayPendingEvents_ErrorDeletingRelayedEvent(t *testing.T) {
+	setUp(t)
+	events := []*database.OutboxEvent{
+		{EventId: "event1", Type: "PostWasCreatedEvent", Data: []byte("data1"), Status: database.OutboxStatusPending},
+	}
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(events, nil)
+	externalBus.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil)
+	dbClient.EXPECT().RemoveData("Outbox", &database.OutboxEventKey{EventId: "event1"}, gomock.Any()).Return(errors.New("some error"))
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, loggerOutput.String(), "Error deleting relayed Outbox event event1")
+}
+
+func TestRelayPendingEvents_ErrorGettingEvents(t *testing.T) {
+	setUp(t)
+	dbClient.EXPECT().GetPendingOutboxEvents(25, gomock.Any()).Return(nil, errors.New("some error"))
+
+	err := relay.RelayPendingEvents(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Contains(t, loggerOutput.String(), "Error getting pending Outbox events")
+}
@@ -0,0 +1,94 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"postservice/internal/config"
+	"postservice/internal/tracing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setUp(t *testing.T) *tracetest.SpanRecorder {
+	_, err := tracing.Setup(config.TracingConfig{Exporter: config.TracingExporterNone}, "test", context.Background())
+	assert.Nil(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func TestInjectAndExtractTraceContext(t *testing.T) {
+	setUp(t)
+	ctx, span := tracing.Start(context.Background(), "span")
+	defer span.End()
+
+	traceContext := tracing.Inject(ctx)
+	extractedCtx := tracing.Extract(context.Background(), traceContext)
+
+	assert.Contains(t, traceContext, "traceparent")
+	extractedSpanContext := trace.SpanContextFromContext(extractedCtx)
+	assert.Equal(t, span.SpanContext().TraceID(), extractedSpanContext.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), extractedSpanContext.SpanID())
+	assert.True(t, extractedSpanContext.IsRemote())
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	setUp(t)
+
+	traceContext := tracing.Inject(context.Background())
+
+	assert.Nil(t, traceContext)
+}
+
+func TestStartChildSpan(t *testing.T) {
+	recorder := setUp(t)
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	_, child := tracing.Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+}
+
+func TestFail(t *testing.T) {
+	recorder := setUp(t)
+	_, span := tracing.Start(context.Background(), "span")
+
+	tracing.Fail(span, errors.New("some error"))
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "some error", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1)
+}
+
+func TestFailIgnoresNilAndCanceled(t *testing.T) {
+	recorder := setUp(t)
+	_, span := tracing.Start(context.Background(), "span")
+
+	tracing.Fail(span, nil)
+	tracing.Fail(span, context.Canceled)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+}
+
+func TestSetupStdoutExporter(t *testing.T) {
+	shutdown, err := tracing.Setup(config.TracingConfig{Exporter: config.TracingExporterStdout, SampleRatio: 1}, "test", context.Background())
+
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+}
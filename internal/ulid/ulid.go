@@ -0,0 +1,45 @@
+// Package ulid generates ULIDs: 26 character identifiers made of a millisecond
+// timestamp and 80 random bits, which sort lexicographically by creation time.
+package ulid
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns the ULID of a random identifier created at t.
+func New(t time.Time) (string, error) {
+	var id [16]byte
+	milliseconds := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(milliseconds)
+		milliseconds >>= 8
+	}
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+
+	return encode(id), nil
+}
+
+// encode writes the 128 bits of id as 26 Crockford base32 characters, the
+// first one taking only the 3 leading bits.
+func encode(id [16]byte) string {
+	var result [26]byte
+	var buffer uint32
+	bits := 2
+	position := 0
+	for _, b := range id {
+		buffer = buffer<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			result[position] = encoding[(buffer>>bits)&31]
+			position++
+		}
+	}
+
+	return string(result[:])
+}
@@ -0,0 +1,41 @@
+package ulid_test
+
+import (
+	"postservice/internal/ulid"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEncodesTimestamp(t *testing.T) {
+	id, err := ulid.New(time.UnixMilli(1469922850259))
+
+	assert.Nil(t, err)
+	assert.Len(t, id, 26)
+	assert.Equal(t, "01ARZ3NDEK", id[:10])
+	assert.Regexp(t, "^[0-9A-HJKMNP-TV-Z]{26}$", id)
+}
+
+func TestNewSortsByTime(t *testing.T) {
+	createdAt := time.Date(2024, 8, 8, 21, 51, 20, 0, time.UTC)
+
+	first, _ := ulid.New(createdAt)
+	second, _ := ulid.New(createdAt.Add(time.Millisecond))
+	third, _ := ulid.New(createdAt.Add(time.Hour))
+
+	assert.Less(t, first, second)
+	assert.Less(t, second, third)
+}
+
+func TestNewIsRandomWithinSameMillisecond(t *testing.T) {
+	createdAt := time.Now()
+	ids := map[string]bool{}
+
+	for i := 0; i < 1000; i++ {
+		id, _ := ulid.New(createdAt)
+		ids[id] = true
+	}
+
+	assert.Len(t, ids, 1000)
+}